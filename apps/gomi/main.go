@@ -1,98 +1,372 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/kzahedi/gomi"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/progress"
+	"github.com/kzahedi/gomi/random"
+	"github.com/kzahedi/gomi/simulate"
 )
 
-func main() {
+// newFlagSet defines all command line options. The names of the options are
+// the keys that are used by Parameters.Set.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Bool("h", false, "help")
+	fs.Bool("v", false, "verbose")
+	fs.Bool("log", false, "log coverted data")
+	fs.String("cfg", "", "Config file (yaml). Values are overwritten by environment variables (GOMI_<OPTION>, e.g. GOMI_BINS) and command line options.")
+	fs.String("mi", "MI_W", "available quantifications are: MI_W, MI_A, MI_A_Prime, MI_MI, MI_SY, MI_SY_NID, MI_CA, MI_WA, MI_WS, MI_Wp, CA, CW, UI, CI, MI_IN, TE, AIS, PID, MI_SY3, PID3, or a query such as \"I(S[t+1]; S[t] | A[t])\" (see gomi info)")
+	fs.Bool("c", false, "Use continuous measure.")
+	fs.Int("cm", 1, "Continuous estimator. 1 = First KSG MI Estimator, 2 = Second KSG MI Estimator, 3 = linear-Gaussian estimator (closed form, fast, exact only for Gaussian data).")
+	fs.Bool("s", false, "Use state-dependent measure.")
+	fs.Int("bins", 0, "Optional. Only used for discrete measures. Input is single value that is used for all random variables.")
+	fs.Int("i", 100, "Optional. Maximal number of iterations, e.g. for Iterative Scaling used for MI_SY.")
+	fs.Float64("tol", 1e-6, "Optional. Iterative Scaling stops when the convergence criterion is below this tolerance. 0 = always run -i iterations.")
+	fs.String("criterion", "marginal", "Optional. Convergence criterion of Iterative Scaling: marginal = largest violation of a marginal constraint, kl = change of the KL divergence (bits).")
+	fs.Bool("trace", false, "Optional. Record the convergence criterion after each iteration in the JSON output (see -log).")
+	fs.String("o", "out.txt", "Output file.")
+	fs.String("wbins", "", "Only used for discrete measures. Input is single value that is used for all random variables that make up W. Input can also be a list of values. In this case there must a value for each variable in W.")
+	fs.String("abins", "", "Only used for discrete measures. Input is single value that is used for all random variables that make up A. Input can also be a list of values. In this case there must a value for each variable in A.")
+	fs.String("sbins", "", "Only used for discrete measures. Input is single value that is used for all random variables that make up S. Input can also be a list of values. In this case there must a value for each variable in S.")
+	fs.String("file", "", "File that contains full data set.")
+	fs.String("wi", "", "Indices of the W columns in the file given by -file.")
+	fs.String("ai", "", "Indices of the A columns in the file given by -file.")
+	fs.String("si", "", "Indices of the S columns in the file given by -file.")
+	fs.Int("ti", -1, "Index of the timestamp column in the file given by -file (optional). It is written next to the state-dependent results. -1 = no timestamps")
+	fs.Bool("values", false, "Write the raw (and, for discrete measures, the discretised) values of W', W, S, and A of each row next to the state-dependent results")
+	fs.String("wfile", "", "File that contains W data set.")
+	fs.String("afile", "", "File that contains A data set.")
+	fs.String("sfile", "", "File that contains S data set.")
+	fs.String("dfile", "", "File (yaml) that contains all min, max values for W, S, A (optional)")
+	fs.String("wmin", "", "Minimum of each W column (optional, overwrites -dfile)")
+	fs.String("wmax", "", "Maximum of each W column (optional, overwrites -dfile)")
+	fs.String("smin", "", "Minimum of each S column (optional, overwrites -dfile)")
+	fs.String("smax", "", "Maximum of each S column (optional, overwrites -dfile)")
+	fs.String("amin", "", "Minimum of each A column (optional, overwrites -dfile)")
+	fs.String("amax", "", "Maximum of each A column (optional, overwrites -dfile)")
+	fs.Bool("sparse", false, "Use Sparse Matrix Implementation (same as -storage sparse)")
+	fs.String("storage", "auto", "Storage of the discrete distributions: auto = dense if it fits into the memory budget, sparse otherwise, dense, sparse")
+	fs.String("mem", "4G", "Memory budget for the discrete distributions, e.g. 512M, 4G. 0 = no limit")
+	fs.Int("k", 30, "k used for KSG and FP estimators")
+	fs.String("norm", "auto", "Normalisation of continuous data: auto = domain if the domains are given, minmax otherwise, domain, minmax, zscore, rank (empirical copula)")
+	fs.Float64("noise", 0.0, "Amplitude of uniform noise that is added to continuous data after the normalisation to break ties. 0 = no noise")
+	fs.String("categorical", "", "Comma-separated list of the variables (W, S, A) with categorical columns, e.g. A. Continuous measures then use the mixed estimator")
+	fs.String("source", "A", "Source variable (W, S, or A) of the transfer entropy TE")
+	fs.String("target", "W", "Target variable (W, S, or A) of the transfer entropy TE and of the active information storage AIS")
+	fs.Int("history", 1, "Length of the history of the target of TE and AIS")
+	fs.Int("shistory", 1, "Length of the history of the source of TE")
+	fs.String("redundancy", "broja", "Measure of redundancy of PID: imin (Williams-Beer), broja (Bertschinger et al.), ccs (Ince), dep (James et al.)")
+	fs.String("unit", "bits", "Unit of the results of all measures and estimators: bits, nats, or bans")
+	fs.String("reference", "none", "Reference by which the results are also reported normalised: none, entropy = H(W') (discrete only), information = I(W';W,A), alphabet = log|W| (discrete only), not for MI_A_Prime and CA")
+	fs.Int64("seed", 0, "Seed of the random number generator. Results are identical for identical seeds and data")
+	return fs
+}
+
+// parseParameters returns the effective parameters. The precedence is
+// defaults < config file < environment < command line options. The options
+// in own are defined by the subcommand and are not parameters.
+func parseParameters(fs *flag.FlagSet, args []string, own ...string) gomi.Parameters {
+	fs.Parse(args)
 
-	helpPtr := flag.Bool("h", false, "help")
-	verbosePtr := flag.Bool("v", false, "verbose")
-	logPtr := flag.Bool("log", false, "log coverted data")
-	cfgPtr := flag.String("cfg", "", "Config file. If present, other command line parameters will be ignored.")
-	measurePtr := flag.String("mi", "MI_W", "available quantifications are: MI_W, MI_A, MI_A_Prime, MI_MI, MI_SY, MI_SY_NID, MI_CA, MI_WA, MI_WS, MI_Wp, CA, UI, CI, MI_IN")
-	continuousPtr := flag.Bool("c", false, "Use continuous measure.")
-	continuousModePtr := flag.Int("cm", 1, "Only required if KSG Estimator is involved. 1 = First KSG MI Estimator, 2 = Second KSG MI Estimator.")
-	stateDependentPtr := flag.Bool("s", false, "Use state-dependent measure.")
-	binsPtr := flag.Int("bins", 0, "Optional. Only used for discrete measures. Input is single value that is used for all random variables.")
-	iterationsPtr := flag.Int("i", 0, "Optional. Iterations, e.g. for Iterative Scaling used for MI_SY.")
-	outputPtr := flag.String("o", "out.txt", "Output file.")
-	wBinsPtr := flag.String("wbins", "", "Only used for discrete measures. Input is single value that is used for all random variables that make up W. Input can also be a list of values. In this case there must a value for each variable in W.")
-	aBinsPtr := flag.String("abins", "", "Only used for discrete measures. Input is single value that is used for all random variables that make up A. Input can also be a list of values. In this case there must a value for each variable in A.")
-	sBinsPtr := flag.String("sbins", "", "Only used for discrete measures. Input is single value that is used for all random variables that make up S. Input can also be a list of values. In this case there must a value for each variable in S.")
-	filePtr := flag.String("file", "", "File that contains full data set.")
-	wIndicesPtr := flag.String("wi", "", "Indices of the W columns in the file given by -file.")
-	aIndicesPtr := flag.String("ai", "", "Indices of the A columns in the file given by -file.")
-	sIndicesPtr := flag.String("si", "", "Indices of the S columns in the file given by -file.")
-	wFilePtr := flag.String("wfile", "", "File that contains W data set.")
-	aFilePtr := flag.String("afile", "", "File that contains A data set.")
-	sFilePtr := flag.String("sfile", "", "File that contains S data set.")
-	dFilePtr := flag.String("dfile", "", "File (yaml) that contains all min, max values for W, S, A (optional)")
-	sparsePtr := flag.Bool("sparse", false, "Use Sparse Matrix Implementation")
-	knnPtr := flag.Int("k", 30, "k used for KSG and FP estimators")
-	flag.Parse()
-
-	if *helpPtr == true {
-		flag.PrintDefaults()
+	if fs.Lookup("h").Value.String() == "true" {
+		fs.PrintDefaults()
 		os.Exit(0)
 	}
 
 	p := gomi.CreateParametersContainer()
 
-	if *cfgPtr != "" {
-		p.SetConfigFile(*cfgPtr)
-	}
-
-	p.SetMeasureName(*measurePtr)
-	p.SetUseContinuous(*continuousPtr)
-	p.SetContinuousMode(*continuousModePtr)
-	p.SetUseStateDependent(*stateDependentPtr)
-	p.SetGlobalBins(*binsPtr)
-	p.SetWBins(*wBinsPtr)
-	p.SetSBins(*sBinsPtr)
-	p.SetABins(*aBinsPtr)
-	p.SetK(*knnPtr)
-	p.SetOutput(*outputPtr)
-	p.SetVerbose(*verbosePtr)
-	p.SetGlobalFile(*filePtr)
-	p.SetWIndices(*wIndicesPtr)
-	p.SetSIndices(*sIndicesPtr)
-	p.SetAIndices(*aIndicesPtr)
-	p.SetWFile(*wFilePtr)
-	p.SetSFile(*sFilePtr)
-	p.SetAFile(*aFilePtr)
-	p.SetDFile(*dFilePtr)
-	p.SetIterations(*iterationsPtr)
-	p.SetLogData(*logPtr)
-	p.SetUseSparseMatrix(*sparsePtr)
+	cfg, _ := os.LookupEnv(gomi.EnvironmentVariable("cfg"))
+	if isSet(fs, "cfg") {
+		cfg = fs.Lookup("cfg").Value.String()
+	}
+	exitOnError(p.SetConfigFile(cfg))
+	exitOnError(p.SetFromEnvironment(os.LookupEnv))
 
-	p.CheckParameters()
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "h" || f.Name == "cfg" {
+			return
+		}
+		for _, name := range own {
+			if f.Name == name {
+				return
+			}
+		}
+		exitOnError(p.Set(f.Name, f.Value.String()))
+	})
 
-	var data gomi.Data
+	return p
+}
 
-	data.Read(p)
+func isSet(fs *flag.FlagSet, name string) (set bool) {
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+// configDump prints the effective configuration
+//
+//	gomi config dump [options]
+func configDump(args []string) {
+	p := parseParameters(newFlagSet("config dump"), args)
+	fmt.Print(gomi.DumpConfig(p))
+}
+
+// rerun recomputes the result stored in a JSON file (see -log) and compares
+// it with the stored result
+//
+//	gomi rerun [-o output] [-tol tolerance] result.json
+func rerun(args []string) {
+	fs := flag.NewFlagSet("rerun", flag.ExitOnError)
+	outputPtr := fs.String("o", "", "Output file for the recomputed result. Default is <result>-rerun.txt")
+	tolerancePtr := fs.Float64("tol", 1e-9, "Tolerance for the comparison of the results")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Println("usage: gomi rerun [-o output] [-tol tolerance] result.json")
+		fs.PrintDefaults()
+		os.Exit(-1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := gomi.Rerun(ctx, fs.Arg(0), *outputPtr, *tolerancePtr, nil)
+	exitOnError(err)
+	fmt.Println(report)
+	if len(report.DataChanges) > 0 || len(report.Differences) > 0 {
+		stop()
+		os.Exit(1)
+	}
+}
+
+// info evaluates an information-theoretic query over W, S, and A (see
+// gomi.ParseQuery) with the back end that is selected by the options
+//
+//	gomi info [options] "I(W[t+1]; W[t] | A[t])"
+func info(args []string) {
+	fs := newFlagSet("info")
+	var query string
+	if len(args) > 0 && strings.HasPrefix(args[0], "-") == false {
+		query, args = args[0], args[1:]
+	}
+	p := parseParameters(fs, args)
+	if query == "" && fs.NArg() == 1 {
+		query = fs.Arg(0)
+	}
+	if query == "" {
+		fmt.Println("usage: gomi info [options] query, e.g. gomi info -file data.csv -wi 0 -ai 1 -bins 10 \"I(W[t+1]; W[t] | A[t])\"")
+		os.Exit(-1)
+	}
+	q, err := gomi.ParseQuery(query)
+	exitOnError(err)
+	p.MeasureName = q.String()
+	run(p)
+}
+
+// simulateLoop generates a time series of a sensorimotor loop (see package
+// simulate) and writes it together with the analytic values of the measures
+// to <output>-analytic.txt
+//
+//	gomi simulate [-model binary|gaussian] [-n samples] [-seed seed] [-o output] [parameters]
+func simulateLoop(args []string) {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	modelPtr := fs.String("model", "binary", "Model: binary (Zahedi & Ay 2013) or gaussian (linear-Gaussian)")
+	nPtr := fs.Int("n", 10000, "Number of samples")
+	seedPtr := fs.Int64("seed", 0, "Seed of the random number generator")
+	outputPtr := fs.String("o", "simulation.csv", "Output file for the time series")
+	phiPtr := fs.Float64("phi", 0.5, "Coupling of the body (W -> W')")
+	psiPtr := fs.Float64("psi", 0.5, "Coupling of the controller (A -> W')")
+	chiPtr := fs.Float64("chi", 0.0, "Synergistic coupling of W and A (binary)")
+	zetaPtr := fs.Float64("zeta", 1.0, "Coupling of the sensor (W -> S)")
+	muPtr := fs.Float64("mu", 1.0, "Coupling of the policy (S -> A)")
+	sigmaPtr := fs.Float64("sigma", 1.0, "Standard deviation of the noise (gaussian)")
+	unitPtr := fs.String("unit", gomi.UnitBits, fmt.Sprintf("Unit of the analytic values: %s", strings.Join(gomi.Units, ", ")))
+	iterationsPtr := fs.Int("i", 1000, "Maximum number of iterations of the iterative scaling (binary)")
+	tolerancePtr := fs.Float64("tol", 1e-10, "Tolerance of the iterative scaling (binary)")
+	fs.Parse(args)
+
+	if *nPtr < 2 {
+		exitOnError(fmt.Errorf("the number of samples must be at least 2, got %d", *nPtr))
+	}
+	known := false
+	for _, u := range gomi.Units {
+		known = known || u == *unitPtr
+	}
+	if known == false {
+		exitOnError(fmt.Errorf("unknown unit %q, use one of %s", *unitPtr, strings.Join(gomi.Units, ", ")))
+	}
+
+	rng := random.New(*seedPtr).Stream("simulate", 0)
+	var series simulate.Series
+	var values []simulate.Value
+	var model fmt.Stringer
+	var unit string
+	var err error
+	switch *modelPtr {
+	case "binary":
+		b := simulate.Binary{Phi: *phiPtr, Psi: *psiPtr, Chi: *chiPtr, Zeta: *zetaPtr, Mu: *muPtr}
+		series = b.Simulate(rng, *nPtr)
+		values, err = b.Analytic(context.Background(),
+			discrete.Stopping{MaxIterations: *iterationsPtr, Tolerance: *tolerancePtr, Criterion: discrete.CriterionMarginal})
+		model, unit = b, gomi.UnitBits
+	case "gaussian":
+		g := simulate.Gaussian{Phi: *phiPtr, Psi: *psiPtr, Zeta: *zetaPtr, Mu: *muPtr, Sigma: *sigmaPtr}
+		series, err = g.Simulate(rng, *nPtr)
+		exitOnError(err)
+		values, err = g.Analytic()
+		model, unit = g, gomi.UnitNats
+	default:
+		err = fmt.Errorf("unknown model %q, use binary or gaussian", *modelPtr)
+	}
+	exitOnError(err)
+	for i, v := range values {
+		if v.Dimensionless == false {
+			values[i].Value = gomi.ConvertUnit(v.Value, unit, *unitPtr)
+		}
+	}
+
+	exitOnError(series.WriteCSV(*outputPtr))
+	analytic := fmt.Sprintf("%s-analytic.txt", strings.TrimSuffix(*outputPtr, ".csv"))
+	header := []string{
+		model.String(),
+		fmt.Sprintf("Samples: %d", *nPtr),
+		fmt.Sprintf("Seed: %d", *seedPtr),
+		fmt.Sprintf("Unit: %s", *unitPtr),
+		fmt.Sprintf("Data: %s with -wi 0 -si 1 -ai 2", *outputPtr),
+	}
+	exitOnError(simulate.WriteValues(analytic, header, values))
+	fmt.Printf("Wrote %s and %s\n", *outputPtr, analytic)
+}
 
-	if p.UseContinuous == true && p.UseStateDependent == true {
-		gomi.ContinuousSDCalculations(p, data)
+// validate estimates the selected measures on analytic benchmarks and
+// reports the bias and variance of the estimator (see
+// gomi.ValidateEstimators)
+//
+//	gomi validate [options] [-n samples] [-dims columns] [-runs runs] [-benchmarks gaussian,binary] [-measures MI_W,MI_A]
+func validate(args []string) {
+	fs := newFlagSet("validate")
+	samplesPtr := fs.Int("n", 10000, "Number of samples of each run")
+	dimsPtr := fs.Int("dims", 1, "Number of columns of W, S, and A")
+	runsPtr := fs.Int("runs", 10, "Number of runs (independent samples) of each benchmark")
+	benchmarksPtr := fs.String("benchmarks", strings.Join(gomi.Benchmarks, ","), fmt.Sprintf("Comma-separated list of benchmarks: %s", strings.Join(gomi.Benchmarks, ", ")))
+	measuresPtr := fs.String("measures", "", "Comma-separated list of measures. Default is the measure given by -mi")
+	p := parseParameters(fs, args, "n", "dims", "runs", "benchmarks", "measures")
+
+	measures := []string{p.MeasureName}
+	if *measuresPtr != "" {
+		measures = strings.Split(*measuresPtr, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var reporter progress.Reporter = progress.None
+	if p.Verbose {
+		bar := progress.NewBar()
+		defer bar.Finish()
+		reporter = bar
+	}
+
+	results, skipped, err := gomi.ValidateEstimators(ctx, p, strings.Split(*benchmarksPtr, ","), measures, *samplesPtr, *dimsPtr, *runsPtr, reporter)
+	exitOnError(err)
+
+	s := fmt.Sprintf("# %d runs with %d samples and %d column(s) per variable, values in %s\n", *runsPtr, *samplesPtr, *dimsPtr, p.Unit)
+	s = fmt.Sprintf("%s%s", s, gomi.ValidationTable(results))
+	for _, v := range skipped {
+		s = fmt.Sprintf("%s# skipped %s (no analytic value)\n", s, v)
+	}
+	fmt.Print(s)
+	exitOnError(ioutil.WriteFile(p.Output, []byte(s), 0644))
+}
+
+// report writes an HTML report of one or more results (see gomi.Report)
+//
+//	gomi report [-o report.html] [-window rows] result.json [result.json ...]
+func report(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	outputPtr := fs.String("o", "report.html", "Output file for the report")
+	windowPtr := fs.Int("window", 0, "Number of rows of the moving average of the point-wise values. 0 = 1/50 of the rows")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Println("usage: gomi report [-o report.html] [-window rows] result.json [result.json ...]")
+		fs.PrintDefaults()
+		os.Exit(-1)
+	}
+	exitOnError(gomi.Report(fs.Args(), *outputPtr, *windowPtr))
+	fmt.Printf("Wrote %s\n", *outputPtr)
+}
+
+func main() {
+
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "dump" {
+		configDump(os.Args[3:])
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rerun" {
+		rerun(os.Args[2:])
+		os.Exit(0)
 	}
-	if p.UseContinuous == true && p.UseStateDependent == false {
-		gomi.ContinuousAvgCalculations(p, data)
+
+	if len(os.Args) > 1 && os.Args[1] == "info" {
+		info(os.Args[2:])
+		os.Exit(0)
 	}
 
-	if p.UseContinuous == false && p.UseSparseMatrix == true && p.UseStateDependent == false {
-		gomi.DiscreteAvgCalculationsSparse(p, data)
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		report(os.Args[2:])
+		os.Exit(0)
 	}
-	if p.UseContinuous == false && p.UseSparseMatrix == true && p.UseStateDependent == true {
-		gomi.DiscreteSDCalculationsSparse(p, data)
+
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		validate(os.Args[2:])
+		os.Exit(0)
 	}
 
-	if p.UseContinuous == false && p.UseSparseMatrix == false && p.UseStateDependent == false {
-		gomi.DiscreteAvgCalculations(p, data)
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		simulateLoop(os.Args[2:])
+		os.Exit(0)
 	}
-	if p.UseContinuous == false && p.UseSparseMatrix == false && p.UseStateDependent == true {
-		gomi.DiscreteSDCalculations(p, data)
+
+	run(parseParameters(newFlagSet("gomi"), os.Args[1:]))
+}
+
+// run validates the parameters, reads the data and calculates the measure
+func run(p gomi.Parameters) {
+	p.CheckParameters()
+
+	var data gomi.Data
+
+	data.Read(p)
+
+	// the computation is stopped on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var reporter progress.Reporter = progress.None
+	if p.Verbose {
+		bar := progress.NewBar()
+		defer bar.Finish()
+		reporter = bar
 	}
+
+	exitOnError(gomi.Calculate(ctx, p, data, reporter))
 }
@@ -1,49 +1,94 @@
 package gomi
 
+import (
+	"fmt"
+	"strconv"
+)
+
+const maxInt = int(^uint(0) >> 1)
+
 // CalculateWBins returns the number of bins for the world states depending
 // on provided data
-func CalculateWBins(p Parameters, d Data) int {
-	wBins := 1
-	if len(p.WBins) > 0 {
-		for _, v := range p.WBins {
-			wBins *= v
-		}
-	} else {
-		for i := 0; i < len(d.W[0]); i++ {
-			wBins *= p.GlobalBins
-		}
+func CalculateWBins(p Parameters, d Data) (int, error) {
+	if len(d.W) == 0 {
+		return 0, fmt.Errorf("W is empty")
 	}
-	return wBins
+	return alphabetSize("W", columnBins(p.WBins, p.GlobalBins, len(d.W[0])))
 }
 
 // CalculateABins returns the number of bins for the actuator states depending
 // on provided data
-func CalculateABins(p Parameters, d Data) int {
-	aBins := 1
-	if len(p.ABins) > 0 {
-		for _, v := range p.ABins {
-			aBins *= v
-		}
-	} else {
-		for i := 0; i < len(d.W[0]); i++ {
-			aBins *= p.GlobalBins
-		}
+func CalculateABins(p Parameters, d Data) (int, error) {
+	if len(d.A) == 0 {
+		return 0, fmt.Errorf("A is empty")
 	}
-	return aBins
+	return alphabetSize("A", columnBins(p.ABins, p.GlobalBins, len(d.A[0])))
 }
 
 // CalculateSBins returns the number of bins for the sensor states depending
 // on provided data
-func CalculateSBins(p Parameters, d Data) int {
-	sBins := 1
-	if len(p.SBins) > 0 {
-		for _, v := range p.SBins {
-			sBins *= v
+func CalculateSBins(p Parameters, d Data) (int, error) {
+	if len(d.S) == 0 {
+		return 0, fmt.Errorf("S is empty")
+	}
+	return alphabetSize("S", columnBins(p.SBins, p.GlobalBins, len(d.S[0])))
+}
+
+// columnBins returns the number of bins of each column. A single value in
+// bins is used for all columns, without bins the global number of bins is
+// used.
+func columnBins(bins []int, global, columns int) []int {
+	if len(bins) > 1 {
+		return bins
+	}
+	b := global
+	if len(bins) == 1 {
+		b = bins[0]
+	}
+	r := make([]int, columns, columns)
+	for i := range r {
+		r[i] = b
+	}
+	return r
+}
+
+// alphabetSize returns the product of the bins, i.e. the number of possible
+// joint states, or an error if the product does not fit into an int
+func alphabetSize(name string, bins []int) (int, error) {
+	n := 1
+	for _, b := range bins {
+		if b < 1 {
+			return 0, fmt.Errorf("%s: number of bins must be at least 1, got %d", name, b)
+		}
+		if n > maxInt/b {
+			return 0, fmt.Errorf("%s: the alphabet of %d columns with %v bins has more than %d states", name, len(bins), bins, maxInt)
+		}
+		n *= b
+	}
+	return n, nil
+}
+
+// relabel maps each observed joint state (row) to a label 0, ..., n-1 in
+// the order of the first occurrence, where n is the number of observed
+// states. In contrast to dh.MakeUnivariateRelabelled, the rows are not
+// combined into one number first, so the size of the alphabet cannot
+// overflow.
+func relabel(data [][]int) []int {
+	labels := map[string]int{}
+	r := make([]int, len(data), len(data))
+	var key []byte
+	for i, row := range data {
+		key = key[:0]
+		for _, v := range row {
+			key = strconv.AppendInt(key, int64(v), 10)
+			key = append(key, ',')
 		}
-	} else {
-		for i := 0; i < len(d.W[0]); i++ {
-			sBins *= p.GlobalBins
+		label, ok := labels[string(key)]
+		if ok == false {
+			label = len(labels)
+			labels[string(key)] = label
 		}
+		r[i] = label
 	}
-	return sBins
+	return r
 }
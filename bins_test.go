@@ -1,6 +1,7 @@
 package gomi
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -28,10 +29,16 @@ func createPGlobalBins(v int) Parameters {
 	return r
 }
 
+// createData returns data with two W columns, one A column, and three S
+// columns
 func createData() Data {
 	d := Data{W: nil, A: nil, S: nil, Discretised: DataDiscretised{W: nil, S: nil, A: nil}}
 	d.W = make([][]float64, 10, 10)
 	d.W[0] = make([]float64, 2, 2)
+	d.A = make([][]float64, 10, 10)
+	d.A[0] = make([]float64, 1, 1)
+	d.S = make([][]float64, 10, 10)
+	d.S[0] = make([]float64, 3, 3)
 	return d
 }
 
@@ -41,14 +48,15 @@ func Test_CalculateWBins(t *testing.T) {
 		d Data
 	}
 	tests := []struct {
-		name string
-		args args
-		want int
+		name    string
+		args    args
+		want    int
+		wantErr bool
 	}{
 		{name: "WBins with only one value.",
 			args: args{p: createPWBins([]int{10}),
 				d: createData()},
-			want: 10},
+			want: 10 * 10},
 		{name: "WBins with only two values.",
 			args: args{p: createPWBins([]int{10, 5}),
 				d: createData()},
@@ -61,11 +69,19 @@ func Test_CalculateWBins(t *testing.T) {
 			args: args{p: createPGlobalBins(123),
 				d: createData()},
 			want: 15129},
+		{name: "WBins overflow.",
+			args: args{p: createPWBins([]int{1 << 40, 1 << 40}),
+				d: createData()},
+			wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := CalculateWBins(tt.args.p, tt.args.d); got != tt.want {
-				t.Errorf("calculateWBins() = %v, want %v", got, tt.want)
+			got, err := CalculateWBins(tt.args.p, tt.args.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateWBins() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CalculateWBins() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -77,9 +93,10 @@ func Test_calculateABins(t *testing.T) {
 		d Data
 	}
 	tests := []struct {
-		name string
-		args args
-		want int
+		name    string
+		args    args
+		want    int
+		wantErr bool
 	}{
 		{name: "ABins with only one value.",
 			args: args{p: createPABins([]int{10}),
@@ -96,12 +113,16 @@ func Test_calculateABins(t *testing.T) {
 		{name: "ABins with no values given.",
 			args: args{p: createPGlobalBins(123),
 				d: createData()},
-			want: 15129},
+			want: 123},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := CalculateABins(tt.args.p, tt.args.d); got != tt.want {
-				t.Errorf("calculateABins() = %v, want %v", got, tt.want)
+			got, err := CalculateABins(tt.args.p, tt.args.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateABins() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CalculateABins() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -113,14 +134,15 @@ func Test_calculateSBins(t *testing.T) {
 		d Data
 	}
 	tests := []struct {
-		name string
-		args args
-		want int
+		name    string
+		args    args
+		want    int
+		wantErr bool
 	}{
 		{name: "SBins with only one value.",
 			args: args{p: createPSBins([]int{10}),
 				d: createData()},
-			want: 10},
+			want: 10 * 10 * 10},
 		{name: "SBins with only two values.",
 			args: args{p: createPSBins([]int{10, 5}),
 				d: createData()},
@@ -132,13 +154,30 @@ func Test_calculateSBins(t *testing.T) {
 		{name: "SBins with no values given.",
 			args: args{p: createPGlobalBins(123),
 				d: createData()},
-			want: 15129},
+			want: 123 * 123 * 123},
+		{name: "SBins overflow.",
+			args: args{p: createPGlobalBins(1 << 30),
+				d: createData()},
+			wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := CalculateSBins(tt.args.p, tt.args.d); got != tt.want {
-				t.Errorf("calculateABins() = %v, want %v", got, tt.want)
+			got, err := CalculateSBins(tt.args.p, tt.args.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateSBins() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CalculateSBins() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_relabel(t *testing.T) {
+	data := [][]int{{299, 299, 299, 299, 299, 299}, {0, 1, 2, 3, 4, 5}, {299, 299, 299, 299, 299, 299}, {1, 0, 2, 3, 4, 5}}
+	got := relabel(data)
+	want := []int{0, 1, 0, 2}
+	if reflect.DeepEqual(got, want) == false {
+		t.Errorf("relabel() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package gomi
+
+import (
+	"context"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// Calculate runs the measure that is selected in the parameters on the data
+// and writes the result to the output file. Long computations report their
+// progress to r (which may be nil) and stop with ctx.Err(), if ctx is
+// cancelled. For discrete measures, the storage of the distributions is
+// selected first (see Parameters.Storage and EstimateMemory). Queries,
+// transfer entropy and active information storage (see Parameters.query)
+// are evaluated with the same back ends. If a reference is selected (see
+// Parameters.Reference), it is computed before the measure and the results
+// are written both raw and normalised.
+func Calculate(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	r = progress.OrNone(r)
+	var err error
+	if p.UseContinuous == false {
+		if p, err = p.selectStorage(data); err != nil {
+			return err
+		}
+	}
+	if p, err = p.computeReference(ctx, data, r); err != nil {
+		return err
+	}
+	if _, ok, _ := p.query(); ok {
+		return QueryCalculations(ctx, p, data, r)
+	}
+	switch {
+	case p.UseContinuous == true && p.UseStateDependent == true:
+		return ContinuousSDCalculations(ctx, p, data, r)
+	case p.UseContinuous == true && p.UseStateDependent == false:
+		_, err := ContinuousAvgCalculations(ctx, p, data, r)
+		return err
+	case p.UseSparseMatrix == true && p.UseStateDependent == false:
+		return DiscreteAvgCalculationsSparse(ctx, p, data, r)
+	case p.UseSparseMatrix == true && p.UseStateDependent == true:
+		return DiscreteSDCalculationsSparse(ctx, p, data, r)
+	case p.UseStateDependent == false:
+		return DiscreteAvgCalculations(ctx, p, data, r)
+	default:
+		return DiscreteSDCalculations(ctx, p, data, r)
+	}
+}
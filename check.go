@@ -1,8 +1,15 @@
 package gomi
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/discrete/pid"
 )
 
 func checkW(d Data) {
@@ -46,3 +53,313 @@ func checkSd(d Data) {
 		os.Exit(0)
 	}
 }
+
+// ParameterError lists all problems that were found by Validate
+type ParameterError struct {
+	Problems []string
+}
+
+func (e ParameterError) Error() string {
+	s := fmt.Sprintf("%d problem(s) with the parameters:", len(e.Problems))
+	for _, p := range e.Problems {
+		s = fmt.Sprintf("%s\n  - %s", s, p)
+	}
+	return s
+}
+
+// variableSpec collects everything that is known about one random variable
+// (W, S, or A) before the data is read
+type variableSpec struct {
+	name     string
+	option   string // name of the command line option of the data file
+	required bool
+	file     string
+	indices  []int
+	bins     []int
+	min, max []float64
+}
+
+// Validate checks the parameters against the requirements of the selected
+// measure and mode, and the shape of the data files. All problems are
+// reported at once in a ParameterError.
+func (p Parameters) Validate() error {
+	var problems []string
+	add := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	mode := p.mode()
+	spec, known := p.lookupMeasure()
+	_, query, queryErr := p.query()
+	switch {
+	case query && queryErr != nil:
+		add("%s", queryErr)
+	case known == false:
+		add("unknown measure %q (-mi), available measures are %s", p.MeasureName, strings.Join(MeasureNames, ", "))
+	case spec.modes == 0:
+		add("measure %s is not implemented yet", p.MeasureName)
+	case spec.modes&mode == 0:
+		add("measure %s is not available in %s mode, available measures are %s", p.MeasureName, modeName(mode), strings.Join(availableIn(mode), ", "))
+	}
+
+	switch {
+	case isKnownReference(p.Reference) == false:
+		add("unknown reference %q (-reference), use one of %s", p.Reference, strings.Join(References, ", "))
+	case p.Reference == ReferenceAlphabet && p.UseContinuous:
+		add("reference %s (-reference) is only available for discrete measures, use %s", ReferenceAlphabet, ReferenceInformation)
+	case p.Reference == ReferenceEntropy && p.UseContinuous:
+		add("reference %s (-reference) is only available for discrete measures, because the differential entropy H(W') can be zero or negative, use %s", ReferenceEntropy, ReferenceInformation)
+	case p.useReference() && spec.dimensionless:
+		add("measure %s is dimensionless and cannot be normalised by a reference (-reference), use %s", p.MeasureName, ReferenceNone)
+	}
+	if isKnownUnit(p.Unit) == false {
+		add("unknown unit %q (-unit), use one of %s", p.Unit, strings.Join(Units, ", "))
+	}
+	// the reference is estimated from the same data as the measure
+	if p.useReference() {
+		spec.W = true
+		spec.A = spec.A || p.Reference == ReferenceInformation
+	}
+
+	if p.UseContinuous {
+		if p.UseSparseMatrix {
+			add("the sparse matrix implementation (-sparse) is only available for discrete measures, remove -sparse or -c")
+		}
+		if p.ContinuousMode < 1 || p.ContinuousMode > 3 {
+			add("continuous mode (-cm) must be 1, 2, or 3, got %d", p.ContinuousMode)
+		}
+		if p.K < 1 {
+			add("k (-k) must be at least 1, got %d", p.K)
+		}
+		if isKnownNormalisation(p.Normalisation) == false {
+			add("unknown normalisation %q (-norm), use one of %s", p.Normalisation, strings.Join(NormalisationModes, ", "))
+		}
+		if p.Normalisation == NormalisationDomain {
+			for _, v := range []struct {
+				name     string
+				required bool
+				min      []float64
+			}{{"W", spec.W, p.WorldMin}, {"S", spec.S, p.SensorMin}, {"A", spec.A, p.ActuatorMin}} {
+				if v.required && len(v.min) == 0 {
+					add("normalisation %s requires the domain of %s (-dfile or -%smin, -%smax)", NormalisationDomain, v.name, strings.ToLower(v.name), strings.ToLower(v.name))
+				}
+			}
+		}
+		if p.Noise < 0.0 {
+			add("the noise (-noise) must not be negative, got %g", p.Noise)
+		}
+		for _, v := range p.Categorical {
+			if isVariable(v) == false {
+				add("categorical variables (-categorical) must be W, S, or A, got %s", v)
+			}
+		}
+		if len(p.Categorical) > 0 {
+			if hasMixedEstimator(p.MeasureName) == false && query == false && p.MeasureName != "CA" && p.MeasureName != "CW" {
+				add("measure %s is not available with categorical variables (-categorical)", p.MeasureName)
+			}
+			if (hasMixedEstimator(p.MeasureName) || query) && p.ContinuousMode != 1 {
+				add("categorical variables (-categorical) require continuous mode 1 (-cm), whose KSG estimator is generalised by the mixed estimator, got %d", p.ContinuousMode)
+			}
+		}
+	} else {
+		if len(p.Categorical) > 0 {
+			add("categorical variables (-categorical) are only used with continuous data (-c)")
+		}
+		if isKnownStorage(p.Storage) == false {
+			add("unknown storage %q (-storage), use %s, %s, or %s", p.Storage, StorageAuto, StorageDense, StorageSparse)
+		}
+		if p.MemoryBudget < 0 {
+			add("the memory budget (-mem) must not be negative, got %d", p.MemoryBudget)
+		}
+		if spec.iterative && p.Iterations < 1 {
+			add("measure %s uses iterative scaling and requires at least one iteration (-i), got %d", p.MeasureName, p.Iterations)
+		}
+		if spec.iterative && p.Tolerance < 0.0 {
+			add("the tolerance (-tol) must not be negative, got %g", p.Tolerance)
+		}
+		if spec.iterative && isKnownCriterion(p.Criterion) == false {
+			add("unknown convergence criterion %q (-criterion), use %s or %s", p.Criterion, discrete.CriterionKL, discrete.CriterionMarginal)
+		}
+		if p.MeasureName == "PID" && pid.IsMeasure(p.Redundancy) == false {
+			add("unknown measure of redundancy %q (-redundancy), use one of %s", p.Redundancy, strings.Join(pid.Measures, ", "))
+		}
+	}
+
+	if p.TimeIndex < -1 {
+		add("the time index (-ti) must be a column index or -1, got %d", p.TimeIndex)
+	}
+
+	if p.Output == "" {
+		add("no output file given (-o)")
+	} else if dir := filepath.Dir(p.Output); checkFile(dir) == false {
+		add("directory %s of the output file (-o) does not exist", dir)
+	}
+
+	variables := []variableSpec{
+		{name: "W", option: "-wfile", required: spec.W, file: p.WFile, indices: p.WIndices, bins: p.WBins, min: p.WorldMin, max: p.WorldMax},
+		{name: "S", option: "-sfile", required: spec.S, file: p.SFile, indices: p.SIndices, bins: p.SBins, min: p.SensorMin, max: p.SensorMax},
+		{name: "A", option: "-afile", required: spec.A, file: p.AFile, indices: p.AIndices, bins: p.ABins, min: p.ActuatorMin, max: p.ActuatorMax},
+	}
+
+	if p.GlobalFile != "" {
+		for _, v := range variables {
+			if v.file != "" {
+				add("both -file and %s are given, %s is ignored when -file is used", v.option, v.file)
+			}
+		}
+	}
+
+	// number of rows and columns of each variable, -1 if unknown
+	rows := map[string]int{}
+	columns := map[string]int{}
+
+	if p.GlobalFile != "" {
+		r, c, err := csvShape(p.GlobalFile)
+		if err != nil {
+			add("cannot read data file (-file): %s", err)
+		}
+		for _, v := range variables {
+			rows[v.name], columns[v.name] = -1, -1
+			if len(v.indices) == 0 {
+				if v.required {
+					add("measure %s requires %s, but no %s indices are given (-%si)", p.MeasureName, v.name, v.name, strings.ToLower(v.name))
+				}
+				continue
+			}
+			if err == nil {
+				rows[v.name] = r
+				for _, i := range v.indices {
+					if i < 0 || i >= c {
+						add("%s index %d is out of range, %s has %d columns (valid indices are 0 to %d)", v.name, i, p.GlobalFile, c, c-1)
+					}
+				}
+			}
+			columns[v.name] = len(v.indices)
+		}
+		if err == nil && p.TimeIndex >= c {
+			add("time index %d (-ti) is out of range, %s has %d columns (valid indices are 0 to %d)", p.TimeIndex, p.GlobalFile, c, c-1)
+		}
+	} else {
+		if p.TimeIndex >= 0 {
+			add("the time index (-ti) is only used together with -file")
+		}
+		for _, v := range variables {
+			rows[v.name], columns[v.name] = -1, -1
+			if len(v.indices) > 0 {
+				add("%s indices (-%si) are only used together with -file", v.name, strings.ToLower(v.name))
+			}
+			if v.file == "" {
+				if v.required {
+					add("measure %s requires %s, but no %s data is given (%s or -file)", p.MeasureName, v.name, v.name, v.option)
+				}
+				continue
+			}
+			r, c, err := csvShape(v.file)
+			if err != nil {
+				add("cannot read %s data file (%s): %s", v.name, v.option, err)
+				continue
+			}
+			rows[v.name], columns[v.name] = r, c
+		}
+	}
+
+	var withRows []string
+	for _, v := range variables {
+		if rows[v.name] >= 0 {
+			withRows = append(withRows, v.name)
+		}
+	}
+	for i := 1; i < len(withRows); i++ {
+		if r0, r := rows[withRows[0]], rows[withRows[i]]; r != r0 {
+			add("%s has %d rows, but %s has %d rows, all data files must have the same number of rows", withRows[0], r0, withRows[i], r)
+		}
+	}
+	if q, ok, err := p.query(); ok && err == nil && len(withRows) > 0 {
+		if min, max := q.offsets(); rows[withRows[0]] <= max-min+1 {
+			add("query %s spans %d time steps, but the data has only %d rows", q, max-min+1, rows[withRows[0]])
+		}
+	}
+	if p.UseContinuous && p.K >= 1 && len(withRows) > 0 && p.K >= rows[withRows[0]] {
+		add("k (-k) must be smaller than the number of rows, got k = %d and %d rows", p.K, rows[withRows[0]])
+	}
+
+	for _, v := range variables {
+		n := columns[v.name]
+		if p.UseContinuous == false && v.required && len(v.bins) == 0 && p.GlobalBins < 1 {
+			add("discrete measures require the number of bins for %s (-bins or -%sbins) to be at least 1, got %d", v.name, strings.ToLower(v.name), p.GlobalBins)
+		}
+		if p.UseContinuous == false && spec.normalisedBy == v.name && n > 0 {
+			if _, err := alphabetSize(v.name, columnBins(v.bins, p.GlobalBins, n)); err != nil {
+				add("%s is normalised by the size of the alphabet of %s: %s", p.MeasureName, v.name, err)
+			}
+		}
+		if p.UseContinuous == false && len(v.bins) > 0 {
+			if n >= 0 && len(v.bins) != 1 && len(v.bins) != n {
+				add("%d %s bins (-%sbins) are given, but %s has %d columns, give either one value or one value per column", len(v.bins), v.name, strings.ToLower(v.name), v.name, n)
+			}
+			for _, b := range v.bins {
+				if b < 1 {
+					add("%s bins (-%sbins) must be at least 1, got %d", v.name, strings.ToLower(v.name), b)
+					break
+				}
+			}
+		}
+		if len(v.min) != len(v.max) {
+			add("%d %s minima but %d %s maxima are given", len(v.min), v.name, len(v.max), v.name)
+			continue
+		}
+		if n >= 0 && len(v.min) > 0 && len(v.min) != n {
+			add("%d %s domains (minimum, maximum) are given, but %s has %d columns", len(v.min), v.name, v.name, n)
+		}
+		for i := range v.min {
+			if v.min[i] >= v.max[i] {
+				add("%s domain of column %d is empty, minimum %f is not smaller than maximum %f", v.name, i, v.min[i], v.max[i])
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return ParameterError{Problems: problems}
+	}
+	return nil
+}
+
+// csvShape returns the number of data rows and the number of columns of a
+// csv file. Empty lines, comments (#), and a non-numeric header are skipped.
+func csvShape(filename string) (rows, columns int, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if first {
+			first = false
+			if _, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64); err != nil {
+				continue
+			}
+		}
+		if columns == 0 {
+			columns = len(fields)
+		} else if len(fields) != columns {
+			return rows, columns, fmt.Errorf("%s: row %d has %d columns, expected %d", filename, rows+1, len(fields), columns)
+		}
+		rows++
+	}
+	if err := scanner.Err(); err != nil {
+		return rows, columns, err
+	}
+	if rows == 0 {
+		return rows, columns, fmt.Errorf("%s contains no data", filename)
+	}
+	return rows, columns, nil
+}
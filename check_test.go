@@ -0,0 +1,127 @@
+package gomi
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCsv(t *testing.T, dir, name, content string) string {
+	filename := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	global := writeCsv(t, dir, "data.csv", "w,s,a\n0.1,0.2,0.3\n0.4,0.5,0.6\n0.7,0.8,0.9\n")
+	short := writeCsv(t, dir, "short.csv", "0.1\n0.2\n")
+	long := writeCsv(t, dir, "long.csv", "0.1\n0.2\n0.3\n")
+
+	tests := []struct {
+		name     string
+		set      func(p *Parameters)
+		problems []string
+	}{
+		{name: "Valid global file",
+			set: func(p *Parameters) { p.SetGlobalFile(global); p.SetWIndices("0"); p.SetAIndices("2") }},
+		{name: "Missing file",
+			set:      func(p *Parameters) { p.SetWFile(filepath.Join(dir, "missing.csv")); p.SetAFile(long) },
+			problems: []string{"cannot read W data file"}},
+		{name: "Index out of range",
+			set:      func(p *Parameters) { p.SetGlobalFile(global); p.SetWIndices("0,3"); p.SetAIndices("2") },
+			problems: []string{"W index 3 is out of range"}},
+		{name: "Different number of rows",
+			set:      func(p *Parameters) { p.SetWFile(short); p.SetAFile(long) },
+			problems: []string{"W has 2 rows, but A has 3 rows"}},
+		{name: "Missing S for MI_MI",
+			set:      func(p *Parameters) { p.SetMeasureName("MI_MI"); p.SetWFile(long); p.SetAFile(long) },
+			problems: []string{"requires S"}},
+		{name: "Missing S and A for CA",
+			set:      func(p *Parameters) { p.SetMeasureName("CA"); p.SetGlobalFile(global); p.SetWIndices("0") },
+			problems: []string{"requires S", "requires A"}},
+		{name: "Too many W bins",
+			set:      func(p *Parameters) { p.SetWFile(long); p.SetAFile(long); p.SetWBins("10,10") },
+			problems: []string{"2 W bins (-wbins) are given, but W has 1 columns"}},
+		{name: "Per-variable bins without global bins",
+			set:      func(p *Parameters) { p.SetGlobalBins(0); p.SetWFile(long); p.SetAFile(long); p.SetWBins("10") },
+			problems: []string{"number of bins for A (-bins or -abins)"}},
+		{name: "Alphabet overflow",
+			set: func(p *Parameters) {
+				p.SetMeasureName("MI_IN")
+				p.SetGlobalFile(global)
+				p.SetSIndices("1")
+				p.SetAIndices("0,1,2")
+				p.SetABins("3000000")
+			},
+			problems: []string{"normalised by the size of the alphabet of A"}},
+		{name: "Mode constraints",
+			set: func(p *Parameters) {
+				p.SetMeasureName("MI_SY")
+				p.SetUseContinuous(true)
+				p.SetUseSparseMatrix(true)
+				p.SetK(3)
+				p.SetWFile(long)
+				p.SetAFile(long)
+			},
+			problems: []string{"not available in continuous", "-sparse", "k (-k) must be smaller"}},
+		{name: "Categorical variables",
+			set: func(p *Parameters) {
+				p.SetMeasureName("MI_W")
+				p.SetUseContinuous(true)
+				p.SetContinuousMode(3)
+				p.SetK(1)
+				p.Categorical = []string{"A", "X"}
+				p.SetWFile(long)
+				p.SetAFile(long)
+			},
+			problems: []string{"must be W, S, or A, got X", "require continuous mode 1"}},
+		{name: "Continuous entropy reference",
+			set: func(p *Parameters) {
+				p.SetUseContinuous(true)
+				p.SetK(1)
+				p.Reference = ReferenceEntropy
+				p.SetWFile(long)
+				p.SetAFile(long)
+			},
+			problems: []string{"differential entropy"}},
+		{name: "Reference of a dimensionless measure",
+			set: func(p *Parameters) {
+				p.SetMeasureName("MI_A_Prime")
+				p.Reference = ReferenceInformation
+				p.SetWFile(long)
+				p.SetAFile(long)
+			},
+			problems: []string{"MI_A_Prime is dimensionless"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CreateParametersContainer()
+			p.SetGlobalBins(10)
+			p.SetOutput(filepath.Join(dir, "out.txt"))
+			tt.set(&p)
+			err := p.Validate()
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Errorf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			pe, ok := err.(ParameterError)
+			if ok == false {
+				t.Fatalf("Validate() = %v, want ParameterError", err)
+			}
+			if len(pe.Problems) != len(tt.problems) {
+				t.Errorf("Validate() reports %d problems, want %d:\n%v", len(pe.Problems), len(tt.problems), err)
+			}
+			for _, want := range tt.problems {
+				if strings.Contains(err.Error(), want) == false {
+					t.Errorf("Validate() = %v, want problem containing %q", err, want)
+				}
+			}
+		})
+	}
+}
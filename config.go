@@ -0,0 +1,641 @@
+package gomi
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/discrete/pid"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// intList is a list of integers in a configuration file. It accepts a yaml
+// list ([1, 2, 3]) and a single value (3). The comma-separated strings
+// ("1,2,3") of version 1 are converted by migrateConfig.
+type intList []int
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *intList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var lst []int
+	if err := unmarshal(&lst); err == nil {
+		*l = lst
+		return nil
+	}
+	var v int
+	if err := unmarshal(&v); err == nil {
+		*l = intList{v}
+		return nil
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return fmt.Errorf("comma-separated lists such as \"%s\" are only supported in version 1 of the configuration file, use a yaml list such as [%s]", s, s)
+}
+
+// configListKeys are the keys of the lists of integers, which version 1 of
+// the configuration file gives as comma-separated strings
+var configListKeys = []string{"W Bins", "S Bins", "A Bins", "W Indices", "S Indices", "A Indices"}
+
+// migrateConfig converts the content of a configuration file of version 1
+// (or without version) to version 2, in which the lists of integers are yaml
+// lists, e.g. "W Indices: 1,2,3" becomes "W Indices: [1, 2, 3]". The content
+// of other versions is returned unchanged.
+func migrateConfig(data []byte) ([]byte, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	version := 1
+	for _, item := range m {
+		if item.Key == "Version" {
+			v, ok := item.Value.(int)
+			if ok == false {
+				return nil, fmt.Errorf("Version must be an integer, got %v", item.Value)
+			}
+			version = v
+		}
+	}
+	if version != 1 {
+		return data, nil
+	}
+
+	for i, item := range m {
+		s, ok := item.Value.(string)
+		if ok == false || containsString(configListKeys, fmt.Sprint(item.Key)) == false {
+			continue
+		}
+		lst, err := parseIntList(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: cannot parse \"%s\" as list of integers", item.Key, s)
+		}
+		m[i].Value = lst
+	}
+	return yaml.Marshal(m)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CfgT is the schema of the configuration file. Every key is optional. Keys
+// that are not given leave the corresponding parameter untouched, unknown
+// keys are rejected.
+type CfgT struct {
+	Version        *int       `yaml:"Version,omitempty"`
+	Measure        *string    `yaml:"Measure,omitempty"`
+	Continuous     *bool      `yaml:"Continuous,omitempty"`
+	ContinuousMode *int       `yaml:"Continuous mode,omitempty"`
+	UseState       *bool      `yaml:"State-dependent,omitempty"`
+	Sparse         *bool      `yaml:"Sparse matrix,omitempty"`
+	Storage        *string    `yaml:"Storage,omitempty"`
+	MemoryBudget   *string    `yaml:"Memory budget,omitempty"`
+	Verbose        *bool      `yaml:"Verbose,omitempty"`
+	Log            *bool      `yaml:"Log data,omitempty"`
+	Bins           *int       `yaml:"Bins,omitempty"`
+	Iterations     *int       `yaml:"Iterations,omitempty"`
+	Tolerance      *float64   `yaml:"Tolerance,omitempty"`
+	Criterion      *string    `yaml:"Convergence criterion,omitempty"`
+	Trace          *bool      `yaml:"Convergence trace,omitempty"`
+	K              *int       `yaml:"k,omitempty"`
+	Normalisation  *string    `yaml:"Normalisation,omitempty"`
+	Noise          *float64   `yaml:"Noise,omitempty"`
+	Categorical    *[]string  `yaml:"Categorical,omitempty"`
+	Seed           *int64     `yaml:"Seed,omitempty"`
+	Source         *string    `yaml:"Source,omitempty"`
+	Target         *string    `yaml:"Target,omitempty"`
+	History        *int       `yaml:"History,omitempty"`
+	SourceHistory  *int       `yaml:"Source history,omitempty"`
+	Redundancy     *string    `yaml:"Redundancy,omitempty"`
+	Reference      *string    `yaml:"Reference,omitempty"`
+	Unit           *string    `yaml:"Unit,omitempty"`
+	Output         *string    `yaml:"Output file,omitempty"`
+	WBins          *intList   `yaml:"W Bins,omitempty"`
+	ABins          *intList   `yaml:"A Bins,omitempty"`
+	SBins          *intList   `yaml:"S Bins,omitempty"`
+	WIndices       *intList   `yaml:"W Indices,omitempty"`
+	AIndices       *intList   `yaml:"A Indices,omitempty"`
+	SIndices       *intList   `yaml:"S Indices,omitempty"`
+	TimeIndex      *int       `yaml:"Time Index,omitempty"`
+	WriteValues    *bool      `yaml:"Write values,omitempty"`
+	File           *string    `yaml:"Full data file,omitempty"`
+	WFile          *string    `yaml:"W data file,omitempty"`
+	AFile          *string    `yaml:"A data file,omitempty"`
+	SFile          *string    `yaml:"S data file,omitempty"`
+	DFile          *string    `yaml:"Domain file,omitempty"`
+	WorldMin       *[]float64 `yaml:"W min,omitempty"`
+	WorldMax       *[]float64 `yaml:"W max,omitempty"`
+	SensorMin      *[]float64 `yaml:"S min,omitempty"`
+	SensorMax      *[]float64 `yaml:"S max,omitempty"`
+	ActuatorMin    *[]float64 `yaml:"A min,omitempty"`
+	ActuatorMax    *[]float64 `yaml:"A max,omitempty"`
+}
+
+// ParseConfig parses and validates the content of a configuration file.
+// Files of version 1 are migrated to the current version first (see
+// migrateConfig).
+func ParseConfig(data []byte) (CfgT, error) {
+	t := CfgT{}
+	data, err := migrateConfig(data)
+	if err != nil {
+		return t, err
+	}
+	if err := yaml.UnmarshalStrict(data, &t); err != nil {
+		return t, err
+	}
+	if msgs := t.check(); len(msgs) > 0 {
+		return t, errors.New(strings.Join(msgs, "\n"))
+	}
+	return t, nil
+}
+
+func (t CfgT) check() (msgs []string) {
+	if t.Version != nil && (*t.Version < 1 || *t.Version > configVersion) {
+		msgs = append(msgs, fmt.Sprintf("Version %d is not supported (supported are 1 to %d)", *t.Version, configVersion))
+	}
+	if t.Measure != nil && isKnownMeasure(*t.Measure) == false {
+		msgs = append(msgs, fmt.Sprintf("Measure: unknown measure %s (available are %s)", *t.Measure, strings.Join(MeasureNames, ", ")))
+	}
+	if t.ContinuousMode != nil && (*t.ContinuousMode < 1 || *t.ContinuousMode > 3) {
+		msgs = append(msgs, fmt.Sprintf("Continuous mode: must be 1, 2, or 3, got %d", *t.ContinuousMode))
+	}
+	if t.K != nil && *t.K < 1 {
+		msgs = append(msgs, fmt.Sprintf("k: must be positive, got %d", *t.K))
+	}
+	if t.Normalisation != nil && isKnownNormalisation(*t.Normalisation) == false {
+		msgs = append(msgs, fmt.Sprintf("Normalisation: must be one of %s, got %s", strings.Join(NormalisationModes, ", "), *t.Normalisation))
+	}
+	if t.Categorical != nil {
+		for _, v := range *t.Categorical {
+			if isVariable(v) == false {
+				msgs = append(msgs, fmt.Sprintf("Categorical: must contain W, S, or A, got %s", v))
+			}
+		}
+	}
+	for _, v := range []struct {
+		key   string
+		value *string
+	}{{"Source", t.Source}, {"Target", t.Target}} {
+		if v.value != nil && isVariable(*v.value) == false {
+			msgs = append(msgs, fmt.Sprintf("%s: must be W, S, or A, got %s", v.key, *v.value))
+		}
+	}
+	if t.Redundancy != nil && pid.IsMeasure(*t.Redundancy) == false {
+		msgs = append(msgs, fmt.Sprintf("Redundancy: must be one of %s, got %s", strings.Join(pid.Measures, ", "), *t.Redundancy))
+	}
+	if t.Reference != nil && isKnownReference(*t.Reference) == false {
+		msgs = append(msgs, fmt.Sprintf("Reference: must be one of %s, got %s", strings.Join(References, ", "), *t.Reference))
+	}
+	if t.Unit != nil && isKnownUnit(*t.Unit) == false {
+		msgs = append(msgs, fmt.Sprintf("Unit: must be one of %s, got %s", strings.Join(Units, ", "), *t.Unit))
+	}
+	if t.Noise != nil && *t.Noise < 0.0 {
+		msgs = append(msgs, fmt.Sprintf("Noise: must not be negative, got %g", *t.Noise))
+	}
+	if t.Bins != nil && *t.Bins < 0 {
+		msgs = append(msgs, fmt.Sprintf("Bins: must not be negative, got %d", *t.Bins))
+	}
+	if t.Iterations != nil && *t.Iterations < 0 {
+		msgs = append(msgs, fmt.Sprintf("Iterations: must not be negative, got %d", *t.Iterations))
+	}
+	if t.Tolerance != nil && *t.Tolerance < 0.0 {
+		msgs = append(msgs, fmt.Sprintf("Tolerance: must not be negative, got %g", *t.Tolerance))
+	}
+	if t.Storage != nil && isKnownStorage(*t.Storage) == false {
+		msgs = append(msgs, fmt.Sprintf("Storage: must be %s, %s, or %s, got %s", StorageAuto, StorageDense, StorageSparse, *t.Storage))
+	}
+	if t.MemoryBudget != nil {
+		if _, err := parseByteSize(*t.MemoryBudget); err != nil {
+			msgs = append(msgs, fmt.Sprintf("Memory budget: %s", err))
+		}
+	}
+	if t.Criterion != nil && isKnownCriterion(*t.Criterion) == false {
+		msgs = append(msgs, fmt.Sprintf("Convergence criterion: must be %s or %s, got %s", discrete.CriterionKL, discrete.CriterionMarginal, *t.Criterion))
+	}
+	for _, l := range []struct {
+		key    string
+		values *intList
+		min    int
+	}{{"W Bins", t.WBins, 1}, {"S Bins", t.SBins, 1}, {"A Bins", t.ABins, 1},
+		{"W Indices", t.WIndices, 0}, {"S Indices", t.SIndices, 0}, {"A Indices", t.AIndices, 0}} {
+		if l.values == nil {
+			continue
+		}
+		for _, v := range *l.values {
+			if v < l.min {
+				msgs = append(msgs, fmt.Sprintf("%s: value %d must be at least %d", l.key, v, l.min))
+			}
+		}
+	}
+	for _, d := range []struct {
+		name     string
+		min, max *[]float64
+	}{{"W", t.WorldMin, t.WorldMax}, {"S", t.SensorMin, t.SensorMax}, {"A", t.ActuatorMin, t.ActuatorMax}} {
+		if (d.min == nil) != (d.max == nil) {
+			msgs = append(msgs, fmt.Sprintf("%s min and %s max must be given together", d.name, d.name))
+			continue
+		}
+		if d.min != nil && len(*d.min) != len(*d.max) {
+			msgs = append(msgs, fmt.Sprintf("%s min has %d values, but %s max has %d", d.name, len(*d.min), d.name, len(*d.max)))
+		}
+	}
+	return
+}
+
+// Apply copies all values that are given in the configuration to the
+// parameters
+func (t CfgT) Apply(p *Parameters) error {
+	if t.Measure != nil {
+		p.MeasureName = *t.Measure
+	}
+	if t.Continuous != nil {
+		p.UseContinuous = *t.Continuous
+	}
+	if t.ContinuousMode != nil {
+		p.ContinuousMode = *t.ContinuousMode
+	}
+	if t.UseState != nil {
+		p.UseStateDependent = *t.UseState
+	}
+	if t.Sparse != nil {
+		p.SetUseSparseMatrix(*t.Sparse)
+	}
+	if t.Storage != nil {
+		if err := p.SetStorage(*t.Storage); err != nil {
+			return err
+		}
+	}
+	if t.MemoryBudget != nil {
+		budget, err := parseByteSize(*t.MemoryBudget)
+		if err != nil {
+			return err
+		}
+		p.MemoryBudget = budget
+	}
+	if t.Verbose != nil {
+		p.Verbose = *t.Verbose
+	}
+	if t.Log != nil {
+		p.LogData = *t.Log
+	}
+	if t.Bins != nil {
+		p.GlobalBins = *t.Bins
+	}
+	if t.Iterations != nil {
+		p.Iterations = *t.Iterations
+	}
+	if t.Tolerance != nil {
+		p.Tolerance = *t.Tolerance
+	}
+	if t.Criterion != nil {
+		p.Criterion = *t.Criterion
+	}
+	if t.Trace != nil {
+		p.Trace = *t.Trace
+	}
+	if t.K != nil {
+		p.K = *t.K
+	}
+	if t.Normalisation != nil {
+		p.Normalisation = *t.Normalisation
+	}
+	if t.Noise != nil {
+		p.Noise = *t.Noise
+	}
+	if t.Categorical != nil {
+		p.Categorical = *t.Categorical
+	}
+	if t.Seed != nil {
+		p.Seed = *t.Seed
+	}
+	if t.Source != nil {
+		p.Source = *t.Source
+	}
+	if t.Target != nil {
+		p.Target = *t.Target
+	}
+	if t.History != nil {
+		p.History = *t.History
+	}
+	if t.SourceHistory != nil {
+		p.SourceHistory = *t.SourceHistory
+	}
+	if t.Redundancy != nil {
+		p.Redundancy = *t.Redundancy
+	}
+	if t.Reference != nil {
+		p.Reference = *t.Reference
+	}
+	if t.Unit != nil {
+		p.Unit = *t.Unit
+	}
+	if t.Output != nil {
+		p.Output = *t.Output
+	}
+	if t.WBins != nil {
+		p.WBins = *t.WBins
+	}
+	if t.SBins != nil {
+		p.SBins = *t.SBins
+	}
+	if t.ABins != nil {
+		p.ABins = *t.ABins
+	}
+	if t.WIndices != nil {
+		p.WIndices = *t.WIndices
+	}
+	if t.SIndices != nil {
+		p.SIndices = *t.SIndices
+	}
+	if t.AIndices != nil {
+		p.AIndices = *t.AIndices
+	}
+	if t.TimeIndex != nil {
+		p.TimeIndex = *t.TimeIndex
+	}
+	if t.WriteValues != nil {
+		p.WriteValues = *t.WriteValues
+	}
+	if t.File != nil {
+		p.GlobalFile = *t.File
+	}
+	if t.WFile != nil {
+		p.WFile = *t.WFile
+	}
+	if t.SFile != nil {
+		p.SFile = *t.SFile
+	}
+	if t.AFile != nil {
+		p.AFile = *t.AFile
+	}
+	if t.DFile != nil {
+		if err := p.SetDFile(*t.DFile); err != nil {
+			return err
+		}
+	}
+	// inline domains take precedence over the domain file
+	if t.WorldMin != nil {
+		p.SetWMinMax(*t.WorldMin, *t.WorldMax)
+	}
+	if t.SensorMin != nil {
+		p.SetSMinMax(*t.SensorMin, *t.SensorMax)
+	}
+	if t.ActuatorMin != nil {
+		p.SetAMinMax(*t.ActuatorMin, *t.ActuatorMax)
+	}
+	return nil
+}
+
+// ConfigFromParameters returns the configuration that reproduces the given
+// parameters
+func ConfigFromParameters(p Parameters) CfgT {
+	version := configVersion
+	wBins := intList(p.WBins)
+	sBins := intList(p.SBins)
+	aBins := intList(p.ABins)
+	wIndices := intList(p.WIndices)
+	sIndices := intList(p.SIndices)
+	aIndices := intList(p.AIndices)
+	memoryBudget := formatByteSize(p.MemoryBudget)
+	return CfgT{
+		Version:        &version,
+		Measure:        &p.MeasureName,
+		Continuous:     &p.UseContinuous,
+		ContinuousMode: &p.ContinuousMode,
+		UseState:       &p.UseStateDependent,
+		Storage:        &p.Storage,
+		MemoryBudget:   &memoryBudget,
+		Verbose:        &p.Verbose,
+		Log:            &p.LogData,
+		Bins:           &p.GlobalBins,
+		Iterations:     &p.Iterations,
+		Tolerance:      &p.Tolerance,
+		Criterion:      &p.Criterion,
+		Trace:          &p.Trace,
+		K:              &p.K,
+		Normalisation:  &p.Normalisation,
+		Noise:          &p.Noise,
+		Categorical:    &p.Categorical,
+		Seed:           &p.Seed,
+		Source:         &p.Source,
+		Target:         &p.Target,
+		History:        &p.History,
+		SourceHistory:  &p.SourceHistory,
+		Redundancy:     &p.Redundancy,
+		Reference:      &p.Reference,
+		Unit:           &p.Unit,
+		Output:         &p.Output,
+		WBins:          &wBins,
+		SBins:          &sBins,
+		ABins:          &aBins,
+		WIndices:       &wIndices,
+		SIndices:       &sIndices,
+		AIndices:       &aIndices,
+		TimeIndex:      &p.TimeIndex,
+		WriteValues:    &p.WriteValues,
+		File:           &p.GlobalFile,
+		WFile:          &p.WFile,
+		SFile:          &p.SFile,
+		AFile:          &p.AFile,
+		DFile:          &p.DFile,
+		WorldMin:       &p.WorldMin,
+		WorldMax:       &p.WorldMax,
+		SensorMin:      &p.SensorMin,
+		SensorMax:      &p.SensorMax,
+		ActuatorMin:    &p.ActuatorMin,
+		ActuatorMax:    &p.ActuatorMax}
+}
+
+// DumpConfig returns the effective configuration in the format of the
+// configuration file
+func DumpConfig(p Parameters) string {
+	bytes, err := yaml.Marshal(ConfigFromParameters(p))
+	if err != nil {
+		panic(err)
+	}
+	return string(bytes)
+}
+
+// SetConfigFile reads the configuration file and applies its values to the
+// parameters
+func (p *Parameters) SetConfigFile(file string) error {
+	p.ConfigFile = file
+	if p.ConfigFile == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(p.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	t, err := ParseConfig(data)
+	if err != nil {
+		return fmt.Errorf("config file %s:\n%s", p.ConfigFile, err)
+	}
+
+	return t.Apply(p)
+}
+
+// ParameterKeys are the keys that are accepted by Parameters.Set. They are
+// equal to the names of the command line options.
+var ParameterKeys = []string{"mi", "c", "cm", "s", "sparse", "storage", "mem", "v", "log", "bins", "i", "tol", "criterion", "trace", "k", "norm", "noise", "categorical", "seed", "source", "target", "history", "shistory", "redundancy", "reference", "unit", "o",
+	"wbins", "sbins", "abins", "wi", "si", "ai", "ti", "values", "file", "wfile", "sfile", "afile", "dfile",
+	"wmin", "wmax", "smin", "smax", "amin", "amax"}
+
+// Set sets the parameter with the given key (see ParameterKeys) from its
+// string representation
+func (p *Parameters) Set(key, value string) (err error) {
+	switch key {
+	case "mi":
+		p.MeasureName = value
+	case "c":
+		p.UseContinuous, err = strconv.ParseBool(value)
+	case "cm":
+		p.ContinuousMode, err = strconv.Atoi(value)
+	case "s":
+		p.UseStateDependent, err = strconv.ParseBool(value)
+	case "sparse":
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			p.SetUseSparseMatrix(b)
+		}
+	case "storage":
+		err = p.SetStorage(value)
+	case "mem":
+		p.MemoryBudget, err = parseByteSize(value)
+	case "v":
+		p.Verbose, err = strconv.ParseBool(value)
+	case "log":
+		p.LogData, err = strconv.ParseBool(value)
+	case "bins":
+		p.GlobalBins, err = strconv.Atoi(value)
+	case "i":
+		p.Iterations, err = strconv.Atoi(value)
+	case "tol":
+		p.Tolerance, err = strconv.ParseFloat(value, 64)
+	case "criterion":
+		p.Criterion = value
+	case "trace":
+		p.Trace, err = strconv.ParseBool(value)
+	case "k":
+		p.K, err = strconv.Atoi(value)
+	case "norm":
+		p.Normalisation = value
+	case "noise":
+		p.Noise, err = strconv.ParseFloat(value, 64)
+	case "categorical":
+		p.Categorical = parseStringList(value)
+	case "seed":
+		p.Seed, err = strconv.ParseInt(value, 10, 64)
+	case "source":
+		p.Source = value
+	case "target":
+		p.Target = value
+	case "history":
+		p.History, err = strconv.Atoi(value)
+	case "shistory":
+		p.SourceHistory, err = strconv.Atoi(value)
+	case "redundancy":
+		p.Redundancy = value
+	case "reference":
+		p.Reference = value
+	case "unit":
+		p.Unit = value
+	case "o":
+		p.Output = value
+	case "wbins":
+		p.WBins, err = parseIntList(value)
+	case "sbins":
+		p.SBins, err = parseIntList(value)
+	case "abins":
+		p.ABins, err = parseIntList(value)
+	case "wi":
+		p.WIndices, err = parseIntList(value)
+	case "si":
+		p.SIndices, err = parseIntList(value)
+	case "ai":
+		p.AIndices, err = parseIntList(value)
+	case "ti":
+		p.TimeIndex, err = strconv.Atoi(value)
+	case "values":
+		p.WriteValues, err = strconv.ParseBool(value)
+	case "file":
+		p.GlobalFile = value
+	case "wfile":
+		p.WFile = value
+	case "sfile":
+		p.SFile = value
+	case "afile":
+		p.AFile = value
+	case "dfile":
+		err = p.SetDFile(value)
+	case "wmin":
+		p.WorldMin, err = parseFloatList(value)
+	case "wmax":
+		p.WorldMax, err = parseFloatList(value)
+	case "smin":
+		p.SensorMin, err = parseFloatList(value)
+	case "smax":
+		p.SensorMax, err = parseFloatList(value)
+	case "amin":
+		p.ActuatorMin, err = parseFloatList(value)
+	case "amax":
+		p.ActuatorMax, err = parseFloatList(value)
+	default:
+		return fmt.Errorf("unknown parameter %s", key)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set %s to \"%s\": %s", key, value, err)
+	}
+	return nil
+}
+
+// SetFromEnvironment sets all parameters for which an environment variable
+// GOMI_<KEY> is defined, e.g. GOMI_BINS=300. The lookup function is usually
+// os.LookupEnv.
+func (p *Parameters) SetFromEnvironment(lookup func(string) (string, bool)) error {
+	var msgs []string
+	for _, key := range ParameterKeys {
+		if value, ok := lookup(EnvironmentVariable(key)); ok {
+			if err := p.Set(key, value); err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s: %s", EnvironmentVariable(key), err))
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// EnvironmentVariable returns the name of the environment variable for the
+// given parameter key
+func EnvironmentVariable(key string) string {
+	return environmentPrefix + strings.ToUpper(key)
+}
+
+func isKnownCriterion(name string) bool {
+	return name == discrete.CriterionKL || name == discrete.CriterionMarginal
+}
+
+func isKnownMeasure(name string) bool {
+	if isQuery(name) {
+		_, err := ParseQuery(name)
+		return err == nil
+	}
+	for _, n := range MeasureNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package gomi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "Version 1 with comma-separated lists",
+			data: "Measure: MI_W\nW Indices: 1,2,3\nA Bins:\n", wantErr: false},
+		{name: "Version 2 with yaml lists",
+			data: "Version: 2\nW Indices: [1, 2, 3]\nW Bins: 300\n", wantErr: false},
+		{name: "Version 2 with comma-separated lists",
+			data: "Version: 2\nW Indices: 1,2,3\n", wantErr: true},
+		{name: "Version 1 with invalid list",
+			data: "W Indices: 1,x\n", wantErr: true},
+		{name: "Unknown key",
+			data: "Measure: MI_W\nBinz: 300\n", wantErr: true},
+		{name: "Unknown measure",
+			data: "Measure: MI_X\n", wantErr: true},
+		{name: "Unsupported version",
+			data: "Version: 99\n", wantErr: true},
+		{name: "Domain without maximum",
+			data: "W min: [0.0, 1.0]\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseConfig([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("ParseConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// the configuration file yaml/cfg.yaml of version 1
+const configV1 = `Measure: MI_W
+Continuous: true
+Continuous mode: 1
+State-dependent: true
+Verbose: true
+Bins: 300
+Iterations: 1000
+k: 100
+Output file: out.csv
+W Bins:
+A Bins:
+S Bins:
+W Indices: 1,2,3
+A Indices: 9
+S Indices: 4
+Full data file: data.csv
+W data file:
+A data file:
+S data file:
+Domain file:
+`
+
+func TestMigrateConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(configV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := CreateParametersContainer()
+	if err := cfg.Apply(&p); err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(p.WIndices, []int{1, 2, 3}) == false || reflect.DeepEqual(p.AIndices, []int{9}) == false ||
+		reflect.DeepEqual(p.SIndices, []int{4}) == false {
+		t.Errorf("indices = %v, %v, %v, want [1 2 3], [9], [4]", p.WIndices, p.AIndices, p.SIndices)
+	}
+	if p.GlobalBins != 300 || p.K != 100 || p.GlobalFile != "data.csv" {
+		t.Errorf("Bins = %d, k = %d, file = %s, want 300, 100, data.csv", p.GlobalBins, p.K, p.GlobalFile)
+	}
+
+	// the migrated file is a valid file of the current version
+	migrated, err := migrateConfig([]byte(configV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseConfig(append([]byte("Version: 2\n"), migrated...)); err != nil {
+		t.Errorf("ParseConfig() of the migrated file: %v", err)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	p := CreateParametersContainer()
+	cfg, err := ParseConfig([]byte("Bins: 10\nk: 5\nVerbose: true\nW Indices: [1, 2]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Apply(&p); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"GOMI_K": "7"}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	if err := p.SetFromEnvironment(lookup); err != nil {
+		t.Fatal(err)
+	}
+	// command line options may reset values to their defaults
+	if err := p.Set("v", "false"); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.GlobalBins != 10 {
+		t.Errorf("GlobalBins = %d, want 10 (config file)", p.GlobalBins)
+	}
+	if p.K != 7 {
+		t.Errorf("K = %d, want 7 (environment)", p.K)
+	}
+	if p.Verbose != false {
+		t.Errorf("Verbose = %t, want false (command line)", p.Verbose)
+	}
+	if p.Iterations != defaultIterations {
+		t.Errorf("Iterations = %d, want %d (default)", p.Iterations, defaultIterations)
+	}
+	if reflect.DeepEqual(p.WIndices, []int{1, 2}) == false {
+		t.Errorf("WIndices = %v, want [1 2]", p.WIndices)
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	p := CreateParametersContainer()
+	p.SetMeasureName("MI_A")
+	p.SetWIndices("1,2,3")
+	p.SetWMinMax([]float64{-1.0, -2.0, -3.0}, []float64{1.0, 2.0, 3.0})
+
+	cfg, err := ParseConfig([]byte(DumpConfig(p)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := CreateParametersContainer()
+	if err := cfg.Apply(&q); err != nil {
+		t.Fatal(err)
+	}
+	if q.String() != p.String() {
+		t.Errorf("DumpConfig() does not reproduce the parameters:\n%s\nwant\n%s", q, p)
+	}
+}
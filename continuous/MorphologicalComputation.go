@@ -1,31 +1,50 @@
 package continuous
 
 import (
-	"github.com/kzahedi/goent/continuous"
+	"context"
+	"fmt"
+
+	"github.com/kzahedi/gomi/progress"
 )
 
 // MorphologicalComputationW [...]
-func MorphologicalComputationW(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) float64 {
-	return continuous.FrenzelPompe(w2w1a1, w2Indices, w1Indices, a1Indices, k, eta)
+func MorphologicalComputationW(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_W", w2w1a1, k, r, FP(w2Indices, w1Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0], nil
 }
 
 // MorphologicalComputationA [...]
-func MorphologicalComputationA(w2w1a1 [][]float64, w2Indices, a1Indices, w1Indices []int, k int, eta bool) float64 {
-	return continuous.FrenzelPompe(w2w1a1, w2Indices, a1Indices, w1Indices, k, eta)
+func MorphologicalComputationA(ctx context.Context, w2w1a1 [][]float64, w2Indices, a1Indices, w1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_A", w2w1a1, k, r, FP(w2Indices, a1Indices, w1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0], nil
 }
 
 // MorphologicalComputationCW1 [...]
-func MorphologicalComputationCW1(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) float64 {
-	return continuous.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, w1Indices, k, false) - continuous.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, a1Indices, k, eta)
+func MorphologicalComputationCW1(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_CW (KSG 1)", w2w1a1, k, r, KSG1(w2Indices, w1Indices), KSG1(w2Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationCW2 [...]
-func MorphologicalComputationCW2(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) float64 {
-	return continuous.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, w1Indices, k, false) - continuous.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, a1Indices, k, eta)
+func MorphologicalComputationCW2(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_CW (KSG 2)", w2w1a1, k, r, KSG2(w2Indices, w1Indices), KSG2(w2Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationWA1 = I(W;{W,A}) - I(W';A)
-func MorphologicalComputationWA1(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) float64 {
+func MorphologicalComputationWA1(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
 	n := len(w1Indices) + len(a1Indices)
 	w1a1Indices := make([]int, n, n)
 	index := 0
@@ -38,11 +57,15 @@ func MorphologicalComputationWA1(w2w1a1 [][]float64, w2Indices, w1Indices, a1Ind
 		index++
 	}
 
-	return continuous.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, w1a1Indices, k, false) - continuous.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, a1Indices, k, eta)
+	v, err := estimate(ctx, "MI_WA (KSG 1)", w2w1a1, k, r, KSG1(w2Indices, w1a1Indices), KSG1(w2Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationWA2 = I(W;{W,A}) - I(W';A)
-func MorphologicalComputationWA2(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) float64 {
+func MorphologicalComputationWA2(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
 	n := len(w1Indices) + len(a1Indices)
 	w1a1Indices := make([]int, n, n)
 	index := 0
@@ -55,11 +78,15 @@ func MorphologicalComputationWA2(w2w1a1 [][]float64, w2Indices, w1Indices, a1Ind
 		index++
 	}
 
-	return continuous.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, w1a1Indices, k, false) - continuous.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, a1Indices, k, eta)
+	v, err := estimate(ctx, "MI_WA (KSG 2)", w2w1a1, k, r, KSG2(w2Indices, w1a1Indices), KSG2(w2Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationWS1 = I(W;{W,S}) - I(W';S)
-func MorphologicalComputationWS1(w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, eta bool) float64 {
+func MorphologicalComputationWS1(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, r progress.Reporter) (float64, error) {
 	n := len(w1Indices) + len(s1Indices)
 	w1s1Indices := make([]int, n, n)
 	index := 0
@@ -72,11 +99,15 @@ func MorphologicalComputationWS1(w2w1s1 [][]float64, w2Indices, w1Indices, s1Ind
 		index++
 	}
 
-	return continuous.KraskovStoegbauerGrassberger1(w2w1s1, w2Indices, w1s1Indices, k, false) - continuous.KraskovStoegbauerGrassberger1(w2w1s1, w2Indices, s1Indices, k, eta)
+	v, err := estimate(ctx, "MI_WS (KSG 1)", w2w1s1, k, r, KSG1(w2Indices, w1s1Indices), KSG1(w2Indices, s1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationWS2 = I(W;{W,S}) - I(W';S)
-func MorphologicalComputationWS2(w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, eta bool) float64 {
+func MorphologicalComputationWS2(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, r progress.Reporter) (float64, error) {
 	n := len(w1Indices) + len(s1Indices)
 	w1s1Indices := make([]int, n, n)
 	index := 0
@@ -89,29 +120,127 @@ func MorphologicalComputationWS2(w2w1s1 [][]float64, w2Indices, w1Indices, s1Ind
 		index++
 	}
 
-	return continuous.KraskovStoegbauerGrassberger2(w2w1s1, w2Indices, w1s1Indices, k, false) - continuous.KraskovStoegbauerGrassberger2(w2w1s1, w2Indices, s1Indices, k, eta)
+	v, err := estimate(ctx, "MI_WS (KSG 2)", w2w1s1, k, r, KSG2(w2Indices, w1s1Indices), KSG2(w2Indices, s1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationMI1 [...]
-func MorphologicalComputationMI1(w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, eta bool) float64 {
-	return continuous.KraskovStoegbauerGrassberger1(w2w1s1a1, w2Indices, w1Indices, k, false) - continuous.KraskovStoegbauerGrassberger1(w2w1s1a1, s1Indices, a1Indices, k, eta)
+func MorphologicalComputationMI1(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_MI (KSG 1)", w2w1s1a1, k, r, KSG1(w2Indices, w1Indices), KSG1(s1Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationMI2 [...]
-func MorphologicalComputationMI2(w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, eta bool) float64 {
-	return continuous.KraskovStoegbauerGrassberger2(w2w1s1a1, w2Indices, w1Indices, k, false) - continuous.KraskovStoegbauerGrassberger2(w2w1s1a1, a1Indices, s1Indices, k, eta)
+func MorphologicalComputationMI2(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_MI (KSG 2)", w2w1s1a1, k, r, KSG2(w2Indices, w1Indices), KSG2(a1Indices, s1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationCA1 quantifies morphological computation as the causal information flow from
 // W to W' that does pass through A
 // MorphologicalComputationCA = CIF(W -> W') - CIF(A -> W') = I(W';W) - I(W'|A)
-func MorphologicalComputationCA1(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) float64 {
-	return continuous.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, w1Indices, k, eta) - continuous.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, a1Indices, k, eta)
+func MorphologicalComputationCA1(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_CA (KSG 1)", w2w1a1, k, r, KSG1(w2Indices, w1Indices), KSG1(w2Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
 }
 
 // MorphologicalComputationCA2 quantifies morphological computation as the causal information flow from
 // W to W' that does pass through A
 // MorphologicalComputationCA = CIF(W -> W') - CIF(A -> W') = I(W';W) - I(W'|A)
-func MorphologicalComputationCA2(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) float64 {
-	return continuous.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, w1Indices, k, eta) - continuous.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, a1Indices, k, eta)
+func MorphologicalComputationCA2(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, error) {
+	v, err := estimate(ctx, "MI_CA (KSG 2)", w2w1a1, k, r, KSG2(w2Indices, w1Indices), KSG2(w2Indices, a1Indices))
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0] - v[1], nil
+}
+
+// MorphologicalComputationAPrime1 quantifies MI_A' on continuous data. The
+// term log|W| of the discrete measure is replaced by the upper bound
+// I(W';W,A) >= I(W';A|W), so that the result is in [0,1]:
+//    MI_A' = 1 - I(W';A|W) / I(W';W,A)
+// It returns the result and the normalisation I(W';W,A).
+func MorphologicalComputationAPrime1(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, float64, error) {
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	v, err := estimate(ctx, "MI_A_Prime (KSG 1)", w2w1a1, k, r, FP(w2Indices, a1Indices, w1Indices), KSG1(w2Indices, w1a1Indices))
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return aPrime(v[0], v[1])
+}
+
+// MorphologicalComputationAPrime2 is MorphologicalComputationAPrime1 with
+// the second KSG estimator for I(W';W,A)
+func MorphologicalComputationAPrime2(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) (float64, float64, error) {
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	v, err := estimate(ctx, "MI_A_Prime (KSG 2)", w2w1a1, k, r, FP(w2Indices, a1Indices, w1Indices), KSG2(w2Indices, w1a1Indices))
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return aPrime(v[0], v[1])
+}
+
+// aPrime returns 1 - miA/z and z, or an error if z is not positive
+func aPrime(miA, z float64) (float64, float64, error) {
+	if z <= 0.0 {
+		return 0.0, z, fmt.Errorf("MI_A_Prime is undefined, because the estimate of I(W';W,A) = %f is not positive", z)
+	}
+	return 1.0 - miA/z, z, nil
+}
+
+// MorphologicalComputationIN1 quantifies MI_IN on continuous data. The term
+// log|A| of the discrete measure, which is the maximal entropy of A, is
+// replaced by the differential entropy H(A) (Kozachenko-Leonenko estimator):
+//    MI_IN = H(A) - I(A;S)
+// It returns the result and the normalisation H(A).
+func MorphologicalComputationIN1(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices []int, k int, r progress.Reporter) (float64, float64, error) {
+	h, err := KozachenkoLeonenko(ctx, "MI_IN H(A) (Kozachenko-Leonenko)", a1s1, a1Indices, k, r)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	v, err := estimate(ctx, "MI_IN (KSG 1)", a1s1, k, r, KSG1(a1Indices, s1Indices))
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return h - v[0], h, nil
+}
+
+// MorphologicalComputationIN2 is MorphologicalComputationIN1 with the second
+// KSG estimator for I(A;S)
+func MorphologicalComputationIN2(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices []int, k int, r progress.Reporter) (float64, float64, error) {
+	h, err := KozachenkoLeonenko(ctx, "MI_IN H(A) (Kozachenko-Leonenko)", a1s1, a1Indices, k, r)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	v, err := estimate(ctx, "MI_IN (KSG 2)", a1s1, k, r, KSG2(a1Indices, s1Indices))
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return h - v[0], h, nil
+}
+
+// estimate returns the averages of the k-NN estimates of the terms (see
+// KNNEstimateLocal)
+func estimate(ctx context.Context, stage string, data [][]float64, k int, r progress.Reporter, terms ...KNN) ([]float64, error) {
+	local, err := KNNEstimateLocal(ctx, stage, data, k, r, terms...)
+	if err != nil {
+		return nil, err
+	}
+	v := make([]float64, len(local), len(local))
+	for i, l := range local {
+		v[i] = average(l)
+	}
+	return v, nil
 }
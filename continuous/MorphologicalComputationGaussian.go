@@ -0,0 +1,141 @@
+package continuous
+
+import (
+	"context"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// MorphologicalComputationWGaussian is MorphologicalComputationW with the
+// linear-Gaussian estimator
+//    MI_W = I(W';W|A)
+func MorphologicalComputationWGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) (float64, error) {
+	return gaussianEstimate(ctx, "MI_W (Gaussian)", w2w1a1, r,
+		Term{Sign: 1.0, X: w2Indices, Y: w1Indices, Z: a1Indices})
+}
+
+// MorphologicalComputationAGaussian is MorphologicalComputationA with the
+// linear-Gaussian estimator
+//    MI_A = I(W';A|W)
+func MorphologicalComputationAGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) (float64, error) {
+	return gaussianEstimate(ctx, "MI_A (Gaussian)", w2w1a1, r,
+		Term{Sign: 1.0, X: w2Indices, Y: a1Indices, Z: w1Indices})
+}
+
+// MorphologicalComputationCAGaussian is MorphologicalComputationCA1 with the
+// linear-Gaussian estimator
+//    MI_CA = I(W';W) - I(W';A)
+func MorphologicalComputationCAGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) (float64, error) {
+	return gaussianEstimate(ctx, "MI_CA (Gaussian)", w2w1a1, r,
+		Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWAGaussian is MorphologicalComputationWA1 with the
+// linear-Gaussian estimator
+//    MI_WA = I(W';{W,A}) - I(W';A)
+func MorphologicalComputationWAGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) (float64, error) {
+	return gaussianEstimate(ctx, "MI_WA (Gaussian)", w2w1a1, r,
+		Term{Sign: 1.0, X: w2Indices, Y: concat(w1Indices, a1Indices)},
+		Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWSGaussian is MorphologicalComputationWS1 with the
+// linear-Gaussian estimator
+//    MI_WS = I(W';{W,S}) - I(W';S)
+func MorphologicalComputationWSGaussian(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, r progress.Reporter) (float64, error) {
+	return gaussianEstimate(ctx, "MI_WS (Gaussian)", w2w1s1, r,
+		Term{Sign: 1.0, X: w2Indices, Y: concat(w1Indices, s1Indices)},
+		Term{Sign: -1.0, X: w2Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationMIGaussian is MorphologicalComputationMI1 with the
+// linear-Gaussian estimator
+//    MI_MI = I(W';W) - I(A;S)
+func MorphologicalComputationMIGaussian(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, r progress.Reporter) (float64, error) {
+	return gaussianEstimate(ctx, "MI_MI (Gaussian)", w2w1s1a1, r,
+		Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		Term{Sign: -1.0, X: a1Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationAPrimeGaussian is MorphologicalComputationAPrime1
+// with the linear-Gaussian estimator
+//    MI_A' = 1 - I(W';A|W) / I(W';W,A)
+func MorphologicalComputationAPrimeGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) (float64, float64, error) {
+	g, err := FitGaussian(ctx, "MI_A_Prime (Gaussian)", w2w1a1, r)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	miA, err := g.ConditionalMutualInformation(w2Indices, a1Indices, w1Indices)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	z, err := g.ConditionalMutualInformation(w2Indices, concat(w1Indices, a1Indices), nil)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return aPrime(miA, z)
+}
+
+// MorphologicalComputationINGaussian is MorphologicalComputationIN1 with the
+// linear-Gaussian estimator
+//    MI_IN = H(A) - I(A;S)
+func MorphologicalComputationINGaussian(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices []int, r progress.Reporter) (float64, float64, error) {
+	g, err := FitGaussian(ctx, "MI_IN (Gaussian)", a1s1, r)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	h, err := g.Entropy(a1Indices)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	mi, err := g.ConditionalMutualInformation(a1Indices, s1Indices, nil)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return h - mi, h, nil
+}
+
+// Term is the (conditional) mutual information Sign * I(X;Y|Z) of a measure,
+// where X, Y, and Z are given by column indices. Z may be empty.
+type Term struct {
+	Sign    float64
+	X, Y, Z []int
+}
+
+// gaussianEstimate fits a Gaussian to data and returns the sum of the terms
+func gaussianEstimate(ctx context.Context, stage string, data [][]float64, r progress.Reporter, terms ...Term) (float64, error) {
+	g, err := FitGaussian(ctx, stage, data, r)
+	if err != nil {
+		return 0.0, err
+	}
+	result := 0.0
+	for _, t := range terms {
+		v, err := g.ConditionalMutualInformation(t.X, t.Y, t.Z)
+		if err != nil {
+			return 0.0, err
+		}
+		result += t.Sign * v
+	}
+	return result, nil
+}
+
+// GaussianEstimateLocal fits a Gaussian to data and returns the sum of the
+// pointwise values of the terms for each row of data
+func GaussianEstimateLocal(ctx context.Context, stage string, data [][]float64, r progress.Reporter, terms ...Term) ([]float64, error) {
+	g, err := FitGaussian(ctx, stage, data, r)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]float64, len(data), len(data))
+	for _, t := range terms {
+		v, err := g.LocalConditionalMutualInformation(ctx, stage, data, t.X, t.Y, t.Z, r)
+		if err != nil {
+			return nil, err
+		}
+		for i := range result {
+			result[i] += t.Sign * v[i]
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package continuous
+
+import (
+	"context"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// MorphologicalComputationWMixed is MorphologicalComputationW with the mixed
+// estimator (see MixedConditionalMutualInformationLocal), where the columns
+// in categorical are discrete
+//    MI_W = I(W';W|A)
+func MorphologicalComputationWMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) (float64, error) {
+	return mixedEstimate(ctx, "MI_W (mixed)", w2w1a1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: w1Indices, Z: a1Indices})
+}
+
+// MorphologicalComputationAMixed is MorphologicalComputationA with the mixed
+// estimator
+//    MI_A = I(W';A|W)
+func MorphologicalComputationAMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) (float64, error) {
+	return mixedEstimate(ctx, "MI_A (mixed)", w2w1a1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: a1Indices, Z: w1Indices})
+}
+
+// MorphologicalComputationCAMixed is MorphologicalComputationCA1 with the
+// mixed estimator
+//    MI_CA = I(W';W) - I(W';A)
+func MorphologicalComputationCAMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) (float64, error) {
+	return mixedEstimate(ctx, "MI_CA (mixed)", w2w1a1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWAMixed is MorphologicalComputationWA1 with the
+// mixed estimator
+//    MI_WA = I(W';{W,A}) - I(W';A)
+func MorphologicalComputationWAMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) (float64, error) {
+	return mixedEstimate(ctx, "MI_WA (mixed)", w2w1a1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: concat(w1Indices, a1Indices)},
+		Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWSMixed is MorphologicalComputationWS1 with the
+// mixed estimator
+//    MI_WS = I(W';{W,S}) - I(W';S)
+func MorphologicalComputationWSMixed(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices, categorical []int, k int, r progress.Reporter) (float64, error) {
+	return mixedEstimate(ctx, "MI_WS (mixed)", w2w1s1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: concat(w1Indices, s1Indices)},
+		Term{Sign: -1.0, X: w2Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationMIMixed is MorphologicalComputationMI1 with the
+// mixed estimator
+//    MI_MI = I(W';W) - I(A;S)
+func MorphologicalComputationMIMixed(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices, categorical []int, k int, r progress.Reporter) (float64, error) {
+	return mixedEstimate(ctx, "MI_MI (mixed)", w2w1s1a1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		Term{Sign: -1.0, X: a1Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationAPrimeMixed is MorphologicalComputationAPrime1 with
+// the mixed estimator
+//    MI_A' = 1 - I(W';A|W) / I(W';W,A)
+func MorphologicalComputationAPrimeMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) (float64, float64, error) {
+	miA, err := mixedEstimate(ctx, "MI_A_Prime (mixed)", w2w1a1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: a1Indices, Z: w1Indices})
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	z, err := mixedEstimate(ctx, "MI_A_Prime (mixed)", w2w1a1, categorical, k, r,
+		Term{Sign: 1.0, X: w2Indices, Y: concat(w1Indices, a1Indices)})
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return aPrime(miA, z)
+}
+
+// MorphologicalComputationINMixed is MorphologicalComputationIN1 with the
+// mixed estimator. H(A) is the entropy of the categories, if A is
+// categorical, and the differential entropy otherwise (see MixedEntropyLocal).
+//    MI_IN = H(A) - I(A;S)
+func MorphologicalComputationINMixed(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices, categorical []int, k int, r progress.Reporter) (float64, float64, error) {
+	h, err := MixedEntropyLocal(ctx, "MI_IN H(A) (Kozachenko-Leonenko)", a1s1, a1Indices, categorical, k, r)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	mi, err := mixedEstimate(ctx, "MI_IN (mixed)", a1s1, categorical, k, r,
+		Term{Sign: 1.0, X: a1Indices, Y: s1Indices})
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	hA := average(h)
+	return hA - mi, hA, nil
+}
+
+// mixedEstimate returns the average of MixedEstimateLocal
+func mixedEstimate(ctx context.Context, stage string, data [][]float64, categorical []int, k int, r progress.Reporter, terms ...Term) (float64, error) {
+	v, err := MixedEstimateLocal(ctx, stage, data, categorical, k, r, terms...)
+	if err != nil {
+		return 0.0, err
+	}
+	return average(v), nil
+}
+
+func average(values []float64) float64 {
+	r := 0.0
+	for _, v := range values {
+		r += v
+	}
+	return r / float64(len(values))
+}
@@ -0,0 +1,131 @@
+package continuous
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// KozachenkoLeonenko returns the differential entropy (in nats) of the
+// columns of data given by indices. It uses the distance to the k-th nearest
+// neighbour in the maximum norm, which is also used by the KSG estimators:
+//    H(X) = psi(N) - psi(k) + d <log(2 rho_k(x))>
+func KozachenkoLeonenko(ctx context.Context, stage string, data [][]float64, indices []int, k int, r progress.Reporter) (float64, error) {
+	local, err := KozachenkoLeonenkoLocal(ctx, stage, data, indices, k, r)
+	if err != nil {
+		return 0.0, err
+	}
+	h := 0.0
+	for _, v := range local {
+		h += v
+	}
+	return h / float64(len(local)), nil
+}
+
+// KozachenkoLeonenkoLocal returns the pointwise values
+//    h(x) = psi(N) - psi(k) + d log(2 rho_k(x))
+// of the Kozachenko-Leonenko estimator, whose average is the entropy. The
+// samples are processed in chunks. After each chunk, the estimate stops with
+// ctx.Err() if ctx is cancelled and reports its progress to r.
+func KozachenkoLeonenkoLocal(ctx context.Context, stage string, data [][]float64, indices []int, k int, r progress.Reporter) ([]float64, error) {
+	n := len(data)
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no columns are given")
+	}
+	if k < 1 || k >= n {
+		return nil, fmt.Errorf("k must be between 1 and %d (number of samples - 1), got %d", n-1, k)
+	}
+	rho, err := kthNeighbourDistances(ctx, stage, data, indices, k, progress.OrNone(r))
+	if err != nil {
+		return nil, err
+	}
+	c := digamma(float64(n)) - digamma(float64(k))
+	d := float64(len(indices))
+	h := make([]float64, n, n)
+	for i, v := range rho {
+		if v == 0.0 {
+			return nil, fmt.Errorf("sample %d has more than %d duplicates, the entropy cannot be estimated, add noise to the data (-noise)", i, k)
+		}
+		h[i] = c + d*math.Log(2.0*v)
+	}
+	return h, nil
+}
+
+// kthNeighbourDistances returns the maximum norm distance of each sample to
+// its k-th nearest neighbour. The samples are sorted by the first column, so
+// that the search stops as soon as this coordinate alone is further away than
+// the current k-th neighbour. The samples are processed in chunks as in
+// KNNEstimateLocal.
+func kthNeighbourDistances(ctx context.Context, stage string, data [][]float64, indices []int, k int, rep progress.Reporter) ([]float64, error) {
+	n := len(data)
+	first := indices[0]
+	order := make([]int, n, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return data[order[a]][first] < data[order[b]][first] })
+
+	chunks := (n + knnChunk - 1) / knnChunk
+	rep.Report(stage, 0, chunks)
+	r := make([]float64, n, n)
+	nearest := make([]float64, k, k)
+	for start := 0; start < n; start += knnChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for p := start; p < minInt(start+knnChunk, n); p++ {
+			i := order[p]
+			for j := range nearest {
+				nearest[j] = math.Inf(1)
+			}
+			x := data[i]
+			for q := p - 1; q >= 0 && x[first]-data[order[q]][first] < nearest[k-1]; q-- {
+				insertDistance(nearest, maxDistance(x, data[order[q]], indices))
+			}
+			for q := p + 1; q < n && data[order[q]][first]-x[first] < nearest[k-1]; q++ {
+				insertDistance(nearest, maxDistance(x, data[order[q]], indices))
+			}
+			r[i] = nearest[k-1]
+		}
+		rep.Report(stage, start/knnChunk+1, chunks)
+	}
+	return r, nil
+}
+
+// insertDistance inserts d into the sorted list of the k smallest distances
+func insertDistance(nearest []float64, d float64) {
+	k := len(nearest)
+	if d >= nearest[k-1] {
+		return
+	}
+	j := k - 1
+	for j > 0 && nearest[j-1] > d {
+		nearest[j] = nearest[j-1]
+		j--
+	}
+	nearest[j] = d
+}
+
+func maxDistance(x, y []float64, indices []int) float64 {
+	r := 0.0
+	for _, i := range indices {
+		if d := math.Abs(x[i] - y[i]); d > r {
+			r = d
+		}
+	}
+	return r
+}
+
+// digamma returns the digamma function psi(x) for x > 0
+func digamma(x float64) float64 {
+	r := 0.0
+	for x < 6.0 {
+		r -= 1.0 / x
+		x++
+	}
+	f := 1.0 / (x * x)
+	return r + math.Log(x) - 0.5/x - f*(1.0/12.0-f*(1.0/120.0-f*(1.0/252.0-f*(1.0/240.0-f/132.0))))
+}
@@ -0,0 +1,80 @@
+package continuous
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+func TestKozachenkoLeonenko(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	n := 5000
+	data := make([][]float64, n, n)
+	for i := range data {
+		data[i] = []float64{rng.Float64(), rng.NormFloat64(), 2.0 * rng.NormFloat64()}
+	}
+	tests := []struct {
+		name    string
+		indices []int
+		want    float64
+	}{
+		{"uniform", []int{0}, 0.0},
+		{"normal", []int{1}, 0.5 * math.Log(2.0*math.Pi*math.E)},
+		{"bivariate normal", []int{1, 2}, math.Log(2.0*math.Pi*math.E) + math.Log(2.0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := KozachenkoLeonenko(context.Background(), "H", data, tt.indices, 4, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 0.05 {
+				t.Errorf("KozachenkoLeonenko() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKozachenkoLeonenkoDuplicates(t *testing.T) {
+	data := [][]float64{{0.0}, {0.0}, {0.0}, {1.0}}
+	if _, err := KozachenkoLeonenko(context.Background(), "H", data, []int{0}, 1, nil); err == nil {
+		t.Errorf("KozachenkoLeonenko() of duplicate samples did not return an error")
+	}
+}
+
+func TestKozachenkoLeonenkoCancel(t *testing.T) {
+	data := correlated(3*knnChunk, 0.5)
+	ctx, cancel := context.WithCancel(context.Background())
+	var reports []int
+	r := progress.Func(func(stage string, done, total int) {
+		reports = append(reports, done)
+		if done == 1 {
+			cancel()
+		}
+	})
+	if _, err := KozachenkoLeonenkoLocal(ctx, "test", data, []int{0}, 5, r); err != context.Canceled {
+		t.Errorf("KozachenkoLeonenkoLocal() error = %v, want %v", err, context.Canceled)
+	}
+	if len(reports) != 2 {
+		t.Errorf("KozachenkoLeonenkoLocal() reported %v, want 0 and 1 of 3 chunks", reports)
+	}
+}
+
+func TestDigamma(t *testing.T) {
+	eulerGamma := 0.5772156649015329
+	tests := []struct {
+		x, want float64
+	}{
+		{1.0, -eulerGamma},
+		{2.0, 1.0 - eulerGamma},
+		{10.0, 2.251752589066721},
+	}
+	for _, tt := range tests {
+		if got := digamma(tt.x); math.Abs(got-tt.want) > 1e-10 {
+			t.Errorf("digamma(%v) = %v, want %v", tt.x, got, tt.want)
+		}
+	}
+}
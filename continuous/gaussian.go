@@ -0,0 +1,242 @@
+package continuous
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// rows between two progress reports and context checks of FitGaussian
+const gaussianChunk = 1 << 16
+
+// Gaussian is a multivariate normal distribution that is fitted to data. It
+// is the basis of the linear-Gaussian (closed-form) estimator, which
+// calculates the (conditional) mutual information from the log-determinants
+// of covariance matrices. All values are given in nats.
+type Gaussian struct {
+	Mean       []float64
+	Covariance [][]float64
+}
+
+// FitGaussian estimates the mean and the (maximum likelihood) covariance of
+// all columns of data. It requires two passes over the data and
+// O(columns^2) memory, so it scales linearly with the number of samples.
+func FitGaussian(ctx context.Context, stage string, data [][]float64, r progress.Reporter) (Gaussian, error) {
+	r = progress.OrNone(r)
+	if len(data) == 0 {
+		return Gaussian{}, fmt.Errorf("cannot fit a Gaussian to empty data")
+	}
+	n := len(data)
+	m := len(data[0])
+	g := Gaussian{Mean: make([]float64, m, m), Covariance: make([][]float64, m, m)}
+	for i := range g.Covariance {
+		g.Covariance[i] = make([]float64, m, m)
+	}
+
+	total := 2 * ((n + gaussianChunk - 1) / gaussianChunk)
+	done := 0
+	r.Report(stage, done, total)
+	for start := 0; start < n; start += gaussianChunk {
+		if err := ctx.Err(); err != nil {
+			return Gaussian{}, err
+		}
+		for _, row := range data[start:minInt(start+gaussianChunk, n)] {
+			for j, v := range row {
+				g.Mean[j] += v
+			}
+		}
+		done++
+		r.Report(stage, done, total)
+	}
+	for j := range g.Mean {
+		g.Mean[j] /= float64(n)
+	}
+
+	d := make([]float64, m, m)
+	for start := 0; start < n; start += gaussianChunk {
+		if err := ctx.Err(); err != nil {
+			return Gaussian{}, err
+		}
+		for _, row := range data[start:minInt(start+gaussianChunk, n)] {
+			for j, v := range row {
+				d[j] = v - g.Mean[j]
+			}
+			for j := 0; j < m; j++ {
+				for k := 0; k <= j; k++ {
+					g.Covariance[j][k] += d[j] * d[k]
+				}
+			}
+		}
+		done++
+		r.Report(stage, done, total)
+	}
+	for j := 0; j < m; j++ {
+		for k := 0; k <= j; k++ {
+			g.Covariance[j][k] /= float64(n)
+			g.Covariance[k][j] = g.Covariance[j][k]
+		}
+	}
+	return g, ctx.Err()
+}
+
+// ConditionalMutualInformation returns I(X;Y|Z) of the Gaussian, where the
+// indices select the columns of X, Y, and Z. Z may be empty, which gives
+// I(X;Y).
+//    I(X;Y|Z) = 1/2 (log|S_XZ| + log|S_YZ| - log|S_XYZ| - log|S_Z|)
+func (g Gaussian) ConditionalMutualInformation(xIndices, yIndices, zIndices []int) (float64, error) {
+	f, err := g.factors(xIndices, yIndices, zIndices)
+	if err != nil {
+		return 0.0, err
+	}
+	return 0.5 * (f[0].logDet + f[1].logDet - f[2].logDet - f[3].logDet), nil
+}
+
+// LocalConditionalMutualInformation returns the pointwise values
+//    i(x;y|z) = log p(x,y,z) + log p(z) - log p(x,z) - log p(y,z)
+//             = c - 1/2 (m_XYZ + m_Z - m_XZ - m_YZ)
+// of the Gaussian for each row of data, where c = I(X;Y|Z) and m are the
+// squared Mahalanobis distances of the row. Their average over the data to
+// which the Gaussian was fitted equals ConditionalMutualInformation.
+func (g Gaussian) LocalConditionalMutualInformation(ctx context.Context, stage string, data [][]float64, xIndices, yIndices, zIndices []int, r progress.Reporter) ([]float64, error) {
+	r = progress.OrNone(r)
+	f, err := g.factors(xIndices, yIndices, zIndices)
+	if err != nil {
+		return nil, err
+	}
+	c := 0.5 * (f[0].logDet + f[1].logDet - f[2].logDet - f[3].logDet)
+
+	n := len(data)
+	result := make([]float64, n, n)
+	total := (n + gaussianChunk - 1) / gaussianChunk
+	r.Report(stage, 0, total)
+	for start := 0; start < n; start += gaussianChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for i := start; i < minInt(start+gaussianChunk, n); i++ {
+			q := f[2].mahalanobis(data[i], g.Mean) + f[3].mahalanobis(data[i], g.Mean) -
+				f[0].mahalanobis(data[i], g.Mean) - f[1].mahalanobis(data[i], g.Mean)
+			result[i] = c - 0.5*q
+		}
+		r.Report(stage, start/gaussianChunk+1, total)
+	}
+	return result, ctx.Err()
+}
+
+// Entropy returns the differential entropy of the selected columns
+//    H(X) = 1/2 (d log(2 pi e) + log|S_X|)
+func (g Gaussian) Entropy(indices []int) (float64, error) {
+	c, err := g.cholesky(indices)
+	if err != nil {
+		return 0.0, err
+	}
+	return 0.5 * (float64(len(indices))*math.Log(2.0*math.Pi*math.E) + c.logDet), nil
+}
+
+// LocalEntropy returns the pointwise values h(x) = -log p(x) of the
+// selected columns for each row of data
+func (g Gaussian) LocalEntropy(data [][]float64, indices []int) ([]float64, error) {
+	c, err := g.cholesky(indices)
+	if err != nil {
+		return nil, err
+	}
+	z := float64(len(indices))*math.Log(2.0*math.Pi) + c.logDet
+	result := make([]float64, len(data), len(data))
+	for i, row := range data {
+		result[i] = 0.5 * (z + c.mahalanobis(row, g.Mean))
+	}
+	return result, nil
+}
+
+// factors returns the Cholesky factors of the covariances of (X,Z), (Y,Z),
+// (X,Y,Z), and Z
+func (g Gaussian) factors(xIndices, yIndices, zIndices []int) ([]cholesky, error) {
+	if len(xIndices) == 0 || len(yIndices) == 0 {
+		return nil, fmt.Errorf("X and Y must not be empty")
+	}
+	blocks := [][]int{
+		concat(xIndices, zIndices),
+		concat(yIndices, zIndices),
+		concat(xIndices, yIndices, zIndices),
+		zIndices,
+	}
+	f := make([]cholesky, len(blocks), len(blocks))
+	for i, b := range blocks {
+		var err error
+		if f[i], err = g.cholesky(b); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// cholesky is the lower triangular Cholesky factor of the covariance of the
+// selected columns
+type cholesky struct {
+	indices []int
+	l       [][]float64
+	logDet  float64
+	buffer  []float64
+}
+
+func (g Gaussian) cholesky(indices []int) (cholesky, error) {
+	for _, index := range indices {
+		if index < 0 || index >= len(g.Mean) {
+			return cholesky{}, fmt.Errorf("column %d is out of range, the Gaussian has %d columns", index, len(g.Mean))
+		}
+	}
+	n := len(indices)
+	c := cholesky{indices: indices, l: make([][]float64, n, n), buffer: make([]float64, n, n)}
+	for i := range c.l {
+		c.l[i] = make([]float64, i+1, i+1)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s := g.Covariance[indices[i]][indices[j]]
+			for k := 0; k < j; k++ {
+				s -= c.l[i][k] * c.l[j][k]
+			}
+			if i == j {
+				if s <= 0.0 {
+					return cholesky{}, fmt.Errorf("the covariance of the columns %v is singular, e.g. because a column is constant or a linear combination of others", indices)
+				}
+				c.l[i][i] = math.Sqrt(s)
+				c.logDet += 2.0 * math.Log(c.l[i][i])
+			} else {
+				c.l[i][j] = s / c.l[j][j]
+			}
+		}
+	}
+	return c, nil
+}
+
+// mahalanobis returns (v-mean)^T S^-1 (v-mean) of the selected columns of v
+func (c cholesky) mahalanobis(v, mean []float64) float64 {
+	r := 0.0
+	for i, index := range c.indices {
+		s := v[index] - mean[index]
+		for k := 0; k < i; k++ {
+			s -= c.l[i][k] * c.buffer[k]
+		}
+		c.buffer[i] = s / c.l[i][i]
+		r += c.buffer[i] * c.buffer[i]
+	}
+	return r
+}
+
+func concat(indices ...[]int) []int {
+	var r []int
+	for _, i := range indices {
+		r = append(r, i...)
+	}
+	return r
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
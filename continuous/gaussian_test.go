@@ -0,0 +1,109 @@
+package continuous
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// gaussianData returns samples of (x, y, z) with z ~ N(0,1), x = z + e1,
+// y = x + e2, and e1, e2 ~ N(0,1). Hence, I(X;Y|Z) = 1/2 log 2 and
+// I(X;Y) = 1/2 log 3 in nats.
+func gaussianData(n int) [][]float64 {
+	rng := rand.New(rand.NewSource(1))
+	data := make([][]float64, n, n)
+	for i := range data {
+		z := rng.NormFloat64()
+		x := z + rng.NormFloat64()
+		y := x + rng.NormFloat64()
+		data[i] = []float64{x, y, z}
+	}
+	return data
+}
+
+func TestGaussianConditionalMutualInformation(t *testing.T) {
+	g, err := FitGaussian(context.Background(), "test", gaussianData(200000), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		x, y, z []int
+		want    float64
+	}{
+		{"I(X;Y|Z)", []int{0}, []int{1}, []int{2}, 0.5 * math.Log(2.0)},
+		{"I(X;Y)", []int{0}, []int{1}, nil, 0.5 * math.Log(3.0)},
+		{"I(Y;Z|X)", []int{1}, []int{2}, []int{0}, 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.ConditionalMutualInformation(tt.x, tt.y, tt.z)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("ConditionalMutualInformation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGaussianLocalConditionalMutualInformation(t *testing.T) {
+	data := gaussianData(1000)
+	g, err := FitGaussian(context.Background(), "test", data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := g.ConditionalMutualInformation([]int{0}, []int{1}, []int{2})
+	local, err := g.LocalConditionalMutualInformation(context.Background(), "test", data, []int{0}, []int{1}, []int{2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	avg := 0.0
+	for _, v := range local {
+		avg += v / float64(len(local))
+	}
+	if math.Abs(avg-want) > 1e-9 {
+		t.Errorf("average of the local values = %v, want %v", avg, want)
+	}
+}
+
+func TestGaussianLocalMutualInformationClosedForm(t *testing.T) {
+	// standard bivariate normal distribution with correlation 0.5, for which
+	//    i(x;y) = 1/2 log(1/(1-r^2)) - (x^2 - 2rxy + y^2)/(2(1-r^2)) + (x^2 + y^2)/2
+	g := Gaussian{Mean: []float64{0.0, 0.0}, Covariance: [][]float64{{1.0, 0.5}, {0.5, 1.0}}}
+	tests := []struct {
+		x, y float64
+		want float64
+	}{
+		{0.0, 0.0, 0.143841036225890},   // 1/2 log(4/3)
+		{1.0, 1.0, 0.477174369559223},   // 1/2 log(4/3) + 1/3
+		{1.0, -1.0, -0.856158963774110}, // 1/2 log(4/3) - 1
+		{2.0, 2.0, 1.477174369559223},   // 1/2 log(4/3) + 4/3
+	}
+	data := make([][]float64, len(tests), len(tests))
+	for i, tt := range tests {
+		data[i] = []float64{tt.x, tt.y}
+	}
+	local, err := g.LocalConditionalMutualInformation(context.Background(), "test", data, []int{0}, []int{1}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		if math.Abs(local[i]-tt.want) > 1e-12 {
+			t.Errorf("i(%v;%v) = %v, want %v", tt.x, tt.y, local[i], tt.want)
+		}
+	}
+}
+
+func TestGaussianSingular(t *testing.T) {
+	data := [][]float64{{0.0, 1.0}, {1.0, 1.0}, {2.0, 1.0}}
+	g, err := FitGaussian(context.Background(), "test", data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.ConditionalMutualInformation([]int{0}, []int{1}, nil); err == nil {
+		t.Errorf("ConditionalMutualInformation() of a constant column did not return an error")
+	}
+}
@@ -0,0 +1,77 @@
+package continuous
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// Information returns the (conditional) mutual information I(X;Y|Z) of the
+// term (the sign is ignored) in nats. The estimator is chosen like for the
+// measures: the mixed estimator, if columns are categorical (see
+// MixedConditionalMutualInformationLocal), and otherwise the estimator of the
+// continuous mode (1 = KSG 1 for I(X;Y), 2 = KSG 2 for I(X;Y), and
+// Frenzel-Pompe for I(X;Y|Z) in both modes, 3 = linear-Gaussian).
+func Information(ctx context.Context, data [][]float64, t Term, categorical []int, mode, k int, r progress.Reporter) (float64, error) {
+	t.Sign = 1.0
+	if len(categorical) > 0 {
+		return mixedEstimate(ctx, "I (mixed)", data, categorical, k, r, t)
+	}
+	var v []float64
+	var err error
+	switch {
+	case mode == 3:
+		return gaussianEstimate(ctx, "I (Gaussian)", data, r, t)
+	case mode != 1 && mode != 2:
+		return 0.0, fmt.Errorf("unknown continuous mode %d", mode)
+	case len(t.Z) > 0:
+		v, err = estimate(ctx, "I (FP)", data, k, r, FP(t.X, t.Y, t.Z))
+	case mode == 1:
+		v, err = estimate(ctx, "I (KSG 1)", data, k, r, KSG1(t.X, t.Y))
+	default:
+		v, err = estimate(ctx, "I (KSG 2)", data, k, r, KSG2(t.X, t.Y))
+	}
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0], nil
+}
+
+// ConditionalEntropy returns the (conditional) entropy H(X|Z) in nats, where
+// Z may be empty. It is given by
+//    H(X|Z) = H(X) - I(X;Z)
+// with the entropy of the categories or the Kozachenko-Leonenko estimator
+// for H(X) (see MixedEntropyLocal) and Information for I(X;Z). The
+// linear-Gaussian estimator (mode 3) uses the closed form.
+func ConditionalEntropy(ctx context.Context, data [][]float64, xIndices, zIndices, categorical []int, mode, k int, r progress.Reporter) (float64, error) {
+	if mode == 3 && len(categorical) == 0 {
+		g, err := FitGaussian(ctx, "H (Gaussian)", data, r)
+		if err != nil {
+			return 0.0, err
+		}
+		hXZ, err := g.Entropy(concat(xIndices, zIndices))
+		if err != nil {
+			return 0.0, err
+		}
+		hZ := 0.0
+		if len(zIndices) > 0 {
+			if hZ, err = g.Entropy(zIndices); err != nil {
+				return 0.0, err
+			}
+		}
+		return hXZ - hZ, nil
+	}
+	h, err := MixedEntropyLocal(ctx, "H (Kozachenko-Leonenko)", data, xIndices, categorical, k, r)
+	if err != nil {
+		return 0.0, err
+	}
+	if len(zIndices) == 0 {
+		return average(h), nil
+	}
+	mi, err := Information(ctx, data, Term{X: xIndices, Y: zIndices}, categorical, mode, k, r)
+	if err != nil {
+		return 0.0, err
+	}
+	return average(h) - mi, nil
+}
@@ -0,0 +1,197 @@
+package continuous
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// rows between two context checks and progress reports of the k-NN
+// estimators
+const knnChunk = 1 << 8
+
+// k-NN estimators of KNN
+const (
+	// EstimatorFP is the Frenzel-Pompe estimator of I(X;Y|Z)
+	EstimatorFP = iota
+	// EstimatorKSG1 is the first KSG estimator of I(X;Y)
+	EstimatorKSG1
+	// EstimatorKSG2 is the second KSG estimator of I(X;Y)
+	EstimatorKSG2
+)
+
+// KNN is one term of a k-NN estimate: I(X;Y) with the first or the second
+// estimator of A. Kraskov, H. Stoegbauer, and P. Grassberger. Estimating
+// mutual information. Physical Review E, 69(6):066138, 2004, or I(X;Y|Z)
+// with the estimator of S. Frenzel and B. Pompe. Partial mutual information
+// for coupling analysis of multivariate time series. Physical Review
+// Letters, 99(20):204101, 2007.
+type KNN struct {
+	Estimator int
+	X, Y, Z   []int
+}
+
+// KSG1 returns the term I(X;Y) with the first KSG estimator
+func KSG1(xIndices, yIndices []int) KNN {
+	return KNN{Estimator: EstimatorKSG1, X: xIndices, Y: yIndices}
+}
+
+// KSG2 returns the term I(X;Y) with the second KSG estimator
+func KSG2(xIndices, yIndices []int) KNN {
+	return KNN{Estimator: EstimatorKSG2, X: xIndices, Y: yIndices}
+}
+
+// FP returns the term I(X;Y|Z) with the Frenzel-Pompe estimator
+func FP(xIndices, yIndices, zIndices []int) KNN {
+	return KNN{Estimator: EstimatorFP, X: xIndices, Y: yIndices, Z: zIndices}
+}
+
+// KNNEstimateLocal returns the pointwise values of each term for each row of
+// data in nats. All distances are measured in the maximum norm. With the
+// distance eps to the k-th neighbour in the joint space, the values are
+//    KSG 1: i = psi(k) + psi(N) - psi(n_x + 1) - psi(n_y + 1)
+//    KSG 2: i = psi(k) - 1/k + psi(N) - psi(n_x) - psi(n_y)
+//    FP:    i = psi(k) - psi(n_xz + 1) - psi(n_yz + 1) + psi(n_z + 1)
+// where the n count the other samples closer than eps in the subspaces. For
+// KSG 2, n_x and n_y count the samples within the largest distance of the k
+// neighbours in X and in Y. The rows are processed in chunks. After each
+// chunk, the estimate stops with ctx.Err() if ctx is cancelled and reports
+// its progress to r.
+func KNNEstimateLocal(ctx context.Context, stage string, data [][]float64, k int, r progress.Reporter, terms ...KNN) ([][]float64, error) {
+	r = progress.OrNone(r)
+	n := len(data)
+	if k < 1 || k >= n {
+		return nil, fmt.Errorf("k must be between 1 and %d (number of samples - 1), got %d", n-1, k)
+	}
+	chunks := (n + knnChunk - 1) / knnChunk
+	total := len(terms) * chunks
+	r.Report(stage, 0, total)
+
+	result := make([][]float64, len(terms), len(terms))
+	for t, term := range terms {
+		result[t] = make([]float64, n, n)
+		xyz := newSpace(data, concat(term.X, term.Y, term.Z))
+		x, y := newSpace(data, concat(term.X, term.Z)), newSpace(data, concat(term.Y, term.Z))
+		var z *space
+		if len(term.Z) > 0 {
+			z = newSpace(data, term.Z)
+		}
+		nearest := make([]neighbour, k, k)
+		for start := 0; start < n; start += knnChunk {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			for i := start; i < minInt(start+knnChunk, n); i++ {
+				xyz.nearest(nearest, data, i)
+				switch term.Estimator {
+				case EstimatorKSG1:
+					eps := nearest[k-1].distance
+					result[t][i] = digamma(float64(k)) + digamma(float64(n)) -
+						digamma(float64(x.count(data, i, eps, false)+1)) -
+						digamma(float64(y.count(data, i, eps, false)+1))
+				case EstimatorKSG2:
+					epsX, epsY := 0.0, 0.0
+					for _, nb := range nearest {
+						epsX = math.Max(epsX, maxDistance(data[i], data[nb.index], term.X))
+						epsY = math.Max(epsY, maxDistance(data[i], data[nb.index], term.Y))
+					}
+					result[t][i] = digamma(float64(k)) - 1.0/float64(k) + digamma(float64(n)) -
+						digamma(float64(x.count(data, i, epsX, true))) -
+						digamma(float64(y.count(data, i, epsY, true)))
+				default:
+					eps := nearest[k-1].distance
+					nz := n - 1
+					if z != nil {
+						nz = z.count(data, i, eps, false)
+					}
+					result[t][i] = digamma(float64(k)) -
+						digamma(float64(x.count(data, i, eps, false)+1)) -
+						digamma(float64(y.count(data, i, eps, false)+1)) +
+						digamma(float64(nz+1))
+				}
+			}
+			r.Report(stage, t*chunks+start/knnChunk+1, total)
+		}
+	}
+	return result, nil
+}
+
+// space is a subspace of data, in which the samples are sorted by the first
+// column, so that the searches stop as soon as this coordinate alone is
+// further away than the current distance (see kthNeighbourDistances)
+type space struct {
+	indices []int
+	// order are the samples sorted by the first column and rank the
+	// position of each sample in order
+	order, rank []int
+}
+
+func newSpace(data [][]float64, indices []int) *space {
+	s := space{indices: indices, order: make([]int, len(data), len(data)), rank: make([]int, len(data), len(data))}
+	first := indices[0]
+	for i := range s.order {
+		s.order[i] = i
+	}
+	sort.Slice(s.order, func(a, b int) bool { return data[s.order[a]][first] < data[s.order[b]][first] })
+	for p, i := range s.order {
+		s.rank[i] = p
+	}
+	return &s
+}
+
+// neighbour is a sample and its distance to the current sample
+type neighbour struct {
+	index    int
+	distance float64
+}
+
+// nearest sets nearest to the k nearest neighbours of sample i, sorted by
+// distance
+func (s *space) nearest(nearest []neighbour, data [][]float64, i int) {
+	k := len(nearest)
+	for j := range nearest {
+		nearest[j] = neighbour{-1, math.Inf(1)}
+	}
+	first := s.indices[0]
+	insert := func(j int) {
+		d := maxDistance(data[i], data[j], s.indices)
+		if d >= nearest[k-1].distance {
+			return
+		}
+		l := k - 1
+		for l > 0 && nearest[l-1].distance > d {
+			nearest[l] = nearest[l-1]
+			l--
+		}
+		nearest[l] = neighbour{j, d}
+	}
+	p := s.rank[i]
+	for q := p - 1; q >= 0 && data[i][first]-data[s.order[q]][first] < nearest[k-1].distance; q-- {
+		insert(s.order[q])
+	}
+	for q := p + 1; q < len(s.order) && data[s.order[q]][first]-data[i][first] < nearest[k-1].distance; q++ {
+		insert(s.order[q])
+	}
+}
+
+// count returns the number of other samples whose distance to sample i is
+// smaller than eps, or at most eps if inclusive is true
+func (s *space) count(data [][]float64, i int, eps float64, inclusive bool) (c int) {
+	first := s.indices[0]
+	within := func(d float64) bool { return d < eps || (inclusive && d == eps) }
+	p := s.rank[i]
+	for q := p - 1; q >= 0 && within(data[i][first]-data[s.order[q]][first]); q-- {
+		if within(maxDistance(data[i], data[s.order[q]], s.indices)) {
+			c++
+		}
+	}
+	for q := p + 1; q < len(s.order) && within(data[s.order[q]][first]-data[i][first]); q++ {
+		if within(maxDistance(data[i], data[s.order[q]], s.indices)) {
+			c++
+		}
+	}
+	return
+}
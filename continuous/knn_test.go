@@ -0,0 +1,95 @@
+package continuous
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	goent "github.com/kzahedi/goent/continuous"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// correlated returns samples (x, y, z) of a Gaussian with corr(x, y) = rho
+// and an independent z
+func correlated(n int, rho float64) [][]float64 {
+	rng := rand.New(rand.NewSource(1))
+	data := make([][]float64, n, n)
+	for i := range data {
+		x := rng.NormFloat64()
+		data[i] = []float64{x, rho*x + math.Sqrt(1.0-rho*rho)*rng.NormFloat64(), rng.NormFloat64()}
+	}
+	return data
+}
+
+func TestKNNEstimateLocal(t *testing.T) {
+	data := correlated(2000, 0.8)
+	want := -0.5 * math.Log(1.0-0.8*0.8)
+	tests := []struct {
+		name string
+		term KNN
+		want float64
+	}{
+		{"KSG 1", KSG1([]int{0}, []int{1}), want},
+		{"KSG 2", KSG2([]int{0}, []int{1}), want},
+		{"FP", FP([]int{0}, []int{1}, []int{2}), want},
+		{"FP, independent", FP([]int{0}, []int{2}, []int{1}), 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, err := KNNEstimateLocal(context.Background(), "test", data, 5, nil, tt.term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := average(local[0]); math.Abs(got-tt.want) > 0.05 {
+				t.Errorf("KNNEstimateLocal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestKNNEstimateLocalGoent compares the estimates with the implementation
+// in goent, which the continuous measures used before
+func TestKNNEstimateLocalGoent(t *testing.T) {
+	data := correlated(500, 0.6)
+	k := 5
+	tests := []struct {
+		name string
+		term KNN
+		want float64
+	}{
+		{"KSG 1", KSG1([]int{0}, []int{1}), goent.KraskovStoegbauerGrassberger1(data, []int{0}, []int{1}, k, false)},
+		{"KSG 2", KSG2([]int{0}, []int{1}), goent.KraskovStoegbauerGrassberger2(data, []int{0}, []int{1}, k, false)},
+		{"KSG 1, two columns", KSG1([]int{0, 2}, []int{1}), goent.KraskovStoegbauerGrassberger1(data, []int{0, 2}, []int{1}, k, false)},
+		{"FP", FP([]int{0}, []int{1}, []int{2}), goent.FrenzelPompe(data, []int{0}, []int{1}, []int{2}, k, false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, err := KNNEstimateLocal(context.Background(), "test", data, k, nil, tt.term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := average(local[0]); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("KNNEstimateLocal() = %v, goent = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKNNEstimateLocalCancel(t *testing.T) {
+	data := correlated(3*knnChunk, 0.5)
+	ctx, cancel := context.WithCancel(context.Background())
+	var reports []int
+	r := progress.Func(func(stage string, done, total int) {
+		reports = append(reports, done)
+		if done == 1 {
+			cancel()
+		}
+	})
+	if _, err := KNNEstimateLocal(ctx, "test", data, 5, r, KSG1([]int{0}, []int{1}), KSG1([]int{0}, []int{2})); err != context.Canceled {
+		t.Errorf("KNNEstimateLocal() error = %v, want %v", err, context.Canceled)
+	}
+	if len(reports) != 2 {
+		t.Errorf("KNNEstimateLocal() reported %v, want 0 and 1 of 6 chunks", reports)
+	}
+}
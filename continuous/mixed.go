@@ -0,0 +1,191 @@
+package continuous
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// rows between two context checks and progress reports of the mixed
+// estimator
+const mixedChunk = 1 << 10
+
+// MixedConditionalMutualInformationLocal returns the pointwise values of the
+// k-NN estimator of I(X;Y|Z) for mixtures of discrete and continuous
+// variables (W. Gao, S. Kannan, S. Oh, and P. Viswanath. Estimating mutual
+// information for discrete-continuous mixtures. NIPS 2017, and O. C. Mesner
+// and C. R. Shalizi. Conditional mutual information estimation for mixed,
+// discrete and continuous data. IEEE Trans. Inf. Theory, 2020):
+//    i = psi(k~) - psi(n_xz) - psi(n_yz) + psi(n_z)
+// Columns in categorical are compared by equality (distance 0 or infinity),
+// all other columns by their absolute difference, and the distance of two
+// samples is the maximum over the columns. k~ is k, or the number of samples
+// at distance 0 if the k-th neighbour has distance 0, and the n are the
+// numbers of samples closer than the k-th neighbour in the subspaces. For
+// continuous data, the estimator equals the first KSG (Frenzel-Pompe)
+// estimator. Z may be empty, which gives I(X;Y). The values are given in nats.
+func MixedConditionalMutualInformationLocal(ctx context.Context, data [][]float64, xIndices, yIndices, zIndices, categorical []int, k int) ([]float64, error) {
+	return mixedLocal(ctx, data, xIndices, yIndices, zIndices, categorical, k, func(int) {})
+}
+
+// mixedLocal is MixedConditionalMutualInformationLocal, which processes the
+// rows in chunks. After each chunk, it stops with ctx.Err() if ctx is
+// cancelled and calls done with the number of completed chunks.
+func mixedLocal(ctx context.Context, data [][]float64, xIndices, yIndices, zIndices, categorical []int, k int, done func(chunks int)) ([]float64, error) {
+	isCategorical := map[int]bool{}
+	for _, c := range categorical {
+		isCategorical[c] = true
+	}
+	distance := func(a, b []float64, indices []int) float64 {
+		r := 0.0
+		for _, c := range indices {
+			if isCategorical[c] {
+				if a[c] != b[c] {
+					return math.Inf(1)
+				}
+				continue
+			}
+			if d := math.Abs(a[c] - b[c]); d > r {
+				r = d
+			}
+		}
+		return r
+	}
+
+	xyz := concat(xIndices, yIndices, zIndices)
+	xz := concat(xIndices, zIndices)
+	yz := concat(yIndices, zIndices)
+	n := len(data)
+	result := make([]float64, n, n)
+	nearest := make([]float64, k, k)
+	for i := 0; i < n; i++ {
+		if i%mixedChunk == 0 {
+			if i > 0 {
+				done(i / mixedChunk)
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		for j := range nearest {
+			nearest[j] = math.Inf(1)
+		}
+		for j := 0; j < n; j++ {
+			if j != i {
+				insertDistance(nearest, distance(data[i], data[j], xyz))
+			}
+		}
+
+		rho := nearest[k-1]
+		kt := k
+		within := func(d float64) bool { return d < rho }
+		switch {
+		case rho == 0.0:
+			within = func(d float64) bool { return d == 0.0 }
+			kt = 0
+			for j := 0; j < n; j++ {
+				if distance(data[i], data[j], xyz) == 0.0 {
+					kt++
+				}
+			}
+		case math.IsInf(rho, 1):
+			// less than k samples share the categories of sample i, so all of
+			// them are used as neighbours
+			rho = 0.0
+			kt = 1
+			for _, d := range nearest {
+				if math.IsInf(d, 1) == false {
+					rho = math.Max(rho, d)
+					kt++
+				}
+			}
+			within = func(d float64) bool { return d <= rho }
+		}
+
+		nxz, nyz, nz := 0, 0, 0
+		for j := 0; j < n; j++ {
+			if within(distance(data[i], data[j], xz)) {
+				nxz++
+			}
+			if within(distance(data[i], data[j], yz)) {
+				nyz++
+			}
+			if within(distance(data[i], data[j], zIndices)) {
+				nz++
+			}
+		}
+		result[i] = digamma(float64(kt)) - digamma(float64(nxz)) - digamma(float64(nyz)) + digamma(float64(nz))
+	}
+	done((n + mixedChunk - 1) / mixedChunk)
+	return result, nil
+}
+
+// MixedEstimateLocal returns the sum of the pointwise values of the terms,
+// which are estimated with MixedConditionalMutualInformationLocal. The
+// progress is reported to r after each chunk of rows of each term.
+func MixedEstimateLocal(ctx context.Context, stage string, data [][]float64, categorical []int, k int, r progress.Reporter, terms ...Term) ([]float64, error) {
+	r = progress.OrNone(r)
+	result := make([]float64, len(data), len(data))
+	chunks := (len(data) + mixedChunk - 1) / mixedChunk
+	total := len(terms) * chunks
+	r.Report(stage, 0, total)
+	for i, t := range terms {
+		v, err := mixedLocal(ctx, data, t.X, t.Y, t.Z, categorical, k, func(c int) { r.Report(stage, i*chunks+c, total) })
+		if err != nil {
+			return nil, err
+		}
+		for j := range result {
+			result[j] += t.Sign * v[j]
+		}
+	}
+	return result, nil
+}
+
+// DiscreteEntropyLocal returns the pointwise values -log p(x) of the
+// (joint) categories x of the columns of data given by indices
+func DiscreteEntropyLocal(data [][]float64, indices []int) []float64 {
+	counts := map[string]int{}
+	keys := make([]string, len(data), len(data))
+	var key []byte
+	for i, row := range data {
+		key = key[:0]
+		for _, c := range indices {
+			key = strconv.AppendUint(key, math.Float64bits(row[c]), 16)
+			key = append(key, ',')
+		}
+		keys[i] = string(key)
+		counts[keys[i]]++
+	}
+	r := make([]float64, len(data), len(data))
+	for i, key := range keys {
+		r[i] = -math.Log(float64(counts[key]) / float64(len(data)))
+	}
+	return r
+}
+
+// MixedEntropyLocal returns the pointwise values of the entropy of the
+// columns of data given by indices. If all columns are categorical, the
+// values are -log p(x) (see DiscreteEntropyLocal), and if none is, they are
+// given by the Kozachenko-Leonenko estimator. Categorical and continuous
+// columns cannot be mixed.
+func MixedEntropyLocal(ctx context.Context, stage string, data [][]float64, indices, categorical []int, k int, r progress.Reporter) ([]float64, error) {
+	n := 0
+	for _, i := range indices {
+		for _, c := range categorical {
+			if i == c {
+				n++
+				break
+			}
+		}
+	}
+	switch n {
+	case 0:
+		return KozachenkoLeonenkoLocal(ctx, stage, data, indices, k, r)
+	case len(indices):
+		return DiscreteEntropyLocal(data, indices), nil
+	}
+	return nil, fmt.Errorf("the entropy of categorical and continuous columns is not defined")
+}
@@ -0,0 +1,75 @@
+package continuous
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+func TestMixedConditionalMutualInformationLocal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	n := 600
+	discrete := make([][]float64, n, n)
+	mixed := make([][]float64, n, n)
+	for i := 0; i < n; i++ {
+		x := float64(i % 3)
+		discrete[i] = []float64{x, x}
+		y := float64(rng.Intn(2))
+		mixed[i] = []float64{y + rng.Float64(), y, float64(rng.Intn(2))}
+	}
+	tests := []struct {
+		name        string
+		data        [][]float64
+		x, y        []int
+		categorical []int
+		want        float64
+	}{
+		{"discrete X = Y", discrete, []int{0}, []int{1}, []int{0, 1}, math.Log(3.0)},
+		{"continuous X with disjoint supports given Y", mixed, []int{0}, []int{1}, []int{1, 2}, math.Log(2.0)},
+		{"independent", mixed, []int{0}, []int{2}, []int{1, 2}, 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, err := MixedConditionalMutualInformationLocal(context.Background(), tt.data, tt.x, tt.y, nil, tt.categorical, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := average(local); math.Abs(got-tt.want) > 0.05 {
+				t.Errorf("MixedConditionalMutualInformationLocal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMixedEstimateLocalProgress(t *testing.T) {
+	data := correlated(3*mixedChunk, 0.5)
+	var reports []int
+	r := progress.Func(func(stage string, done, total int) {
+		if total != 6 {
+			t.Errorf("MixedEstimateLocal() reported a total of %d, want 6 chunks", total)
+		}
+		reports = append(reports, done)
+	})
+	if _, err := MixedEstimateLocal(context.Background(), "test", data, nil, 5, r, Term{Sign: 1.0, X: []int{0}, Y: []int{1}}, Term{Sign: 1.0, X: []int{0}, Y: []int{2}}); err != nil {
+		t.Fatal(err)
+	}
+	for i, done := range reports {
+		if done != i {
+			t.Fatalf("MixedEstimateLocal() reported %v, want 0 to 6", reports)
+		}
+	}
+	if len(reports) != 7 {
+		t.Errorf("MixedEstimateLocal() reported %v, want 0 to 6", reports)
+	}
+}
+
+func TestDiscreteEntropyLocal(t *testing.T) {
+	data := [][]float64{{0.0}, {0.0}, {1.0}, {2.0}}
+	want := 1.5 * math.Log(2.0)
+	if got := average(DiscreteEntropyLocal(data, []int{0})); math.Abs(got-want) > 1e-10 {
+		t.Errorf("DiscreteEntropyLocal() = %v, want %v", got, want)
+	}
+}
@@ -1,7 +1,11 @@
 package state
 
 import (
-	"github.com/kzahedi/goent/continuous/state"
+	"context"
+	"fmt"
+
+	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/progress"
 )
 
 func diff(r1, r2 []float64) []float64 {
@@ -13,49 +17,65 @@ func diff(r1, r2 []float64) []float64 {
 }
 
 // MorphologicalComputationW [...]
-func MorphologicalComputationW(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) []float64 {
-	return state.FrenzelPompe(w2w1a1, w2Indices, w1Indices, a1Indices, k, eta)
+func MorphologicalComputationW(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_W", w2w1a1, k, r, continuous.FP(w2Indices, w1Indices, a1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return v[0], nil
 }
 
 // MorphologicalComputationA [...]
-func MorphologicalComputationA(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) []float64 {
-	return state.FrenzelPompe(w2w1a1, w2Indices, a1Indices, w1Indices, k, eta)
+func MorphologicalComputationA(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_A", w2w1a1, k, r, continuous.FP(w2Indices, a1Indices, w1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return v[0], nil
 }
 
 // MorphologicalComputationMI1 [...]
-func MorphologicalComputationMI1(w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, eta bool) []float64 {
-	r1 := state.KraskovStoegbauerGrassberger1(w2w1s1a1, w2Indices, w1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger1(w2w1s1a1, s1Indices, a1Indices, k, eta)
-	return diff(r1, r2)
+func MorphologicalComputationMI1(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_MI (KSG 1)", w2w1s1a1, k, r, continuous.KSG1(w2Indices, w1Indices), continuous.KSG1(s1Indices, a1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
 }
 
 // MorphologicalComputationMI2 [...]
-func MorphologicalComputationMI2(w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, eta bool) []float64 {
-	r1 := state.KraskovStoegbauerGrassberger2(w2w1s1a1, w2Indices, w1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger2(w2w1s1a1, a1Indices, s1Indices, k, eta)
-	return diff(r1, r2)
+func MorphologicalComputationMI2(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_MI (KSG 2)", w2w1s1a1, k, r, continuous.KSG2(w2Indices, w1Indices), continuous.KSG2(a1Indices, s1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
 }
 
 // MorphologicalComputationCA1 quantifies morphological computation as the causal information flow from
 // W to W' that does pass through A
 // MorphologicalComputationCA = CIF(W -> W') - CIF(A -> W') = I(W';W) - I(W'|A)
-func MorphologicalComputationCA1(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) []float64 {
-	r1 := state.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, w1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, a1Indices, k, eta)
-	return diff(r1, r2)
+func MorphologicalComputationCA1(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_CA (KSG 1)", w2w1a1, k, r, continuous.KSG1(w2Indices, w1Indices), continuous.KSG1(w2Indices, a1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
 }
 
 // MorphologicalComputationCA2 quantifies morphological computation as the causal information flow from
 // W to W' that does pass through A
 // MorphologicalComputationCA = CIF(W -> W') - CIF(A -> W') = I(W';W) - I(W'|A)
-func MorphologicalComputationCA2(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) []float64 {
-	r1 := state.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, w1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, a1Indices, k, eta)
-	return diff(r1, r2)
+func MorphologicalComputationCA2(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_CA (KSG 2)", w2w1a1, k, r, continuous.KSG2(w2Indices, w1Indices), continuous.KSG2(w2Indices, a1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
 }
 
 // MorphologicalComputationWA1 = I(W;{W,A}) - I(W';A)
-func MorphologicalComputationWA1(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) []float64 {
+func MorphologicalComputationWA1(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
 	n := len(w1Indices) + len(a1Indices)
 	w1a1Indices := make([]int, n, n)
 	index := 0
@@ -68,13 +88,15 @@ func MorphologicalComputationWA1(w2w1a1 [][]float64, w2Indices, w1Indices, a1Ind
 		index++
 	}
 
-	r1 := state.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, w1a1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger1(w2w1a1, w2Indices, a1Indices, k, eta)
-	return diff(r1, r2)
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_WA (KSG 1)", w2w1a1, k, r, continuous.KSG1(w2Indices, w1a1Indices), continuous.KSG1(w2Indices, a1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
 }
 
 // MorphologicalComputationWA2 = I(W;{W,A}) - I(W';A)
-func MorphologicalComputationWA2(w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, eta bool) []float64 {
+func MorphologicalComputationWA2(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, error) {
 	n := len(w1Indices) + len(a1Indices)
 	w1a1Indices := make([]int, n, n)
 	index := 0
@@ -87,13 +109,15 @@ func MorphologicalComputationWA2(w2w1a1 [][]float64, w2Indices, w1Indices, a1Ind
 		index++
 	}
 
-	r1 := state.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, w1a1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger2(w2w1a1, w2Indices, a1Indices, k, eta)
-	return diff(r1, r2)
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_WA (KSG 2)", w2w1a1, k, r, continuous.KSG2(w2Indices, w1a1Indices), continuous.KSG2(w2Indices, a1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
 }
 
 // MorphologicalComputationWS1 = I(W;{W,S}) - I(W';S)
-func MorphologicalComputationWS1(w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, eta bool) []float64 {
+func MorphologicalComputationWS1(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, r progress.Reporter) ([]float64, error) {
 	n := len(w1Indices) + len(s1Indices)
 	w1s1Indices := make([]int, n, n)
 	index := 0
@@ -106,13 +130,15 @@ func MorphologicalComputationWS1(w2w1s1 [][]float64, w2Indices, w1Indices, s1Ind
 		index++
 	}
 
-	r1 := state.KraskovStoegbauerGrassberger1(w2w1s1, w2Indices, w1s1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger1(w2w1s1, w2Indices, s1Indices, k, eta)
-	return diff(r1, r2)
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_WS (KSG 1)", w2w1s1, k, r, continuous.KSG1(w2Indices, w1s1Indices), continuous.KSG1(w2Indices, s1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
 }
 
 // MorphologicalComputationWS2 = I(W;{W,S}) - I(W';S)
-func MorphologicalComputationWS2(w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, eta bool) []float64 {
+func MorphologicalComputationWS2(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, k int, r progress.Reporter) ([]float64, error) {
 	n := len(w1Indices) + len(s1Indices)
 	w1s1Indices := make([]int, n, n)
 	index := 0
@@ -125,7 +151,82 @@ func MorphologicalComputationWS2(w2w1s1 [][]float64, w2Indices, w1Indices, s1Ind
 		index++
 	}
 
-	r1 := state.KraskovStoegbauerGrassberger2(w2w1s1, w2Indices, w1s1Indices, k, eta)
-	r2 := state.KraskovStoegbauerGrassberger2(w2w1s1, w2Indices, s1Indices, k, eta)
-	return diff(r1, r2)
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_WS (KSG 2)", w2w1s1, k, r, continuous.KSG2(w2Indices, w1s1Indices), continuous.KSG2(w2Indices, s1Indices))
+	if err != nil {
+		return nil, err
+	}
+	return diff(v[0], v[1]), nil
+}
+
+// MorphologicalComputationAPrime1 returns the pointwise values
+//    1 - i(w';a|w) / I(W';W,A)
+// and the normalisation I(W';W,A) (see continuous.MorphologicalComputationAPrime1)
+func MorphologicalComputationAPrime1(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, float64, error) {
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_A_Prime (KSG 1)", w2w1a1, k, r, continuous.FP(w2Indices, a1Indices, w1Indices), continuous.KSG1(w2Indices, w1a1Indices))
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return aPrime(v[0], mean(v[1]))
+}
+
+// MorphologicalComputationAPrime2 is MorphologicalComputationAPrime1 with
+// the second KSG estimator for I(W';W,A)
+func MorphologicalComputationAPrime2(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, k int, r progress.Reporter) ([]float64, float64, error) {
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_A_Prime (KSG 2)", w2w1a1, k, r, continuous.FP(w2Indices, a1Indices, w1Indices), continuous.KSG2(w2Indices, w1a1Indices))
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return aPrime(v[0], mean(v[1]))
+}
+
+// aPrime returns 1 - miA/z for each value of miA and z, or an error if z is
+// not positive
+func aPrime(miA []float64, z float64) ([]float64, float64, error) {
+	if z <= 0.0 {
+		return nil, z, fmt.Errorf("MI_A_Prime is undefined, because the estimate of I(W';W,A) = %f is not positive", z)
+	}
+	r := make([]float64, len(miA), len(miA))
+	for i, v := range miA {
+		r[i] = 1.0 - v/z
+	}
+	return r, z, nil
+}
+
+// MorphologicalComputationIN1 returns the pointwise values
+//    h(a) - i(a;s)
+// and the normalisation H(A) (see continuous.MorphologicalComputationIN1)
+func MorphologicalComputationIN1(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices []int, k int, r progress.Reporter) ([]float64, float64, error) {
+	h, err := continuous.KozachenkoLeonenkoLocal(ctx, "MI_IN h(a) (Kozachenko-Leonenko)", a1s1, a1Indices, k, r)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_IN (KSG 1)", a1s1, k, r, continuous.KSG1(a1Indices, s1Indices))
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return diff(h, v[0]), mean(h), nil
+}
+
+// MorphologicalComputationIN2 is MorphologicalComputationIN1 with the second
+// KSG estimator for I(A;S)
+func MorphologicalComputationIN2(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices []int, k int, r progress.Reporter) ([]float64, float64, error) {
+	h, err := continuous.KozachenkoLeonenkoLocal(ctx, "MI_IN h(a) (Kozachenko-Leonenko)", a1s1, a1Indices, k, r)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	v, err := continuous.KNNEstimateLocal(ctx, "MI_IN (KSG 2)", a1s1, k, r, continuous.KSG2(a1Indices, s1Indices))
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return diff(h, v[0]), mean(h), nil
+}
+
+func mean(values []float64) float64 {
+	r := 0.0
+	for _, v := range values {
+		r += v
+	}
+	return r / float64(len(values))
 }
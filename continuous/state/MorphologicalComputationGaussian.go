@@ -0,0 +1,103 @@
+package state
+
+import (
+	"context"
+
+	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// MorphologicalComputationWGaussian is MorphologicalComputationW with the
+// linear-Gaussian estimator
+//    MI_W = I(W';W|A)
+func MorphologicalComputationWGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) ([]float64, error) {
+	return continuous.GaussianEstimateLocal(ctx, "MI_W (Gaussian)", w2w1a1, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1Indices, Z: a1Indices})
+}
+
+// MorphologicalComputationAGaussian is MorphologicalComputationA with the
+// linear-Gaussian estimator
+//    MI_A = I(W';A|W)
+func MorphologicalComputationAGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) ([]float64, error) {
+	return continuous.GaussianEstimateLocal(ctx, "MI_A (Gaussian)", w2w1a1, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: a1Indices, Z: w1Indices})
+}
+
+// MorphologicalComputationCAGaussian is MorphologicalComputationCA1 with the
+// linear-Gaussian estimator
+//    MI_CA = I(W';W) - I(W';A)
+func MorphologicalComputationCAGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) ([]float64, error) {
+	return continuous.GaussianEstimateLocal(ctx, "MI_CA (Gaussian)", w2w1a1, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		continuous.Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWAGaussian is MorphologicalComputationWA1 with the
+// linear-Gaussian estimator
+//    MI_WA = I(W';{W,A}) - I(W';A)
+func MorphologicalComputationWAGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) ([]float64, error) {
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	return continuous.GaussianEstimateLocal(ctx, "MI_WA (Gaussian)", w2w1a1, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1a1Indices},
+		continuous.Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWSGaussian is MorphologicalComputationWS1 with the
+// linear-Gaussian estimator
+//    MI_WS = I(W';{W,S}) - I(W';S)
+func MorphologicalComputationWSGaussian(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices []int, r progress.Reporter) ([]float64, error) {
+	w1s1Indices := append(append([]int{}, w1Indices...), s1Indices...)
+	return continuous.GaussianEstimateLocal(ctx, "MI_WS (Gaussian)", w2w1s1, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1s1Indices},
+		continuous.Term{Sign: -1.0, X: w2Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationMIGaussian is MorphologicalComputationMI1 with the
+// linear-Gaussian estimator
+//    MI_MI = I(W';W) - I(A;S)
+func MorphologicalComputationMIGaussian(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices []int, r progress.Reporter) ([]float64, error) {
+	return continuous.GaussianEstimateLocal(ctx, "MI_MI (Gaussian)", w2w1s1a1, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		continuous.Term{Sign: -1.0, X: a1Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationAPrimeGaussian is MorphologicalComputationAPrime1
+// with the linear-Gaussian estimator
+//    MI_A' = 1 - i(w';a|w) / I(W';W,A)
+func MorphologicalComputationAPrimeGaussian(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices []int, r progress.Reporter) ([]float64, float64, error) {
+	stage := "MI_A_Prime (Gaussian)"
+	g, err := continuous.FitGaussian(ctx, stage, w2w1a1, r)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	z, err := g.ConditionalMutualInformation(w2Indices, w1a1Indices, nil)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	miA, err := g.LocalConditionalMutualInformation(ctx, stage, w2w1a1, w2Indices, a1Indices, w1Indices, r)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return aPrime(miA, z)
+}
+
+// MorphologicalComputationINGaussian is MorphologicalComputationIN1 with the
+// linear-Gaussian estimator
+//    MI_IN = h(a) - i(a;s)
+func MorphologicalComputationINGaussian(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices []int, r progress.Reporter) ([]float64, float64, error) {
+	stage := "MI_IN (Gaussian)"
+	g, err := continuous.FitGaussian(ctx, stage, a1s1, r)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	h, err := g.LocalEntropy(a1s1, a1Indices)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	mi, err := g.LocalConditionalMutualInformation(ctx, stage, a1s1, a1Indices, s1Indices, nil, r)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return diff(h, mi), mean(h), nil
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"context"
+
+	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// MorphologicalComputationWMixed is MorphologicalComputationW with the mixed
+// estimator (see continuous.MixedConditionalMutualInformationLocal), where
+// the columns in categorical are discrete
+//    MI_W = I(W';W|A)
+func MorphologicalComputationWMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) ([]float64, error) {
+	return continuous.MixedEstimateLocal(ctx, "MI_W (mixed)", w2w1a1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1Indices, Z: a1Indices})
+}
+
+// MorphologicalComputationAMixed is MorphologicalComputationA with the mixed
+// estimator
+//    MI_A = I(W';A|W)
+func MorphologicalComputationAMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) ([]float64, error) {
+	return continuous.MixedEstimateLocal(ctx, "MI_A (mixed)", w2w1a1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: a1Indices, Z: w1Indices})
+}
+
+// MorphologicalComputationCAMixed is MorphologicalComputationCA1 with the
+// mixed estimator
+//    MI_CA = I(W';W) - I(W';A)
+func MorphologicalComputationCAMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) ([]float64, error) {
+	return continuous.MixedEstimateLocal(ctx, "MI_CA (mixed)", w2w1a1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		continuous.Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWAMixed is MorphologicalComputationWA1 with the
+// mixed estimator
+//    MI_WA = I(W';{W,A}) - I(W';A)
+func MorphologicalComputationWAMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) ([]float64, error) {
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	return continuous.MixedEstimateLocal(ctx, "MI_WA (mixed)", w2w1a1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1a1Indices},
+		continuous.Term{Sign: -1.0, X: w2Indices, Y: a1Indices})
+}
+
+// MorphologicalComputationWSMixed is MorphologicalComputationWS1 with the
+// mixed estimator
+//    MI_WS = I(W';{W,S}) - I(W';S)
+func MorphologicalComputationWSMixed(ctx context.Context, w2w1s1 [][]float64, w2Indices, w1Indices, s1Indices, categorical []int, k int, r progress.Reporter) ([]float64, error) {
+	w1s1Indices := append(append([]int{}, w1Indices...), s1Indices...)
+	return continuous.MixedEstimateLocal(ctx, "MI_WS (mixed)", w2w1s1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1s1Indices},
+		continuous.Term{Sign: -1.0, X: w2Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationMIMixed is MorphologicalComputationMI1 with the
+// mixed estimator
+//    MI_MI = I(W';W) - I(A;S)
+func MorphologicalComputationMIMixed(ctx context.Context, w2w1s1a1 [][]float64, w2Indices, w1Indices, s1Indices, a1Indices, categorical []int, k int, r progress.Reporter) ([]float64, error) {
+	return continuous.MixedEstimateLocal(ctx, "MI_MI (mixed)", w2w1s1a1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1Indices},
+		continuous.Term{Sign: -1.0, X: a1Indices, Y: s1Indices})
+}
+
+// MorphologicalComputationAPrimeMixed is MorphologicalComputationAPrime1 with
+// the mixed estimator
+//    MI_A' = 1 - i(w';a|w) / I(W';W,A)
+func MorphologicalComputationAPrimeMixed(ctx context.Context, w2w1a1 [][]float64, w2Indices, w1Indices, a1Indices, categorical []int, k int, r progress.Reporter) ([]float64, float64, error) {
+	stage := "MI_A_Prime (mixed)"
+	miA, err := continuous.MixedEstimateLocal(ctx, stage, w2w1a1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: a1Indices, Z: w1Indices})
+	if err != nil {
+		return nil, 0.0, err
+	}
+	w1a1Indices := append(append([]int{}, w1Indices...), a1Indices...)
+	z, err := continuous.MixedEstimateLocal(ctx, stage, w2w1a1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: w2Indices, Y: w1a1Indices})
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return aPrime(miA, mean(z))
+}
+
+// MorphologicalComputationINMixed is MorphologicalComputationIN1 with the
+// mixed estimator (see continuous.MixedEntropyLocal for h(a))
+//    MI_IN = h(a) - i(a;s)
+func MorphologicalComputationINMixed(ctx context.Context, a1s1 [][]float64, a1Indices, s1Indices, categorical []int, k int, r progress.Reporter) ([]float64, float64, error) {
+	h, err := continuous.MixedEntropyLocal(ctx, "MI_IN h(a) (Kozachenko-Leonenko)", a1s1, a1Indices, categorical, k, r)
+	if err != nil {
+		return nil, 0.0, err
+	}
+	mi, err := continuous.MixedEstimateLocal(ctx, "MI_IN (mixed)", a1s1, categorical, k, r,
+		continuous.Term{Sign: 1.0, X: a1Indices, Y: s1Indices})
+	if err != nil {
+		return nil, 0.0, err
+	}
+	return diff(h, mi), mean(h), nil
+}
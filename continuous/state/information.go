@@ -0,0 +1,68 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// Information returns the pointwise values of the (conditional) mutual
+// information I(X;Y|Z) of the term (the sign is ignored) in nats. The
+// estimator is chosen as in continuous.Information.
+func Information(ctx context.Context, data [][]float64, t continuous.Term, categorical []int, mode, k int, r progress.Reporter) ([]float64, error) {
+	t.Sign = 1.0
+	if len(categorical) > 0 {
+		return continuous.MixedEstimateLocal(ctx, "i (mixed)", data, categorical, k, r, t)
+	}
+	var v [][]float64
+	var err error
+	switch {
+	case mode == 3:
+		return continuous.GaussianEstimateLocal(ctx, "i (Gaussian)", data, r, t)
+	case mode != 1 && mode != 2:
+		return nil, fmt.Errorf("unknown continuous mode %d", mode)
+	case len(t.Z) > 0:
+		v, err = continuous.KNNEstimateLocal(ctx, "i (FP)", data, k, r, continuous.FP(t.X, t.Y, t.Z))
+	case mode == 1:
+		v, err = continuous.KNNEstimateLocal(ctx, "i (KSG 1)", data, k, r, continuous.KSG1(t.X, t.Y))
+	default:
+		v, err = continuous.KNNEstimateLocal(ctx, "i (KSG 2)", data, k, r, continuous.KSG2(t.X, t.Y))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v[0], nil
+}
+
+// ConditionalEntropy returns the pointwise values of the (conditional)
+// entropy H(X|Z) in nats, where Z may be empty
+//    h(x|z) = h(x) - i(x;z)
+// (see continuous.ConditionalEntropy)
+func ConditionalEntropy(ctx context.Context, data [][]float64, xIndices, zIndices, categorical []int, mode, k int, r progress.Reporter) ([]float64, error) {
+	if mode == 3 && len(categorical) == 0 {
+		g, err := continuous.FitGaussian(ctx, "h (Gaussian)", data, r)
+		if err != nil {
+			return nil, err
+		}
+		hXZ, err := g.LocalEntropy(data, append(append([]int{}, xIndices...), zIndices...))
+		if err != nil || len(zIndices) == 0 {
+			return hXZ, err
+		}
+		hZ, err := g.LocalEntropy(data, zIndices)
+		if err != nil {
+			return nil, err
+		}
+		return diff(hXZ, hZ), nil
+	}
+	h, err := continuous.MixedEntropyLocal(ctx, "h (Kozachenko-Leonenko)", data, xIndices, categorical, k, r)
+	if err != nil || len(zIndices) == 0 {
+		return h, err
+	}
+	mi, err := Information(ctx, data, continuous.Term{X: xIndices, Y: zIndices}, categorical, mode, k, r)
+	if err != nil {
+		return nil, err
+	}
+	return diff(h, mi), nil
+}
@@ -1,53 +1,62 @@
 package gomi
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/progress"
 )
 
 // ContinuousAvgCalculations returns the averaged morphological computation
 // based on estimators for continuous data. Note that not all measures are
 // available on continuous state spaces.
-func ContinuousAvgCalculations(p Parameters, d Data) float64 {
-	var r float64
+func ContinuousAvgCalculations(ctx context.Context, p Parameters, d Data, r progress.Reporter) (float64, error) {
+	if len(p.Categorical) > 0 && hasMixedEstimator(p.MeasureName) {
+		return mixedContinuousAvg(ctx, p, d, r)
+	}
+	var result float64
+	var err error
 	switch p.MeasureName {
 	case "MI_W":
-		r = MiWContinuousAvg(p, d)
+		result, err = MiWContinuousAvg(ctx, p, d, r)
 	case "MI_A":
-		r = MiAContinuousAvg(p, d)
+		result, err = MiAContinuousAvg(ctx, p, d, r)
 	case "MI_A_Prime":
-		r = MiAPrimeContinuousAvg(p, d)
+		result, err = MiAPrimeContinuousAvg(ctx, p, d, r)
 	case "MI_MI":
-		r = MiMiContinuousAvg(p, d)
+		result, err = MiMiContinuousAvg(ctx, p, d, r)
 	case "MI_SY":
-		r = MiSyContinuousAvg(p, d)
+		result = MiSyContinuousAvg(p, d)
 	case "MI_CA":
-		r = MiCaContinuousAvg(p, d)
+		result, err = MiCaContinuousAvg(ctx, p, d, r)
 	case "MI_WA":
-		r = MiWaContinuousAvg(p, d)
+		result, err = MiWaContinuousAvg(ctx, p, d, r)
 	case "MI_WS":
-		r = MiWsContinuousAvg(p, d)
+		result, err = MiWsContinuousAvg(ctx, p, d, r)
 	case "MI_Wp":
-		r = MiWpContinuousAvg(p, d)
+		result = MiWpContinuousAvg(p, d)
 	case "CA":
-		r = CaContinuousAvg(p, d)
+		result, err = CaContinuousAvg(p, d)
+	case "CW":
+		result, err = CwContinuousAvg(p, d)
 	case "UI":
-		r = UIContinuousAvg(p, d)
+		result = UIContinuousAvg(p, d)
 	case "CI":
-		r = CiContinuousAvg(p, d)
+		result = CiContinuousAvg(p, d)
 	case "MI_IN":
-		r = MiInContinuousAvg(p, d)
+		result, err = MiInContinuousAvg(ctx, p, d, r)
 	default:
-		fmt.Println(fmt.Sprintf("unknown measure given %s in the context of continuous-avg measures.", p.MeasureName))
+		err = fmt.Errorf("unknown measure given %s in the context of continuous-avg measures.", p.MeasureName)
 	}
-	return r
+	return result, err
 }
 
 // MiWContinuousAvg returns the result of the quantification MI_W. This function
 // also writes the result to a file as specified in the parameters p
 //    MI_W = I(W';W|A)
-func MiWContinuousAvg(p Parameters, data Data) (result float64) {
+func MiWContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_W Continuous Avg")
@@ -57,12 +66,9 @@ func MiWContinuousAvg(p Parameters, data Data) (result float64) {
 	if p.LogData {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err = NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return
 	}
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
@@ -71,7 +77,19 @@ func MiWContinuousAvg(p Parameters, data Data) (result float64) {
 		fmt.Println(p)
 	}
 
-	result = continuous.MorphologicalComputationW(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
+	if p.ContinuousMode == 3 {
+		result, err = continuous.MorphologicalComputationWGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return
+		}
+		writeOutputAvg(p, result, "MI_W continuous (Gaussian Estimator)", output)
+		return
+	}
+
+	result, err = continuous.MorphologicalComputationW(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+	if err != nil {
+		return
+	}
 	writeOutputAvg(p, result, "MI_W continuous", output)
 	return
 }
@@ -79,7 +97,7 @@ func MiWContinuousAvg(p Parameters, data Data) (result float64) {
 // MiAContinuousAvg returns the result of the quantification MI_A. This function
 // also writes the result to a file as specified in the parameters p
 //    MI_A = I(W';A|W)
-func MiAContinuousAvg(p Parameters, data Data) (result float64) {
+func MiAContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_A Continuous Avg")
@@ -89,12 +107,9 @@ func MiAContinuousAvg(p Parameters, data Data) (result float64) {
 	if p.LogData {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err = NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return
 	}
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
@@ -103,23 +118,76 @@ func MiAContinuousAvg(p Parameters, data Data) (result float64) {
 		fmt.Println(p)
 	}
 
-	result = continuous.MorphologicalComputationA(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
+	if p.ContinuousMode == 3 {
+		result, err = continuous.MorphologicalComputationAGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return
+		}
+		writeOutputAvg(p, result, "MI_A continuous (Gaussian Estimator)", output)
+		return
+	}
+
+	result, err = continuous.MorphologicalComputationA(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+	if err != nil {
+		return
+	}
 	writeOutputAvg(p, result, "MI_A continuous", output)
 	return
 }
 
 // MiAPrimeContinuousAvg returns the result of the quantification MI_A'. This function
 // also writes the result to a file as specified in the parameters p
-//    MI_A' = 1 - I(W';A|W)/log|W|
-func MiAPrimeContinuousAvg(p Parameters, data Data) float64 {
-	fmt.Println("MI_A Prime Continuous Avg is not implemented yet.")
-	return -1.0
+//    MI_A' = 1 - I(W';A|W)/I(W';W,A)
+// The term log|W| of the discrete measure is replaced by the upper bound
+// I(W';W,A) of I(W';A|W).
+func MiAPrimeContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_A Prime Continuous Avg")
+	}
+
+	w2w1a1, w2Indices, w1Indices, a1Indices := MakeW2W1A1(data, p)
+	if p.LogData {
+		output.SetW2W1A1Raw(w2w1a1)
+	}
+	w2w1a1, err = NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return
+	}
+	if p.LogData {
+		output.SetW2W1A1Normalised(w2w1a1)
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	var z float64
+	var label string
+	switch p.ContinuousMode {
+	case 1:
+		result, z, err = continuous.MorphologicalComputationAPrime1(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		label = "MI_A_Prime continuous (KSG 1 Estimator)"
+	case 2:
+		result, z, err = continuous.MorphologicalComputationAPrime2(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		label = "MI_A_Prime continuous (KSG 2 Estimator)"
+	case 3:
+		result, z, err = continuous.MorphologicalComputationAPrimeGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		label = "MI_A_Prime continuous (Gaussian Estimator)"
+	default:
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
+	}
+	if err != nil {
+		return
+	}
+	output.SetNormaliser("I(W';W,A)", z)
+	writeOutputAvg(p, result, label, output)
+	return
 }
 
 // MiMiContinuousAvg returns the result of the quantification MI_MI. This function
 // also writes the result to a file as specified in the parameters p
 //    MI_MI = I(W';W) - I(A;S)
-func MiMiContinuousAvg(p Parameters, data Data) (result float64) {
+func MiMiContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_MI Prime Continuous Avg")
@@ -129,15 +197,12 @@ func MiMiContinuousAvg(p Parameters, data Data) (result float64) {
 	if p.LogData {
 		output.SetW2W1S1A1Raw(w2w1s1a1)
 	}
-	if p.DFile != "" {
-		w2w1s1a1 = NormaliseContinuousData(w2w1s1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.SensorMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.SensorMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1s1a1 = NormaliseContinuousDataByColumn(w2w1s1a1, &p)
+	w2w1s1a1, err = NormaliseContinuous(w2w1s1a1, "WWSA", &p)
+	if err != nil {
+		return
 	}
 	if p.LogData {
-		output.SetW2W1S1A1Raw(w2w1s1a1)
+		output.SetW2W1S1A1Normalised(w2w1s1a1)
 	}
 	if p.Verbose == true {
 		fmt.Println(p)
@@ -145,13 +210,25 @@ func MiMiContinuousAvg(p Parameters, data Data) (result float64) {
 
 	switch p.ContinuousMode {
 	case 1:
-		result = continuous.MorphologicalComputationMI1(w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationMI1(ctx, w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_MI continuous (KSG 1 Estimator)", output)
 	case 2:
-		result = continuous.MorphologicalComputationMI2(w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationMI2(ctx, w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_MI continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err = continuous.MorphologicalComputationMIGaussian(ctx, w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, r)
+		if err != nil {
+			return
+		}
+		writeOutputAvg(p, result, "MI_MI continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
 	return
 }
@@ -159,7 +236,7 @@ func MiMiContinuousAvg(p Parameters, data Data) (result float64) {
 // MiCaContinuousAvg returns the result of the quantification MI_CA. This function
 // also writes the result to a file as specified in the parameters p
 //    MI_CA = I(W';W) - I(W';A)
-func MiCaContinuousAvg(p Parameters, data Data) (result float64) {
+func MiCaContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_CA Continuous Avg")
@@ -169,12 +246,9 @@ func MiCaContinuousAvg(p Parameters, data Data) (result float64) {
 	if p.LogData {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err = NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return
 	}
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
@@ -185,13 +259,25 @@ func MiCaContinuousAvg(p Parameters, data Data) (result float64) {
 
 	switch p.ContinuousMode {
 	case 1:
-		result = continuous.MorphologicalComputationCA1(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationCA1(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_CA continuous (KSG 1 Estimator)", output)
 	case 2:
-		result = continuous.MorphologicalComputationCA2(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationCA2(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_CA continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err = continuous.MorphologicalComputationCAGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return
+		}
+		writeOutputAvg(p, result, "MI_CA continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
 	return
 }
@@ -199,7 +285,7 @@ func MiCaContinuousAvg(p Parameters, data Data) (result float64) {
 // MiWaContinuousAvg returns the result of the quantification MI_WA. This function
 // also writes the result to a file as specified in the parameters p
 //    MI_WA = I(W;{W,A}) - I(W';A)
-func MiWaContinuousAvg(p Parameters, data Data) (result float64) {
+func MiWaContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_WA Continuous Avg")
@@ -209,12 +295,9 @@ func MiWaContinuousAvg(p Parameters, data Data) (result float64) {
 	if p.LogData {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err = NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return
 	}
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
@@ -225,13 +308,25 @@ func MiWaContinuousAvg(p Parameters, data Data) (result float64) {
 
 	switch p.ContinuousMode {
 	case 1:
-		result = continuous.MorphologicalComputationWA1(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationWA1(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_WA continuous (KSG 1 Estimator)", output)
 	case 2:
-		result = continuous.MorphologicalComputationWA2(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationWA2(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_WA continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err = continuous.MorphologicalComputationWAGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return
+		}
+		writeOutputAvg(p, result, "MI_WA continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
 	return
 }
@@ -239,25 +334,22 @@ func MiWaContinuousAvg(p Parameters, data Data) (result float64) {
 // MiWsContinuousAvg returns the result of the quantification MI_WS. This function
 // also writes the result to a file as specified in the parameters p
 //    MI_WS = I(W;{W,S}) - I(W';S)
-func MiWsContinuousAvg(p Parameters, data Data) (result float64) {
+func MiWsContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
 	var output Output
 	if p.Verbose {
-		fmt.Println("MI_WS Prime Continuous Avg")
+		fmt.Println("MI_WS Continuous Avg")
 	}
 
-	w2w1a1, w2Indices, w1Indices, s1Indices := MakeW2W1S1(data, p)
+	w2w1s1, w2Indices, w1Indices, s1Indices := MakeW2W1S1(data, p)
 	if p.LogData {
-		output.SetW2W1A1Raw(w2w1a1)
+		output.SetW2W1S1Raw(w2w1s1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1s1, err = NormaliseContinuous(w2w1s1, "WWS", &p)
+	if err != nil {
+		return
 	}
 	if p.LogData {
-		output.SetW2W1A1Normalised(w2w1a1)
+		output.SetW2W1S1Normalised(w2w1s1)
 	}
 	if p.Verbose == true {
 		fmt.Println(p)
@@ -265,25 +357,76 @@ func MiWsContinuousAvg(p Parameters, data Data) (result float64) {
 
 	switch p.ContinuousMode {
 	case 1:
-		result = continuous.MorphologicalComputationWS1(w2w1a1, w2Indices, w1Indices, s1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationWS1(ctx, w2w1s1, w2Indices, w1Indices, s1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_WS continuous (KSG 1 Estimator)", output)
 	case 2:
-		result = continuous.MorphologicalComputationWS2(w2w1a1, w2Indices, w1Indices, s1Indices, p.K, p.Verbose)
+		result, err = continuous.MorphologicalComputationWS2(ctx, w2w1s1, w2Indices, w1Indices, s1Indices, p.K, r)
+		if err != nil {
+			return
+		}
 		writeOutputAvg(p, result, "MI_WS continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err = continuous.MorphologicalComputationWSGaussian(ctx, w2w1s1, w2Indices, w1Indices, s1Indices, r)
+		if err != nil {
+			return
+		}
+		writeOutputAvg(p, result, "MI_WS continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
 	return
 }
 
 // MiInContinuousAvg returns the result of the quantification MI_IN. This function
 // also writes the result to a file as specified in the parameters p
-//    MI_IN = log|A| - I(A;S)
-// This function returns -1.0, because the quantification is not implemented
-// based on entropy estimators for continuous state spaces yet.
-func MiInContinuousAvg(p Parameters, data Data) float64 {
-	fmt.Println("MI_IN Prime Continuous Avg not implemented yet.")
-	return -1.0
+//    MI_IN = H(A) - I(A;S)
+// The maximal entropy log|A| of the discrete measure is replaced by the
+// differential entropy H(A).
+func MiInContinuousAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) (result float64, err error) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_IN Continuous Avg")
+	}
+
+	a1s1, a1Indices, s1Indices := MakeA1S1(data, p)
+	if p.LogData {
+		output.SetA1S1Raw(a1s1)
+	}
+	a1s1, err = NormaliseContinuous(a1s1, "AS", &p)
+	if err != nil {
+		return
+	}
+	if p.LogData {
+		output.SetA1S1Normalised(a1s1)
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	var h float64
+	var label string
+	switch p.ContinuousMode {
+	case 1:
+		result, h, err = continuous.MorphologicalComputationIN1(ctx, a1s1, a1Indices, s1Indices, p.K, r)
+		label = "MI_IN continuous (KSG 1 Estimator)"
+	case 2:
+		result, h, err = continuous.MorphologicalComputationIN2(ctx, a1s1, a1Indices, s1Indices, p.K, r)
+		label = "MI_IN continuous (KSG 2 Estimator)"
+	case 3:
+		result, h, err = continuous.MorphologicalComputationINGaussian(ctx, a1s1, a1Indices, s1Indices, r)
+		label = "MI_IN continuous (Gaussian Estimator)"
+	default:
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
+	}
+	if err != nil {
+		return
+	}
+	output.SetNormaliser("H(A)", h)
+	writeOutputAvg(p, result, label, output)
+	return
 }
 
 // MiWpContinuousAvg returns the result of the quantification MI_Wp. This function
@@ -297,11 +440,53 @@ func MiWpContinuousAvg(p Parameters, data Data) float64 {
 
 // CaContinuousAvg returns the result of the quantification CA. This function
 // also writes the result to a file as specified in the parameters p
-// This function returns -1.0, because the quantification is not implemented
-// based on entropy estimators for continuous state spaces yet.
-func CaContinuousAvg(p Parameters, data Data) float64 {
-	fmt.Println("CA Prime Continuous Avg not implemented yet.")
-	return -1.0
+// The interventional distributions p(s'|do(a)) and p(s'|do(s)) are
+// calculated on an adaptive partition of S and A (see
+// MakePS2S1A1Partition).
+func CaContinuousAvg(p Parameters, data Data) (float64, error) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("CA Continuous Avg")
+	}
+
+	ps2s1a1, bins, sBins, err := MakePS2S1A1Partition(data, &p, &output)
+	if err != nil {
+		return 0.0, err
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+		fmt.Printf("Adaptive partition with %d bins per column\n", bins)
+	}
+
+	result := discrete.MorphologicalComputationIntrinsicCA(ps2s1a1, sBins)
+	output.SetPartition(bins)
+	writeOutputAvg(p, result, "CA continuous (adaptive partition)", output)
+	return result, nil
+}
+
+// CwContinuousAvg returns the result of the quantification CW. This function
+// also writes the result to a file as specified in the parameters p
+// The interventional distribution p(s'|do(s)) is calculated on an adaptive
+// partition of S and A (see MakePS2S1A1Partition).
+func CwContinuousAvg(p Parameters, data Data) (float64, error) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("CW Continuous Avg")
+	}
+
+	ps2s1a1, bins, _, err := MakePS2S1A1Partition(data, &p, &output)
+	if err != nil {
+		return 0.0, err
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+		fmt.Printf("Adaptive partition with %d bins per column\n", bins)
+	}
+
+	result := discrete.MorphologicalComputationIntrinsicCW(ps2s1a1)
+	output.SetPartition(bins)
+	writeOutputAvg(p, result, "CW continuous (adaptive partition)", output)
+	return result, nil
 }
 
 // UIContinuousAvg returns the result of the quantification UI. This function
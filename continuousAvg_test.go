@@ -1,6 +1,7 @@
 package gomi
 
 import (
+	"context"
 	"math"
 	"testing"
 )
@@ -54,7 +55,7 @@ func TestContinuousAvgCalculations(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := ContinuousAvgCalculations(tt.args.p, tt.args.d); got != tt.want {
+			if got, _ := ContinuousAvgCalculations(context.Background(), tt.args.p, tt.args.d, nil); got != tt.want {
 				t.Errorf("ContinuousAvgCalculations() = %v, want %v", got, tt.want)
 			}
 		})
@@ -77,7 +78,7 @@ func TestMiWContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotResult := MiWContinuousAvg(tt.args.p, tt.args.data); math.Abs(gotResult-tt.wantResult) > 0.0001 {
+			if gotResult, _ := MiWContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); math.Abs(gotResult-tt.wantResult) > 0.0001 {
 				t.Errorf("MiWContinuousAvg() = %v, want %v", gotResult, tt.wantResult)
 			}
 		})
@@ -98,7 +99,7 @@ func TestMiAContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotResult := MiAContinuousAvg(tt.args.p, tt.args.data); gotResult != tt.wantResult {
+			if gotResult, _ := MiAContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); gotResult != tt.wantResult {
 				t.Errorf("MiAContinuousAvg() = %v, want %v", gotResult, tt.wantResult)
 			}
 		})
@@ -119,7 +120,7 @@ func TestMiAPrimeContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := MiAPrimeContinuousAvg(tt.args.p, tt.args.data); got != tt.want {
+			if got, _ := MiAPrimeContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); got != tt.want {
 				t.Errorf("MiAPrimeContinuousAvg() = %v, want %v", got, tt.want)
 			}
 		})
@@ -140,7 +141,7 @@ func TestMiMiContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotResult := MiMiContinuousAvg(tt.args.p, tt.args.data); gotResult != tt.wantResult {
+			if gotResult, _ := MiMiContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); gotResult != tt.wantResult {
 				t.Errorf("MiMiContinuousAvg() = %v, want %v", gotResult, tt.wantResult)
 			}
 		})
@@ -161,7 +162,7 @@ func TestMiCaContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotResult := MiCaContinuousAvg(tt.args.p, tt.args.data); gotResult != tt.wantResult {
+			if gotResult, _ := MiCaContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); gotResult != tt.wantResult {
 				t.Errorf("MiCaContinuousAvg() = %v, want %v", gotResult, tt.wantResult)
 			}
 		})
@@ -182,7 +183,7 @@ func TestMiWaContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotResult := MiWaContinuousAvg(tt.args.p, tt.args.data); gotResult != tt.wantResult {
+			if gotResult, _ := MiWaContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); gotResult != tt.wantResult {
 				t.Errorf("MiWaContinuousAvg() = %v, want %v", gotResult, tt.wantResult)
 			}
 		})
@@ -203,7 +204,7 @@ func TestMiWsContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotResult := MiWsContinuousAvg(tt.args.p, tt.args.data); gotResult != tt.wantResult {
+			if gotResult, _ := MiWsContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); gotResult != tt.wantResult {
 				t.Errorf("MiWsContinuousAvg() = %v, want %v", gotResult, tt.wantResult)
 			}
 		})
@@ -224,7 +225,7 @@ func TestMiInContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := MiInContinuousAvg(tt.args.p, tt.args.data); got != tt.want {
+			if got, _ := MiInContinuousAvg(context.Background(), tt.args.p, tt.args.data, nil); got != tt.want {
 				t.Errorf("MiInContinuousAvg() = %v, want %v", got, tt.want)
 			}
 		})
@@ -266,7 +267,7 @@ func TestCaContinuousAvg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := CaContinuousAvg(tt.args.p, tt.args.data); got != tt.want {
+			if got, _ := CaContinuousAvg(tt.args.p, tt.args.data); got != tt.want {
 				t.Errorf("CaContinuousAvg() = %v, want %v", got, tt.want)
 			}
 		})
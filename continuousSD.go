@@ -1,31 +1,36 @@
 package gomi
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/kzahedi/gomi/continuous/state"
+	"github.com/kzahedi/gomi/progress"
 )
 
 // ContinuousSDCalculations returns the value of the selected continuous measure
 // state-dependent (or point-wise)
-func ContinuousSDCalculations(p Parameters, d Data) {
+func ContinuousSDCalculations(ctx context.Context, p Parameters, d Data, r progress.Reporter) error {
+	if len(p.Categorical) > 0 && hasMixedEstimator(p.MeasureName) {
+		return mixedContinuousSD(ctx, p, d, r)
+	}
 	switch p.MeasureName {
 	case "MI_W":
-		miwContinuousSD(p, d)
+		return miwContinuousSD(ctx, p, d, r)
 	case "MI_A":
-		miaContinuousSD(p, d)
+		return miaContinuousSD(ctx, p, d, r)
 	case "MI_A_Prime":
-		miaPrimeContinuousSD(p, d)
+		return miaPrimeContinuousSD(ctx, p, d, r)
 	case "MI_MI":
-		mimiContinuousSD(p, d)
+		return mimiContinuousSD(ctx, p, d, r)
 	case "MI_SY":
 		misyContinuousSD(p, d)
 	case "MI_CA":
-		micaContinuousSD(p, d)
+		return micaContinuousSD(ctx, p, d, r)
 	case "MI_WA":
-		miwaContinuousSD(p, d)
+		return miwaContinuousSD(ctx, p, d, r)
 	case "MI_WS":
-		miwsContinuousSD(p, d)
+		return miwsContinuousSD(ctx, p, d, r)
 	case "MI_Wp":
 		miwpContinuousSD(p, d)
 	case "CA":
@@ -35,13 +40,14 @@ func ContinuousSDCalculations(p Parameters, d Data) {
 	case "CI":
 		ciContinuousSD(p, d)
 	case "MI_IN":
-		miinContinuousSD(p, d)
+		return miinContinuousSD(ctx, p, d, r)
 	default:
-		fmt.Println(fmt.Sprintf("unknown measure given %s in the context of continuous-state-dependent measures.", p.MeasureName))
+		return fmt.Errorf("unknown measure given %s in the context of continuous-state-dependent measures.", p.MeasureName)
 	}
+	return nil
 }
 
-func miwContinuousSD(p Parameters, data Data) {
+func miwContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_W Continuous SD")
@@ -53,12 +59,9 @@ func miwContinuousSD(p Parameters, data Data) {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
 
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err := NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return err
 	}
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
@@ -68,12 +71,25 @@ func miwContinuousSD(p Parameters, data Data) {
 		fmt.Println(p)
 	}
 
-	result := state.MorphologicalComputationW(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
+	if p.ContinuousMode == 3 {
+		result, err := state.MorphologicalComputationWGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_W continuous (Gaussian Estimator)", output)
+		return nil
+	}
+
+	result, err := state.MorphologicalComputationW(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+	if err != nil {
+		return err
+	}
 
-	writeOutputSD(p, result, "MI_W continuous", output)
+	writeOutputSD(p, data, result, "MI_W continuous", output)
+	return nil
 }
 
-func miaContinuousSD(p Parameters, data Data) {
+func miaContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_A Continuous SD")
@@ -84,12 +100,9 @@ func miaContinuousSD(p Parameters, data Data) {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
 
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err := NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return err
 	}
 	if p.Verbose == true {
 		fmt.Println(p)
@@ -97,11 +110,24 @@ func miaContinuousSD(p Parameters, data Data) {
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
 	}
-	result := state.MorphologicalComputationA(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
-	writeOutputSD(p, result, "MI_A continuous", output)
+	if p.ContinuousMode == 3 {
+		result, err := state.MorphologicalComputationAGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_A continuous (Gaussian Estimator)", output)
+		return nil
+	}
+
+	result, err := state.MorphologicalComputationA(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+	if err != nil {
+		return err
+	}
+	writeOutputSD(p, data, result, "MI_A continuous", output)
+	return nil
 }
 
-func mimiContinuousSD(p Parameters, data Data) {
+func mimiContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_MI Prime Continuous SD")
@@ -111,12 +137,9 @@ func mimiContinuousSD(p Parameters, data Data) {
 	if p.LogData {
 		output.SetW2W1S1A1Raw(w2w1s1a1)
 	}
-	if p.DFile != "" {
-		w2w1s1a1 = NormaliseContinuousData(w2w1s1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.SensorMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.SensorMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1s1a1 = NormaliseContinuousDataByColumn(w2w1s1a1, &p)
+	w2w1s1a1, err := NormaliseContinuous(w2w1s1a1, "WWSA", &p)
+	if err != nil {
+		return err
 	}
 	if p.LogData {
 		output.SetW2W1S1A1Normalised(w2w1s1a1)
@@ -126,18 +149,30 @@ func mimiContinuousSD(p Parameters, data Data) {
 	}
 	switch p.ContinuousMode {
 	case 1:
-		result := state.MorphologicalComputationMI1(w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_MI continuous (KSG 1 Estimator)", output)
+		result, err := state.MorphologicalComputationMI1(ctx, w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_MI continuous (KSG 1 Estimator)", output)
 	case 2:
-		result := state.MorphologicalComputationMI2(w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_MI continuous (KSG 2 Estimator)", output)
+		result, err := state.MorphologicalComputationMI2(ctx, w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_MI continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err := state.MorphologicalComputationMIGaussian(ctx, w2w1s1a1, w2Indices, w1Indices, s1Indices, a1Indices, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_MI continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		return fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
-
+	return nil
 }
 
-func micaContinuousSD(p Parameters, data Data) {
+func micaContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_WA Continuous SD")
@@ -147,12 +182,9 @@ func micaContinuousSD(p Parameters, data Data) {
 	if p.LogData {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err := NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return err
 	}
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
@@ -163,17 +195,30 @@ func micaContinuousSD(p Parameters, data Data) {
 
 	switch p.ContinuousMode {
 	case 1:
-		result := state.MorphologicalComputationCA1(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_CA continuous (KSG 1 Estimator)", output)
+		result, err := state.MorphologicalComputationCA1(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_CA continuous (KSG 1 Estimator)", output)
 	case 2:
-		result := state.MorphologicalComputationCA2(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_CA continuous (KSG 2 Estimator)", output)
+		result, err := state.MorphologicalComputationCA2(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_CA continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err := state.MorphologicalComputationCAGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_CA continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		return fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
+	return nil
 }
 
-func miwaContinuousSD(p Parameters, data Data) {
+func miwaContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_WA Continuous SD")
@@ -183,12 +228,9 @@ func miwaContinuousSD(p Parameters, data Data) {
 	if p.LogData {
 		output.SetW2W1A1Raw(w2w1a1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1a1, err := NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return err
 	}
 	if p.LogData {
 		output.SetW2W1A1Normalised(w2w1a1)
@@ -199,36 +241,45 @@ func miwaContinuousSD(p Parameters, data Data) {
 
 	switch p.ContinuousMode {
 	case 1:
-		result := state.MorphologicalComputationWA1(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_WA continuous (KSG 1 Estimator)", output)
+		result, err := state.MorphologicalComputationWA1(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_WA continuous (KSG 1 Estimator)", output)
 	case 2:
-		result := state.MorphologicalComputationWA2(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_WA continuous (KSG 2 Estimator)", output)
+		result, err := state.MorphologicalComputationWA2(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_WA continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err := state.MorphologicalComputationWAGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_WA continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		return fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
-
+	return nil
 }
 
-func miwsContinuousSD(p Parameters, data Data) {
+func miwsContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_WS Continuous SD")
 	}
 
-	w2w1a1, w2Indices, w1Indices, a1Indices := MakeW2W1A1(data, p)
+	w2w1s1, w2Indices, w1Indices, s1Indices := MakeW2W1S1(data, p)
 	if p.LogData {
-		output.SetW2W1A1Raw(w2w1a1)
+		output.SetW2W1S1Raw(w2w1s1)
 	}
-	if p.DFile != "" {
-		w2w1a1 = NormaliseContinuousData(w2w1a1,
-			[][]float64{p.WorldMin, p.WorldMin, p.ActuatorMin},
-			[][]float64{p.WorldMax, p.WorldMax, p.ActuatorMax}, &p)
-	} else {
-		w2w1a1 = NormaliseContinuousDataByColumn(w2w1a1, &p)
+	w2w1s1, err := NormaliseContinuous(w2w1s1, "WWS", &p)
+	if err != nil {
+		return err
 	}
 	if p.LogData {
-		output.SetW2W1A1Normalised(w2w1a1)
+		output.SetW2W1S1Normalised(w2w1s1)
 	}
 	if p.Verbose == true {
 		fmt.Println(p)
@@ -236,14 +287,27 @@ func miwsContinuousSD(p Parameters, data Data) {
 
 	switch p.ContinuousMode {
 	case 1:
-		result := state.MorphologicalComputationWS1(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_WS continuous (KSG 1 Estimator)", output)
+		result, err := state.MorphologicalComputationWS1(ctx, w2w1s1, w2Indices, w1Indices, s1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_WS continuous (KSG 1 Estimator)", output)
 	case 2:
-		result := state.MorphologicalComputationWS2(w2w1a1, w2Indices, w1Indices, a1Indices, p.K, p.Verbose)
-		writeOutputSD(p, result, "MI_WS continuous (KSG 2 Estimator)", output)
+		result, err := state.MorphologicalComputationWS2(ctx, w2w1s1, w2Indices, w1Indices, s1Indices, p.K, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_WS continuous (KSG 2 Estimator)", output)
+	case 3:
+		result, err := state.MorphologicalComputationWSGaussian(ctx, w2w1s1, w2Indices, w1Indices, s1Indices, r)
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, data, result, "MI_WS continuous (Gaussian Estimator)", output)
 	default:
-		fmt.Println(fmt.Sprintf("Unknown Continuous Mode %d", p.ContinuousMode))
+		return fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
 	}
+	return nil
 }
 
 func misyContinuousSD(p Parameters, data Data) {
@@ -266,10 +330,92 @@ func ciContinuousSD(p Parameters, data Data) {
 	fmt.Println("CI Continuous SD")
 }
 
-func miinContinuousSD(p Parameters, data Data) {
-	fmt.Println("MI_IN Continuous SD")
+func miinContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_IN Continuous SD")
+	}
+
+	a1s1, a1Indices, s1Indices := MakeA1S1(data, p)
+	if p.LogData {
+		output.SetA1S1Raw(a1s1)
+	}
+	a1s1, err := NormaliseContinuous(a1s1, "AS", &p)
+	if err != nil {
+		return err
+	}
+	if p.LogData {
+		output.SetA1S1Normalised(a1s1)
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	var result []float64
+	var h float64
+	var label string
+	switch p.ContinuousMode {
+	case 1:
+		result, h, err = state.MorphologicalComputationIN1(ctx, a1s1, a1Indices, s1Indices, p.K, r)
+		label = "MI_IN continuous (KSG 1 Estimator)"
+	case 2:
+		result, h, err = state.MorphologicalComputationIN2(ctx, a1s1, a1Indices, s1Indices, p.K, r)
+		label = "MI_IN continuous (KSG 2 Estimator)"
+	case 3:
+		result, h, err = state.MorphologicalComputationINGaussian(ctx, a1s1, a1Indices, s1Indices, r)
+		label = "MI_IN continuous (Gaussian Estimator)"
+	default:
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
+	}
+	if err != nil {
+		return err
+	}
+	output.SetNormaliser("H(A)", h)
+	writeOutputSD(p, data, result, label, output)
+	return nil
 }
 
-func miaPrimeContinuousSD(p Parameters, data Data) {
-	fmt.Println("MI_A Prime Continuous SD")
+func miaPrimeContinuousSD(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_A Prime Continuous SD")
+	}
+
+	w2w1a1, w2Indices, w1Indices, a1Indices := MakeW2W1A1(data, p)
+	if p.LogData {
+		output.SetW2W1A1Raw(w2w1a1)
+	}
+	w2w1a1, err := NormaliseContinuous(w2w1a1, "WWA", &p)
+	if err != nil {
+		return err
+	}
+	if p.LogData {
+		output.SetW2W1A1Normalised(w2w1a1)
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	var result []float64
+	var z float64
+	var label string
+	switch p.ContinuousMode {
+	case 1:
+		result, z, err = state.MorphologicalComputationAPrime1(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		label = "MI_A_Prime continuous (KSG 1 Estimator)"
+	case 2:
+		result, z, err = state.MorphologicalComputationAPrime2(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, p.K, r)
+		label = "MI_A_Prime continuous (KSG 2 Estimator)"
+	case 3:
+		result, z, err = state.MorphologicalComputationAPrimeGaussian(ctx, w2w1a1, w2Indices, w1Indices, a1Indices, r)
+		label = "MI_A_Prime continuous (Gaussian Estimator)"
+	default:
+		err = fmt.Errorf("unknown continuous mode %d", p.ContinuousMode)
+	}
+	if err != nil {
+		return err
+	}
+	output.SetNormaliser("I(W';W,A)", z)
+	writeOutputSD(p, data, result, label, output)
+	return nil
 }
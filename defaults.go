@@ -4,10 +4,14 @@ const (
 	defaultMeasure           = "MI_W"
 	defaultUseContinuous     = false
 	defaultUseSparse         = false
+	defaultStorage           = StorageAuto
+	defaultMemoryBudget      = 4 << 30
 	defaultContinuousMode    = 1
 	defaultUseStateDependent = false
 	defaultBins              = 0
 	defaultIterations        = 100
+	defaultTolerance         = 1e-6
+	defaultCriterion         = "marginal"
 	defaultOutput            = "out.txt"
 	defaultFile              = ""
 	defaultWFile             = ""
@@ -15,4 +19,26 @@ const (
 	defaultSFile             = ""
 	defaultDFile             = ""
 	defaultK                 = 30
+	defaultNormalisation     = NormalisationAuto
+	defaultNoise             = 0.0
+	defaultSeed              = 0
+	defaultTimeIndex         = -1
+	defaultSource            = "A"
+	defaultTarget            = "W"
+	defaultHistory           = 1
+	defaultRedundancy        = "broja"
+	defaultReference         = ReferenceNone
+	defaultUnit              = UnitBits
 )
+
+const (
+	// configVersion is the version of the configuration file schema that is
+	// written by DumpConfig. Files without a version are treated as version 1.
+	configVersion = 2
+	// environmentPrefix is prepended to the upper-case parameter key to get
+	// the name of the environment variable, e.g. GOMI_BINS for -bins
+	environmentPrefix = "GOMI_"
+)
+
+// MeasureNames lists all quantifications that gomi knows about
+var MeasureNames = []string{"MI_W", "MI_A", "MI_A_Prime", "MI_MI", "MI_SY", "MI_SY_NID", "MI_CA", "MI_WA", "MI_WS", "MI_Wp", "CA", "CW", "UI", "CI", "MI_IN", "TE", "AIS", "PID", "MI_SY3", "PID3"}
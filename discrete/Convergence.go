@@ -0,0 +1,102 @@
+package discrete
+
+import (
+	"context"
+	"math"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// Convergence criteria of the iterative scaling
+const (
+	// CriterionKL stops the iteration when the change of D(p||q) between two
+	// iterations (in bits) is below the tolerance
+	CriterionKL = "kl"
+	// CriterionMarginal stops the iteration when the largest violation of a
+	// marginal constraint is below the tolerance
+	CriterionMarginal = "marginal"
+)
+
+// Stopping controls when the iterative scaling stops
+type Stopping struct {
+	// MaxIterations is the maximal number of iterations
+	MaxIterations int
+	// Tolerance is the value of the criterion below which the iteration
+	// stops. With a tolerance of 0, exactly MaxIterations iterations are
+	// performed.
+	Tolerance float64
+	// Criterion is either CriterionKL or CriterionMarginal
+	Criterion string
+	// Trace records the value of the criterion after each iteration
+	Trace bool
+}
+
+// Convergence describes how the iterative scaling stopped
+type Convergence struct {
+	Criterion string
+	Tolerance float64
+	// Achieved is the value of the criterion after the last iteration
+	Achieved   float64
+	Iterations int
+	// Converged is true, if the criterion fell below a positive tolerance
+	// before the maximal number of iterations was reached
+	Converged bool
+	// Trace contains the value of the criterion after each iteration, if
+	// Stopping.Trace was set
+	Trace []float64
+}
+
+// SYFeatures are the features (pairs of variables) of the iterative scaling
+// that is used for MI_SY and MI_Wp
+var SYFeatures = map[string][]int{"W,W'": {0, 2}, "A,W'": {1, 2}, "W,A": {0, 1}}
+
+// SyNidFeatures are the features (pairs of variables) of the iterative
+// scaling that is used for MI_SY_NID
+var SyNidFeatures = map[string][]int{"W,W'": {0, 2}, "A,W'": {1, 2}}
+
+// Scaling is an iterative scaling procedure
+type Scaling interface {
+	// Iterate performs one iteration
+	Iterate()
+	// KL returns D(target||estimate) in bits
+	KL() float64
+	// MaxViolation returns the largest absolute difference between a
+	// marginal of the target and the same marginal of the estimate
+	MaxViolation() float64
+}
+
+// Iterate runs the iterative scaling until the criterion given in s falls
+// below the tolerance, or until the maximal number of iterations is reached,
+// and reports the progress to r. It stops with ctx.Err(), if ctx is
+// cancelled.
+func Iterate(ctx context.Context, is Scaling, s Stopping, stage string, r progress.Reporter) (Convergence, error) {
+	r = progress.OrNone(r)
+	c := Convergence{Criterion: s.Criterion, Tolerance: s.Tolerance}
+	kl := is.KL()
+	for i := 0; i < s.MaxIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return c, err
+		}
+		is.Iterate()
+		c.Iterations = i + 1
+
+		switch s.Criterion {
+		case CriterionKL:
+			last := kl
+			kl = is.KL()
+			c.Achieved = math.Abs(kl - last)
+		default:
+			c.Achieved = is.MaxViolation()
+		}
+		if s.Trace {
+			c.Trace = append(c.Trace, c.Achieved)
+		}
+
+		r.Report(stage, c.Iterations, s.MaxIterations)
+		if s.Tolerance > 0.0 && c.Achieved <= s.Tolerance {
+			c.Converged = true
+			break
+		}
+	}
+	return c, nil
+}
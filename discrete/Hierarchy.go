@@ -0,0 +1,115 @@
+package discrete
+
+import (
+	"context"
+	"math"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// HierarchyFeatures are the features of the iterative scaling of the
+// hierarchy of three sources (W, S, A -> W'), see SynergyHierarchy. The
+// variables are numbered as in p(w',w,s,a). Both families fix the input
+// distribution p(w,s,a). HierarchyFeatures[0] allows only interactions
+// between W' and one source, HierarchyFeatures[1] between W' and pairs of
+// sources.
+var HierarchyFeatures = []map[string][]int{
+	{"W,S,A": {1, 2, 3}, "W',W": {0, 1}, "W',S": {0, 2}, "W',A": {0, 3}},
+	{"W,S,A": {1, 2, 3}, "W',W,S": {0, 1, 2}, "W',W,A": {0, 1, 3}, "W',S,A": {0, 2, 3}},
+}
+
+// Hierarchy is the decomposition of I(W';W,S,A) into the contributions of
+// the interaction orders between W' and the sources W, S, and A (E.
+// Schneidman, S. Still, M. J. Berry, and W. Bialek. Network information and
+// connected correlations. Physical Review Letters, 91(23):238701, 2003, and
+// T. Kahle, E. Olbrich, J. Jost, and N. Ay. Complexity measures from
+// interaction geometry. Physical Review E, 79(2):026201, 2009). p1 and p2 are
+// the projections of p onto the families that are given by the first and the
+// second element of HierarchyFeatures:
+//    Individual = I(W';W,S,A) - D(p||p1)
+//    Pairwise   = D(p||p1) - D(p||p2)
+//    Triple     = D(p||p2)
+// The values sum up to I(W';W,S,A) and are given in bits.
+type Hierarchy struct {
+	// Individual is the information that the sources contain about W'
+	// without synergy
+	Individual float64
+	// Pairwise is the synergy of pairs of sources
+	Pairwise float64
+	// Triple is the synergy that requires all three sources
+	Triple float64
+}
+
+// Synergy returns the synergistic information of the three sources, i.e. the
+// three-source generalisation D(p||p1) of MI_SY
+func (h Hierarchy) Synergy() float64 {
+	return h.Pairwise + h.Triple
+}
+
+// SynergyHierarchy returns the hierarchy of the interaction orders of three
+// sources (see Hierarchy). The two iterative scalings stop as defined by s,
+// or with ctx.Err(), if ctx is cancelled. The returned convergence combines
+// both (see MergeConvergence).
+func SynergyHierarchy(ctx context.Context, pw2w1s1a1 [][][][]float64, s Stopping, r progress.Reporter) (Hierarchy, Convergence, error) {
+	states := []int{len(pw2w1s1a1), len(pw2w1s1a1[0]), len(pw2w1s1a1[0][0]), len(pw2w1s1a1[0][0][0])}
+	p := func(a []int) float64 { return pw2w1s1a1[a[0]][a[1]][a[2]][a[3]] }
+
+	var kl [2]float64
+	var c Convergence
+	for i, stage := range []string{"MI_SY3 iterative scaling (order 1)", "MI_SY3 iterative scaling (order 2)"} {
+		split, ci, err := project(ctx, states, p, HierarchyFeatures[i], s, stage, r)
+		if i == 0 {
+			c = ci
+		} else {
+			c = MergeConvergence(c, ci)
+		}
+		if err != nil {
+			return Hierarchy{}, c, err
+		}
+		kl[i] = klBits(split)
+	}
+
+	mi := informationW2(pw2w1s1a1)
+	return Hierarchy{Individual: mi - kl[0], Pairwise: kl[0] - kl[1], Triple: kl[1]}, c, nil
+}
+
+// MergeConvergence combines the convergence of two consecutive iterative
+// scalings. The result has converged, if both have converged.
+func MergeConvergence(a, b Convergence) Convergence {
+	return Convergence{
+		Criterion:  a.Criterion,
+		Tolerance:  a.Tolerance,
+		Achieved:   math.Max(a.Achieved, b.Achieved),
+		Iterations: a.Iterations + b.Iterations,
+		Converged:  a.Converged && b.Converged,
+		Trace:      append(append([]float64{}, a.Trace...), b.Trace...),
+	}
+}
+
+// informationW2 returns I(W';W,S,A) in bits
+func informationW2(pw2w1s1a1 [][][][]float64) (r float64) {
+	pw2 := make([]float64, len(pw2w1s1a1), len(pw2w1s1a1))
+	pw1s1a1 := map[[3]int]float64{}
+	for w2, p3 := range pw2w1s1a1 {
+		for w1, p2 := range p3 {
+			for s1, p1 := range p2 {
+				for a1, p := range p1 {
+					pw2[w2] += p
+					pw1s1a1[[3]int{w1, s1, a1}] += p
+				}
+			}
+		}
+	}
+	for w2, p3 := range pw2w1s1a1 {
+		for w1, p2 := range p3 {
+			for s1, p1 := range p2 {
+				for a1, p := range p1 {
+					if p > 0.0 {
+						r += p * math.Log2(p/(pw2[w2]*pw1s1a1[[3]int{w1, s1, a1}]))
+					}
+				}
+			}
+		}
+	}
+	return
+}
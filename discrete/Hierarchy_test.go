@@ -0,0 +1,50 @@
+package discrete
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// hierarchyGate returns p(w',w,s,a) of three independent uniform binary
+// sources and W' = f(w,s,a)
+func hierarchyGate(f func(w, s, a int) int) [][][][]float64 {
+	p := make([][][][]float64, 2, 2)
+	for w2 := range p {
+		p[w2] = [][][]float64{{{0, 0}, {0, 0}}, {{0, 0}, {0, 0}}}
+	}
+	for w := 0; w < 2; w++ {
+		for s := 0; s < 2; s++ {
+			for a := 0; a < 2; a++ {
+				p[f(w, s, a)][w][s][a] += 0.125
+			}
+		}
+	}
+	return p
+}
+
+func TestSynergyHierarchy(t *testing.T) {
+	tests := []struct {
+		name string
+		p    [][][][]float64
+		want Hierarchy
+	}{
+		{"copy of W", hierarchyGate(func(w, s, a int) int { return w }), Hierarchy{1.0, 0.0, 0.0}},
+		{"XOR of W and S", hierarchyGate(func(w, s, a int) int { return w ^ s }), Hierarchy{0.0, 1.0, 0.0}},
+		{"XOR of W, S, and A", hierarchyGate(func(w, s, a int) int { return w ^ s ^ a }), Hierarchy{0.0, 0.0, 1.0}},
+	}
+	s := Stopping{MaxIterations: 1000, Tolerance: 1e-12, Criterion: CriterionMarginal}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := SynergyHierarchy(context.Background(), tt.p, s, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got.Individual-tt.want.Individual) > 1e-6 ||
+				math.Abs(got.Pairwise-tt.want.Pairwise) > 1e-6 ||
+				math.Abs(got.Triple-tt.want.Triple) > 1e-6 {
+				t.Errorf("SynergyHierarchy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
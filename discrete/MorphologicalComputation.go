@@ -1,11 +1,12 @@
 package discrete
 
 import (
+	"context"
 	"math"
 
 	"github.com/kzahedi/goent/discrete"
+	"github.com/kzahedi/gomi/progress"
 	stat "gonum.org/v1/gonum/stat"
-	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
 // MorphologicalComputationW quantifies morphological computation as the information that is contained in
@@ -81,99 +82,131 @@ func MorphologicalComputationMI(pw2w1 [][]float64, pa1s1 [][]float64) float64 {
 // MorphologicalComputationSY quantifies morphological computation as the synergistic information that
 // W and A contain about W'. For more details, please read
 // TODO Paper reference
-func MorphologicalComputationSY(pw2w1a1 [][][]float64, iterations int, eta bool) float64 {
-	split := discrete.IterativeScaling{}
-
-	split.NrOfVariables = 3
-	w2Dim := len(pw2w1a1)
-	w1Dim := len(pw2w1a1[0])
-	a1Dim := len(pw2w1a1[0][0])
-	split.NrOfStates = []int{w2Dim, w1Dim, a1Dim}
-
-	split.CreateAlphabet()
-
-	split.PTarget = make([]float64, w2Dim*w1Dim*a1Dim, w2Dim*w1Dim*a1Dim)
-	for i, a := range split.Alphabet {
-		split.PTarget[i] = pw2w1a1[a[0]][a[1]][a[2]]
+// The iterative scaling stops as defined by s, or with ctx.Err(), if ctx is
+// cancelled.
+func MorphologicalComputationSY(ctx context.Context, pw2w1a1 [][][]float64, s Stopping, r progress.Reporter) (float64, Convergence, error) {
+	split, c, err := project(ctx, dims3(pw2w1a1), func(a []int) float64 { return pw2w1a1[a[0]][a[1]][a[2]] }, SYFeatures, s, "MI_SY iterative scaling", r)
+	if err != nil {
+		return 0.0, c, err
 	}
 
-	split.Features = make(map[string][]int)
-	split.Features["W,W'"] = []int{0, 2}
-	split.Features["A,W'"] = []int{1, 2}
-	split.Features["W,A"] = []int{0, 1}
-
-	split.Init()
-	var bar *pb.ProgressBar
+	return klBits(split), c, nil
+}
 
-	if eta == true {
-		bar = pb.StartNew(iterations)
+// MorphologicalComputationSyNid quantifies morphological computation as the synergistic
+// information that W and A contain about W', excluding the input distribution
+// (W,A). For more details, please read
+// TODO Paper reference
+// The iterative scaling stops as defined by s, or with ctx.Err(), if ctx is
+// cancelled.
+func MorphologicalComputationSyNid(ctx context.Context, pw2w1a1 [][][]float64, s Stopping, r progress.Reporter) (float64, Convergence, error) {
+	split, c, err := project(ctx, dims3(pw2w1a1), func(a []int) float64 { return pw2w1a1[a[0]][a[1]][a[2]] }, SyNidFeatures, s, "MI_SY_NID iterative scaling", r)
+	if err != nil {
+		return 0.0, c, err
 	}
 
-	for i := 0; i < iterations; i++ {
-		split.Iterate()
-		if eta == true {
-			bar.Increment()
-		}
-	}
+	return klBits(split), c, nil
+}
 
-	if eta == true {
-		bar.Finish()
+// PairwiseMaximumEntropy returns the distribution q(x,y,z) with maximal
+// entropy that has the same pairwise marginals p(x,y), p(x,z), and p(y,z) as
+// p (the projection of MI_SY). The iterative scaling stops as defined by s,
+// or with ctx.Err(), if ctx is cancelled.
+func PairwiseMaximumEntropy(ctx context.Context, pxyz [][][]float64, s Stopping, r progress.Reporter) ([][][]float64, Convergence, error) {
+	dims := dims3(pxyz)
+	split, c, err := project(ctx, dims, func(a []int) float64 { return pxyz[a[0]][a[1]][a[2]] }, SYFeatures, s, "maximum entropy iterative scaling", r)
+	if err != nil {
+		return nil, c, err
 	}
 
-	return stat.KullbackLeibler(split.PTarget, split.PEstimate) / math.Log(2)
+	q := discrete.Create3D(dims[0], dims[1], dims[2])
+	for i, a := range split.Alphabet {
+		q[a[0]][a[1]][a[2]] = split.PEstimate[i]
+	}
+	return q, c, nil
 }
 
-// MorphologicalComputationSyNid quantifies morphological computation as the synergistic
-// information that W and A contain about W', excluding the input distribution
-// (W,A). For more details, please read
-// TODO Paper reference
-func MorphologicalComputationSyNid(pw2w1a1 [][][]float64, iterations int, eta bool) float64 {
+// project runs the iterative scaling of the distribution p over variables
+// with the given numbers of states onto the family that is defined by the
+// features. p returns the probability of a state of the alphabet.
+func project(ctx context.Context, states []int, p func(a []int) float64, features map[string][]int, s Stopping, stage string, r progress.Reporter) (*discrete.IterativeScaling, Convergence, error) {
 	split := discrete.IterativeScaling{}
 
-	split.NrOfVariables = 3
-	w2Dim := len(pw2w1a1)
-	w1Dim := len(pw2w1a1[0])
-	a1Dim := len(pw2w1a1[0][0])
-	split.NrOfStates = []int{w2Dim, w1Dim, a1Dim}
+	split.NrOfVariables = len(states)
+	split.NrOfStates = states
 
 	split.CreateAlphabet()
 
-	split.PTarget = make([]float64, w2Dim*w1Dim*a1Dim, w2Dim*w1Dim*a1Dim)
+	split.PTarget = make([]float64, len(split.Alphabet), len(split.Alphabet))
 	for i, a := range split.Alphabet {
-		split.PTarget[i] = pw2w1a1[a[0]][a[1]][a[2]]
+		split.PTarget[i] = p(a)
 	}
 
-	split.Features = make(map[string][]int)
-	split.Features["W,W'"] = []int{0, 2}
-	split.Features["A,W'"] = []int{1, 2}
+	split.Features = features
 
-	var bar *pb.ProgressBar
+	split.Init()
+	c, err := Iterate(ctx, denseScaling{&split}, s, stage, r)
+	return &split, c, err
+}
 
-	if eta == true {
-		bar = pb.StartNew(iterations)
-	}
+// dims3 returns the numbers of states of a three-dimensional distribution
+func dims3(p [][][]float64) []int {
+	return []int{len(p), len(p[0]), len(p[0][0])}
+}
 
-	split.Init()
-	for i := 0; i < iterations; i++ {
-		split.Iterate()
-		if eta == true {
-			bar.Increment()
-		}
-	}
+// denseScaling adds the convergence criteria to the iterative scaling
+type denseScaling struct {
+	*discrete.IterativeScaling
+}
 
-	if eta == true {
-		bar.Finish()
-	}
+func (d denseScaling) KL() float64 {
+	return klBits(d.IterativeScaling)
+}
 
+func (d denseScaling) MaxViolation() float64 {
+	return maxViolation(d.IterativeScaling)
+}
+
+// klBits returns D(PTarget||PEstimate) in bits
+func klBits(split *discrete.IterativeScaling) float64 {
 	return stat.KullbackLeibler(split.PTarget, split.PEstimate) / math.Log(2)
 }
 
+// maxViolation returns the largest absolute difference between a marginal of
+// the target and the same marginal of the estimate, over all features
+func maxViolation(split *discrete.IterativeScaling) (v float64) {
+	for _, f := range split.Features {
+		n := 1
+		for _, i := range f {
+			n *= split.NrOfStates[i]
+		}
+		pt := make([]float64, n, n)
+		pe := make([]float64, n, n)
+		for j, a := range split.Alphabet {
+			k := 0
+			for _, i := range f {
+				k = k*split.NrOfStates[i] + a[i]
+			}
+			pt[k] += split.PTarget[j]
+			pe[k] += split.PEstimate[j]
+		}
+		for k := range pt {
+			v = math.Max(v, math.Abs(pt[k]-pe[k]))
+		}
+	}
+	return
+}
+
 // MorphologicalComputationWp calculates the unique information W -> W'. For more details,
 // please see
 // Ghazi-Zahedi, Keyan and Langer, Carlotta and Ay, Nihat,
 // Morphological Computation: Synergy of Body and Brain, Entropy, 2017
-func MorphologicalComputationWp(pw2w1a1 [][][]float64, iterations int, eta bool) float64 {
-	return MorphologicalComputationW(pw2w1a1) - MorphologicalComputationSY(pw2w1a1, iterations, eta)
+func MorphologicalComputationWp(ctx context.Context, pw2w1a1 [][][]float64, s Stopping, r progress.Reporter) (float64, Convergence, error) {
+	sy, c, err := MorphologicalComputationSY(ctx, pw2w1a1, s, r)
+	if err != nil {
+		return 0.0, c, err
+	}
+	return MorphologicalComputationW(pw2w1a1) - sy, c, nil
 }
 
 // MorphologicalComputationIntrinsicCA [...]
@@ -258,7 +291,7 @@ func MorphologicalComputationIntrinsicCW(ps2s1a1 [][][]float64) (r float64) {
 	ps2Cs1 := discrete.Create2D(s2Dim, s1Dim)
 	ps2Cs1Hat := discrete.Create2D(s2Dim, s1Dim)
 	ps2Cs1a1 := discrete.Create3D(s2Dim, s1Dim, a1Dim)
-	pa1Cs1 := discrete.Create2D(s2Dim, a1Dim)
+	pa1Cs1 := discrete.Create2D(a1Dim, s1Dim)
 	ps1a1 := discrete.Create2D(s1Dim, a1Dim)
 	ps1 := make([]float64, s1Dim, s1Dim)
 	pa1 := make([]float64, a1Dim, a1Dim)
@@ -280,14 +313,18 @@ func MorphologicalComputationIntrinsicCW(ps2s1a1 [][][]float64) (r float64) {
 
 	for s1 := 0; s1 < s1Dim; s1++ {
 		for a1 := 0; a1 < a1Dim; a1++ {
-			pa1Cs1[a1][s1] = ps1a1[s1][a1] / ps1[s1]
+			if ps1[s1] > 0.0 {
+				pa1Cs1[a1][s1] = ps1a1[s1][a1] / ps1[s1]
+			}
 		}
 	}
 
 	for s2 := 0; s2 < s2Dim; s2++ {
 		for s1 := 0; s1 < s1Dim; s1++ {
 			for a1 := 0; a1 < a1Dim; a1++ {
-				ps2Cs1a1[s2][s1][a1] = ps2s1a1[s2][s1][a1] / ps1a1[s1][a1]
+				if ps1a1[s1][a1] > 0.0 {
+					ps2Cs1a1[s2][s1][a1] = ps2s1a1[s2][s1][a1] / ps1a1[s1][a1]
+				}
 			}
 		}
 	}
@@ -296,6 +333,9 @@ func MorphologicalComputationIntrinsicCW(ps2s1a1 [][][]float64) (r float64) {
 		for s1 := 0; s1 < s1Dim; s1++ {
 			for a1 := 0; a1 < a1Dim; a1++ {
 				ps2Cs1[s2][s1] += ps2Cs1a1[s2][s1][a1] * pa1Cs1[a1][s1]
+				if pa1[a1] == 0.0 {
+					continue
+				}
 				for s3 := 0; s3 < s1Dim; s3++ {
 					ps2Cs1Hat[s2][s1] += pa1Cs1[a1][s1] * ps2Cs1a1[s2][s3][a1] * pa1Cs1[a1][s3] * ps1[s3] / pa1[a1]
 				}
@@ -305,7 +345,9 @@ func MorphologicalComputationIntrinsicCW(ps2s1a1 [][][]float64) (r float64) {
 
 	for s2 := 0; s2 < s2Dim; s2++ {
 		for s1 := 0; s1 < s1Dim; s1++ {
-			r += ps2Cs1[s2][s1] * math.Log2(ps2Cs1[s2][s1]/ps2Cs1Hat[s2][s1])
+			if ps2Cs1[s2][s1] > 0.0 && ps2Cs1Hat[s2][s1] > 0.0 {
+				r += ps1[s1] * ps2Cs1[s2][s1] * math.Log2(ps2Cs1[s2][s1]/ps2Cs1Hat[s2][s1])
+			}
 		}
 	}
 
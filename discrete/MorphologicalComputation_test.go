@@ -0,0 +1,97 @@
+package discrete
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+var pw2w1a1 = [][][]float64{
+	{{0.20, 0.05}, {0.10, 0.15}},
+	{{0.05, 0.15}, {0.20, 0.10}},
+}
+
+func TestMorphologicalComputationSYCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := 0
+	r := progress.Func(func(stage string, d, total int) {
+		done = d
+		if d == 5 {
+			cancel()
+		}
+	})
+
+	if _, _, err := MorphologicalComputationSY(ctx, pw2w1a1, Stopping{MaxIterations: 100}, r); err != context.Canceled {
+		t.Errorf("MorphologicalComputationSY() error = %v, want %v", err, context.Canceled)
+	}
+	if done != 5 {
+		t.Errorf("MorphologicalComputationSY() stopped after %d iterations, want 5", done)
+	}
+}
+
+func TestMorphologicalComputationSYConvergence(t *testing.T) {
+	tests := []struct {
+		name          string
+		stopping      Stopping
+		wantConverged bool
+	}{
+		{name: "Fixed number of iterations",
+			stopping: Stopping{MaxIterations: 10}, wantConverged: false},
+		{name: "Marginal constraints",
+			stopping: Stopping{MaxIterations: 1000, Tolerance: 1e-9, Criterion: CriterionMarginal, Trace: true}, wantConverged: true},
+		{name: "Change of KL divergence",
+			stopping: Stopping{MaxIterations: 1000, Tolerance: 1e-12, Criterion: CriterionKL, Trace: true}, wantConverged: true},
+		{name: "Iteration cap",
+			stopping: Stopping{MaxIterations: 2, Tolerance: 1e-15, Criterion: CriterionMarginal}, wantConverged: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, c, err := MorphologicalComputationSY(context.Background(), pw2w1a1, tt.stopping, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Converged != tt.wantConverged {
+				t.Errorf("Converged = %t, want %t (achieved %g after %d iterations)", c.Converged, tt.wantConverged, c.Achieved, c.Iterations)
+			}
+			if tt.wantConverged && (c.Achieved > tt.stopping.Tolerance || c.Iterations >= tt.stopping.MaxIterations) {
+				t.Errorf("achieved %g after %d iterations, want at most %g before %d iterations", c.Achieved, c.Iterations, tt.stopping.Tolerance, tt.stopping.MaxIterations)
+			}
+			if tt.wantConverged == false && c.Iterations != tt.stopping.MaxIterations {
+				t.Errorf("Iterations = %d, want %d", c.Iterations, tt.stopping.MaxIterations)
+			}
+			if tt.stopping.Trace && len(c.Trace) != c.Iterations {
+				t.Errorf("len(Trace) = %d, want %d", len(c.Trace), c.Iterations)
+			}
+		})
+	}
+}
+
+func TestMorphologicalComputationIntrinsicCW(t *testing.T) {
+	// s and a are independent and uniformly distributed
+	tests := []struct {
+		name string
+		// next returns s' for s and a
+		next func(s, a int) int
+		want float64
+	}{
+		{"s' = s", func(s, a int) int { return s }, 1.0},
+		{"s' = a", func(s, a int) int { return a }, 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps2s1a1 := [][][]float64{{{0, 0}, {0, 0}}, {{0, 0}, {0, 0}}}
+			for s := 0; s < 2; s++ {
+				for a := 0; a < 2; a++ {
+					ps2s1a1[tt.next(s, a)][s][a] += 0.25
+				}
+			}
+			if got := MorphologicalComputationIntrinsicCW(ps2s1a1); math.Abs(got-tt.want) > 1e-10 {
+				t.Errorf("MorphologicalComputationIntrinsicCW() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
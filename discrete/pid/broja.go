@@ -0,0 +1,151 @@
+package pid
+
+import (
+	"context"
+	"math"
+
+	entropy "github.com/kzahedi/goent/discrete"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// Inner loop of the optimisation: the Sinkhorn scaling stops after
+// sinkhornIterations iterations or when the largest violation of a
+// marginal falls below sinkhornTolerance
+const (
+	sinkhornIterations = 1000
+	sinkhornTolerance  = 1e-14
+)
+
+// BROJARedundancy returns the redundancy that is given by the unique
+// information of N. Bertschinger, J. Rauh, E. Olbrich, J. Jost, and N. Ay.
+// Quantifying unique information. Entropy, 16(4):2161-2183, 2014:
+//    UI1 = min_{q in Delta_p} I_q(T;X1|X2)
+//    R   = I(T;X1) - UI1 = I(T;X1) + I(T;X2) - min_{q in Delta_p} I_q(T;X1,X2)
+// Delta_p is the set of distributions with the marginals p(t,x1) and
+// p(t,x2). Because of
+//    I_q(T;X1,X2) = min_s D(q(t,x1,x2) || p(t)s(x1,x2))
+// the minimum is found by alternating minimisation (I. Csiszar and G.
+// Tusnady. Information geometry and alternating minimization procedures.
+// Statistics and Decisions, Supplement 1:205-237, 1984): each iteration sets
+// s(x1,x2) = q(x1,x2) and projects p(t)s(x1,x2) onto Delta_p by Sinkhorn
+// scaling. Both sets are convex, hence the iteration converges to the
+// minimum. The projection preserves the marginals, which is why the change
+// of I_q(T;X1,X2) between two iterations is used as the convergence
+// criterion (CriterionKL), independent of s.Criterion.
+func BROJARedundancy(ctx context.Context, ptx1x2 [][][]float64, s discrete.Stopping, r progress.Reporter) (float64, discrete.Convergence, error) {
+	b := newBROJA(ptx1x2)
+	s.Criterion = discrete.CriterionKL
+	c, err := discrete.Iterate(ctx, b, s, "PID (BROJA) optimisation", r)
+	if err != nil {
+		return 0.0, c, err
+	}
+	return entropy.MutualInformationBase2(b.ptx1) + entropy.MutualInformationBase2(b.ptx2) - b.KL(), c, nil
+}
+
+// broja is the state of the alternating minimisation. It implements
+// discrete.Scaling.
+type broja struct {
+	pt         []float64
+	ptx1, ptx2 [][]float64
+	q          [][][]float64
+	// factors of the Sinkhorn scaling of each t, q(t,x1,x2) =
+	// a[t][x1] b[t][x2] p(t) s(x1,x2), which are reused as the initial
+	// values of the next iteration
+	a, b [][]float64
+}
+
+func newBROJA(ptx1x2 [][][]float64) *broja {
+	q := make([][][]float64, len(ptx1x2), len(ptx1x2))
+	for t, plane := range ptx1x2 {
+		q[t] = make([][]float64, len(plane), len(plane))
+		for x1, row := range plane {
+			q[t][x1] = append([]float64{}, row...)
+		}
+	}
+	b := &broja{pt: marginalT(q), ptx1: marginalTX1(q), ptx2: marginalTX2(q), q: q}
+	b.a = entropy.Create2D(len(q), len(q[0]))
+	b.b = entropy.Create2D(len(q), len(q[0][0]))
+	for t := range q {
+		for i := range b.a[t] {
+			b.a[t][i] = 1.0
+		}
+		for i := range b.b[t] {
+			b.b[t][i] = 1.0
+		}
+	}
+	return b
+}
+
+// Iterate performs one step of the alternating minimisation
+func (b *broja) Iterate() {
+	s := marginalX1X2(b.q)
+	for t, pt := range b.pt {
+		if pt <= 0.0 {
+			continue
+		}
+		a, c := b.a[t], b.b[t]
+		for i := 0; i < sinkhornIterations; i++ {
+			scale(a, c, b.ptx1[t], pt, s, false)
+			scale(c, a, b.ptx2[t], pt, s, true)
+			if violation(a, c, b.ptx1[t], pt, s) < sinkhornTolerance {
+				break
+			}
+		}
+		for x1, row := range b.q[t] {
+			for x2 := range row {
+				row[x2] = a[x1] * c[x2] * pt * s[x1][x2]
+			}
+		}
+	}
+}
+
+// scale sets the factors f such that the rows (or, if transposed is set,
+// the columns) of f g p(t) s sum to the target
+func scale(f, g, target []float64, pt float64, s [][]float64, transposed bool) {
+	for i, m := range target {
+		sum := 0.0
+		for j, h := range g {
+			if transposed {
+				sum += h * s[j][i]
+			} else {
+				sum += h * s[i][j]
+			}
+		}
+		f[i] = 0.0
+		if sum > 0.0 {
+			f[i] = m / (pt * sum)
+		}
+	}
+}
+
+// violation returns the largest absolute difference between the row sums
+// of a c p(t) s and the target
+func violation(a, c, target []float64, pt float64, s [][]float64) (v float64) {
+	for x1, m := range target {
+		sum := 0.0
+		for x2, f := range c {
+			sum += f * s[x1][x2]
+		}
+		v = math.Max(v, math.Abs(a[x1]*pt*sum-m))
+	}
+	return
+}
+
+// KL returns the objective I_q(T;X1,X2) in bits
+func (b *broja) KL() float64 {
+	return mutualInformation(b.q)
+}
+
+// MaxViolation returns the largest absolute difference between p(t,x1) or
+// p(t,x2) and the same marginal of q
+func (b *broja) MaxViolation() (v float64) {
+	for _, m := range []struct{ p, q [][]float64 }{{b.ptx1, marginalTX1(b.q)}, {b.ptx2, marginalTX2(b.q)}} {
+		for i, row := range m.p {
+			for j, p := range row {
+				v = math.Max(v, math.Abs(p-m.q[i][j]))
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,189 @@
+package pid
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Atom is an atom of the partial information lattice of three sources. It
+// is named by its antichain, i.e. by the collections of sources whose
+// redundancy it refines, e.g. {X1}{X2,X3}.
+type Atom struct {
+	// Sources lists the collections of the antichain, each as the indices
+	// (0, 1, 2) of its sources
+	Sources [][]int
+	Value   float64
+}
+
+// Name returns the antichain of the atom with the given names of the
+// sources, e.g. {W}{SA} for the names W, S, and A
+func (a Atom) Name(names [3]string) string {
+	var b strings.Builder
+	for _, c := range a.Sources {
+		b.WriteString("{")
+		for _, i := range c {
+			b.WriteString(names[i])
+		}
+		b.WriteString("}")
+	}
+	return b.String()
+}
+
+// antichain is a set of collections of sources, in which no collection
+// contains another. The collections are bit masks of the sources.
+type antichain []int
+
+// lattice3 returns the 18 antichains of the lattice of three sources, such
+// that each antichain follows all antichains below it
+func lattice3() []antichain {
+	var lattice []antichain
+	for set := 1; set < 1<<7; set++ {
+		var a antichain
+		for c := 1; c <= 7; c++ {
+			if set&(1<<uint(c-1)) != 0 {
+				a = append(a, c)
+			}
+		}
+		if a.isAntichain() {
+			lattice = append(lattice, a)
+		}
+	}
+	below := func(a antichain) (n int) {
+		for _, b := range lattice {
+			if b.leq(a) {
+				n++
+			}
+		}
+		return
+	}
+	sort.SliceStable(lattice, func(i, j int) bool { return below(lattice[i]) < below(lattice[j]) })
+	return lattice
+}
+
+func (a antichain) isAntichain() bool {
+	for _, c := range a {
+		for _, d := range a {
+			if c != d && c&d == c {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// leq returns true if a is below b, i.e. if each collection of b contains a
+// collection of a
+func (a antichain) leq(b antichain) bool {
+	for _, d := range b {
+		contained := false
+		for _, c := range a {
+			if c&d == c {
+				contained = true
+				break
+			}
+		}
+		if contained == false {
+			return false
+		}
+	}
+	return true
+}
+
+// Decompose3 returns the 18 atoms of the partial information decomposition
+// of I(T;X1,X2,X3) with the redundancy of Williams and Beer, ordered from the
+// redundancy {X1}{X2}{X3} of all sources to the synergy {X1X2X3}. The
+// redundancy of an antichain is
+//    I_min(A1,...,Ak) = sum_t p(t) min_i I(T=t;X_Ai)
+// and the atoms are its Moebius inversion
+//    Pi(a) = I_min(a) - sum_{b < a} Pi(b)
+// The atoms sum up to I(T;X1,X2,X3) and are given in bits. The distribution
+// is given as p[t][x1][x2][x3].
+func Decompose3(ptx1x2x3 [][][][]float64) []Atom {
+	pt := make([]float64, len(ptx1x2x3), len(ptx1x2x3))
+	for t, p3 := range ptx1x2x3 {
+		for _, p2 := range p3 {
+			for _, p1 := range p2 {
+				for _, p := range p1 {
+					pt[t] += p
+				}
+			}
+		}
+	}
+
+	// specific[c][t] = I(T=t;X_c) for each collection c of sources
+	var specific [8][]float64
+	for c := 1; c <= 7; c++ {
+		ptx := marginalCollection(ptx1x2x3, c)
+		specific[c] = make([]float64, len(pt), len(pt))
+		for t, p := range pt {
+			if p > 0.0 {
+				specific[c][t] = specificInformation(ptx, t)
+			}
+		}
+	}
+
+	lattice := lattice3()
+	atoms := make([]Atom, len(lattice), len(lattice))
+	for i, a := range lattice {
+		redundancy := 0.0
+		for t, p := range pt {
+			if p <= 0.0 {
+				continue
+			}
+			m := math.Inf(1)
+			for _, c := range a {
+				m = math.Min(m, specific[c][t])
+			}
+			redundancy += p * m
+		}
+		atoms[i].Value = redundancy
+		for j, b := range lattice[:i] {
+			if b.leq(a) {
+				atoms[i].Value -= atoms[j].Value
+			}
+		}
+		for _, c := range a {
+			var sources []int
+			for s := 0; s < 3; s++ {
+				if c&(1<<uint(s)) != 0 {
+					sources = append(sources, s)
+				}
+			}
+			atoms[i].Sources = append(atoms[i].Sources, sources)
+		}
+	}
+	return atoms
+}
+
+// marginalCollection returns p(t,x_c) of the sources in the collection c
+// (a bit mask of X1, X2, and X3). The states x_c are numbered consecutively.
+func marginalCollection(ptx1x2x3 [][][][]float64, c int) [][]float64 {
+	n1, n2, n3 := len(ptx1x2x3[0]), len(ptx1x2x3[0][0]), len(ptx1x2x3[0][0][0])
+	index := func(x1, x2, x3 int) int {
+		i := 0
+		if c&1 != 0 {
+			i = x1
+		}
+		if c&2 != 0 {
+			i = i*n2 + x2
+		}
+		if c&4 != 0 {
+			i = i*n3 + x3
+		}
+		return i
+	}
+	n := index(n1-1, n2-1, n3-1) + 1
+	r := make([][]float64, len(ptx1x2x3), len(ptx1x2x3))
+	for t, p3 := range ptx1x2x3 {
+		r[t] = make([]float64, n, n)
+		for x1, p2 := range p3 {
+			for x2, p1 := range p2 {
+				for x3, p := range p1 {
+					r[t][index(x1, x2, x3)] += p
+				}
+			}
+		}
+	}
+	return r
+}
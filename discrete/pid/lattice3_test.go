@@ -0,0 +1,71 @@
+package pid
+
+import (
+	"math"
+	"testing"
+)
+
+// gate3 returns p(t,x1,x2,x3) of three independent uniform binary inputs and
+// the target f(x1,x2,x3), which takes the values 0 to n-1
+func gate3(n int, f func(x1, x2, x3 int) int) [][][][]float64 {
+	p := make([][][][]float64, n, n)
+	for t := range p {
+		p[t] = [][][]float64{{{0.0, 0.0}, {0.0, 0.0}}, {{0.0, 0.0}, {0.0, 0.0}}}
+	}
+	for x1 := 0; x1 < 2; x1++ {
+		for x2 := 0; x2 < 2; x2++ {
+			for x3 := 0; x3 < 2; x3++ {
+				p[f(x1, x2, x3)][x1][x2][x3] += 0.125
+			}
+		}
+	}
+	return p
+}
+
+func TestDecompose3(t *testing.T) {
+	names := [3]string{"W", "S", "A"}
+	// T = X1 = X2 = X3
+	rdn := [][][][]float64{
+		{{{0.5, 0.0}, {0.0, 0.0}}, {{0.0, 0.0}, {0.0, 0.0}}},
+		{{{0.0, 0.0}, {0.0, 0.0}}, {{0.0, 0.0}, {0.0, 0.5}}}}
+
+	tests := []struct {
+		name string
+		p    [][][][]float64
+		atom string
+		mi   float64
+	}{
+		{"COPY X1", gate3(2, func(x1, x2, x3 int) int { return x1 }), "{W}", 1.0},
+		{"XOR X1 X2", gate3(2, func(x1, x2, x3 int) int { return x1 ^ x2 }), "{WS}", 1.0},
+		{"XOR X1 X2 X3", gate3(2, func(x1, x2, x3 int) int { return x1 ^ x2 ^ x3 }), "{WSA}", 1.0},
+		{"RDN", rdn, "{W}{S}{A}", 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atoms := Decompose3(tt.p)
+			if len(atoms) != 18 {
+				t.Fatalf("Decompose3() returned %d atoms, want 18", len(atoms))
+			}
+			if got := atoms[0].Name(names); got != "{W}{S}{A}" {
+				t.Errorf("first atom is %s, want {W}{S}{A}", got)
+			}
+			if got := atoms[17].Name(names); got != "{WSA}" {
+				t.Errorf("last atom is %s, want {WSA}", got)
+			}
+			sum := 0.0
+			for _, a := range atoms {
+				want := 0.0
+				if a.Name(names) == tt.atom {
+					want = tt.mi
+				}
+				if math.Abs(a.Value-want) > 1e-9 {
+					t.Errorf("%s = %f, want %f", a.Name(names), a.Value, want)
+				}
+				sum += a.Value
+			}
+			if math.Abs(sum-tt.mi) > 1e-9 {
+				t.Errorf("sum of atoms = %f, want I(T;X1,X2,X3) = %f", sum, tt.mi)
+			}
+		})
+	}
+}
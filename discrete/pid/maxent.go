@@ -0,0 +1,100 @@
+package pid
+
+import (
+	"context"
+	"math"
+
+	entropy "github.com/kzahedi/goent/discrete"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// CCSRedundancy returns the pointwise common change in surprisal of R. A. A.
+// Ince. Measuring multivariate redundant information with pointwise common
+// change in surprisal. Entropy, 19(7):318, 2017:
+//    c(t,x1,x2) = i(t;x1) + i(t;x2) - i(t;x1,x2)
+//    I_ccs      = sum q(t,x1,x2) c(t,x1,x2)
+// q is the distribution with maximal entropy and the pairwise marginals of
+// p (see discrete.PairwiseMaximumEntropy), and the local information is
+// i(t;x) = log q(t|x)/q(t). A term only contributes, if c and all three
+// local informations have the same sign.
+func CCSRedundancy(ctx context.Context, ptx1x2 [][][]float64, s discrete.Stopping, r progress.Reporter) (float64, discrete.Convergence, error) {
+	q, c, err := discrete.PairwiseMaximumEntropy(ctx, ptx1x2, s, r)
+	if err != nil {
+		return 0.0, c, err
+	}
+	qt := marginalT(q)
+	qtx1 := marginalTX1(q)
+	qtx2 := marginalTX2(q)
+	qx1x2 := marginalX1X2(q)
+	qx1 := make([]float64, len(qx1x2), len(qx1x2))
+	qx2 := make([]float64, len(qx1x2[0]), len(qx1x2[0]))
+	for x1, row := range qx1x2 {
+		for x2, p := range row {
+			qx1[x1] += p
+			qx2[x2] += p
+		}
+	}
+
+	sign := func(x float64) int {
+		switch {
+		case x > 0.0:
+			return 1
+		case x < 0.0:
+			return -1
+		}
+		return 0
+	}
+	redundancy := 0.0
+	for t, plane := range q {
+		for x1, row := range plane {
+			for x2, p := range row {
+				if p <= 0.0 {
+					continue
+				}
+				i1 := math.Log2(qtx1[t][x1] / (qx1[x1] * qt[t]))
+				i2 := math.Log2(qtx2[t][x2] / (qx2[x2] * qt[t]))
+				i12 := math.Log2(p / (qx1x2[x1][x2] * qt[t]))
+				cc := i1 + i2 - i12
+				if sign(i1) == sign(i2) && sign(i2) == sign(i12) && sign(i12) == sign(cc) {
+					redundancy += p * cc
+				}
+			}
+		}
+	}
+	return redundancy, c, nil
+}
+
+// DepRedundancy returns the redundancy of the dependency decomposition of
+// R. G. James, J. Emenheiser, and J. P. Crutchfield. Unique information via
+// dependency constraints. Journal of Physics A, 52(1):014002, 2018. The
+// unique information of X1 is the smallest increase of I(T;X1,X2) in the
+// lattice of maximum entropy distributions, when the constraint p(t,x1) is
+// added. For two sources, this gives
+//    R = max(0, I(T;X1) + I(T;X2) - I_ci, I(T;X1) + I(T;X2) - I_q)
+// where I_ci is I(T;X1,X2) of p(t)p(x1|t)p(x2|t) and I_q that of the
+// distribution with maximal entropy and the pairwise marginals of p (see
+// discrete.PairwiseMaximumEntropy).
+func DepRedundancy(ctx context.Context, ptx1x2 [][][]float64, s discrete.Stopping, r progress.Reporter) (float64, discrete.Convergence, error) {
+	q, c, err := discrete.PairwiseMaximumEntropy(ctx, ptx1x2, s, r)
+	if err != nil {
+		return 0.0, c, err
+	}
+	ptx1 := marginalTX1(ptx1x2)
+	ptx2 := marginalTX2(ptx1x2)
+	pt := marginalT(ptx1x2)
+	ci := entropy.Create3D(len(pt), len(ptx1[0]), len(ptx2[0]))
+	for t := range ci {
+		if pt[t] <= 0.0 {
+			continue
+		}
+		for x1 := range ci[t] {
+			for x2 := range ci[t][x1] {
+				ci[t][x1][x2] = ptx1[t][x1] * ptx2[t][x2] / pt[t]
+			}
+		}
+	}
+
+	i := entropy.MutualInformationBase2(ptx1) + entropy.MutualInformationBase2(ptx2)
+	return math.Max(0.0, math.Max(i-mutualInformation(ci), i-mutualInformation(q))), c, nil
+}
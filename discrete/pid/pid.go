@@ -0,0 +1,203 @@
+// Package pid decomposes the information I(T;X1,X2) that two sources X1 and
+// X2 contain about a target T into the four atoms of the partial information
+// lattice (P. L. Williams and R. D. Beer. Nonnegative decomposition of
+// multivariate information. arXiv:1004.2515, 2010):
+//    I(T;X1,X2) = R + U1 + U2 + S
+//    I(T;X1)    = R + U1
+//    I(T;X2)    = R + U2
+// R is the redundant, U1 and U2 are the unique, and S is the synergistic
+// information. The lattice is determined by one of the measures of
+// redundancy (or unique information) from the literature, see Measures.
+// All values are given in bits. The distributions are given as p[t][x1][x2].
+package pid
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	entropy "github.com/kzahedi/goent/discrete"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// Measures of redundancy
+const (
+	// IMin is the redundancy of Williams and Beer (see IMinRedundancy)
+	IMin = "imin"
+	// BROJA is the unique information of Bertschinger, Rauh, Olbrich, Jost,
+	// and Ay (see BROJARedundancy)
+	BROJA = "broja"
+	// ICCS is the pointwise common change in surprisal of Ince (see
+	// CCSRedundancy)
+	ICCS = "ccs"
+	// IDep is the dependency decomposition of James, Emenheiser, and
+	// Crutchfield (see DepRedundancy)
+	IDep = "dep"
+)
+
+// Measures lists the measures of redundancy
+var Measures = []string{IMin, BROJA, ICCS, IDep}
+
+// Atoms are the atoms of the partial information lattice of two sources
+type Atoms struct {
+	Redundant   float64
+	Unique1     float64
+	Unique2     float64
+	Synergistic float64
+}
+
+// IsMeasure returns true, if name is one of Measures
+func IsMeasure(name string) bool {
+	for _, m := range Measures {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Decompose returns the atoms of I(T;X1,X2) with the given measure of
+// redundancy. The iterative measures (all but IMin) stop as defined by s, or
+// with ctx.Err(), if ctx is cancelled. The returned convergence is empty for
+// IMin.
+func Decompose(ctx context.Context, ptx1x2 [][][]float64, measure string, s discrete.Stopping, r progress.Reporter) (Atoms, discrete.Convergence, error) {
+	var (
+		redundancy float64
+		c          discrete.Convergence
+		err        error
+	)
+	switch measure {
+	case IMin:
+		redundancy = IMinRedundancy(ptx1x2)
+	case BROJA:
+		redundancy, c, err = BROJARedundancy(ctx, ptx1x2, s, r)
+	case ICCS:
+		redundancy, c, err = CCSRedundancy(ctx, ptx1x2, s, r)
+	case IDep:
+		redundancy, c, err = DepRedundancy(ctx, ptx1x2, s, r)
+	default:
+		return Atoms{}, c, fmt.Errorf("unknown measure of redundancy %s (available are %v)", measure, Measures)
+	}
+	if err != nil {
+		return Atoms{}, c, err
+	}
+	return Lattice(ptx1x2, redundancy), c, nil
+}
+
+// Lattice returns the atoms that are determined by the redundancy
+//    U1 = I(T;X1) - R
+//    U2 = I(T;X2) - R
+//    S  = I(T;X1,X2) - I(T;X1) - I(T;X2) + R
+func Lattice(ptx1x2 [][][]float64, redundancy float64) Atoms {
+	i1 := entropy.MutualInformationBase2(marginalTX1(ptx1x2))
+	i2 := entropy.MutualInformationBase2(marginalTX2(ptx1x2))
+	i12 := mutualInformation(ptx1x2)
+	return Atoms{
+		Redundant:   redundancy,
+		Unique1:     i1 - redundancy,
+		Unique2:     i2 - redundancy,
+		Synergistic: i12 - i1 - i2 + redundancy,
+	}
+}
+
+// IMinRedundancy returns the redundancy of Williams and Beer, i.e. the
+// expected minimal specific information of the sources about the target
+//    I_min = sum_t p(t) min_i I(T=t;Xi)
+//    I(T=t;Xi) = sum_xi p(xi|t) log p(t|xi)/p(t)
+func IMinRedundancy(ptx1x2 [][][]float64) (r float64) {
+	pt := marginalT(ptx1x2)
+	ptx1 := marginalTX1(ptx1x2)
+	ptx2 := marginalTX2(ptx1x2)
+	for t, p := range pt {
+		if p <= 0.0 {
+			continue
+		}
+		r += p * math.Min(specificInformation(ptx1, t), specificInformation(ptx2, t))
+	}
+	return
+}
+
+// specificInformation returns I(T=t;X) of the joint distribution p(t,x)
+func specificInformation(ptx [][]float64, t int) (r float64) {
+	pt := 0.0
+	for _, p := range ptx[t] {
+		pt += p
+	}
+	px := make([]float64, len(ptx[t]), len(ptx[t]))
+	for _, row := range ptx {
+		for x, p := range row {
+			px[x] += p
+		}
+	}
+	for x, p := range ptx[t] {
+		if p > 0.0 {
+			r += p / pt * math.Log2(p/(px[x]*pt))
+		}
+	}
+	return
+}
+
+// mutualInformation returns I(T;X1,X2)
+func mutualInformation(ptx1x2 [][][]float64) (r float64) {
+	pt := marginalT(ptx1x2)
+	px1x2 := marginalX1X2(ptx1x2)
+	for t, plane := range ptx1x2 {
+		for x1, row := range plane {
+			for x2, p := range row {
+				if p > 0.0 {
+					r += p * math.Log2(p/(pt[t]*px1x2[x1][x2]))
+				}
+			}
+		}
+	}
+	return
+}
+
+func marginalT(ptx1x2 [][][]float64) []float64 {
+	r := make([]float64, len(ptx1x2), len(ptx1x2))
+	for t, plane := range ptx1x2 {
+		for _, row := range plane {
+			for _, p := range row {
+				r[t] += p
+			}
+		}
+	}
+	return r
+}
+
+func marginalTX1(ptx1x2 [][][]float64) [][]float64 {
+	r := entropy.Create2D(len(ptx1x2), len(ptx1x2[0]))
+	for t, plane := range ptx1x2 {
+		for x1, row := range plane {
+			for _, p := range row {
+				r[t][x1] += p
+			}
+		}
+	}
+	return r
+}
+
+func marginalTX2(ptx1x2 [][][]float64) [][]float64 {
+	r := entropy.Create2D(len(ptx1x2), len(ptx1x2[0][0]))
+	for t, plane := range ptx1x2 {
+		for _, row := range plane {
+			for x2, p := range row {
+				r[t][x2] += p
+			}
+		}
+	}
+	return r
+}
+
+func marginalX1X2(ptx1x2 [][][]float64) [][]float64 {
+	r := entropy.Create2D(len(ptx1x2[0]), len(ptx1x2[0][0]))
+	for _, plane := range ptx1x2 {
+		for x1, row := range plane {
+			for x2, p := range row {
+				r[x1][x2] += p
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,84 @@
+package pid
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/kzahedi/gomi/discrete"
+)
+
+// gate returns p(t,x1,x2) of two independent uniform binary inputs and the
+// target f(x1,x2), which takes the values 0 to n-1
+func gate(n int, f func(x1, x2 int) int) [][][]float64 {
+	p := make([][][]float64, n, n)
+	for t := range p {
+		p[t] = [][]float64{{0.0, 0.0}, {0.0, 0.0}}
+	}
+	for x1 := 0; x1 < 2; x1++ {
+		for x2 := 0; x2 < 2; x2++ {
+			p[f(x1, x2)][x1][x2] += 0.25
+		}
+	}
+	return p
+}
+
+func TestDecompose(t *testing.T) {
+	xor := gate(2, func(x1, x2 int) int { return x1 ^ x2 })
+	rdn := [][][]float64{{{0.5, 0.0}, {0.0, 0.0}}, {{0.0, 0.0}, {0.0, 0.5}}}
+	copy := gate(4, func(x1, x2 int) int { return 2*x1 + x2 })
+	and := gate(2, func(x1, x2 int) int { return x1 & x2 })
+	// I(T;X1) of AND
+	iAnd := 1.5 - 0.75*math.Log2(3.0)
+
+	tests := []struct {
+		name    string
+		p       [][][]float64
+		measure string
+		want    Atoms
+	}{
+		{"XOR imin", xor, IMin, Atoms{0.0, 0.0, 0.0, 1.0}},
+		{"XOR broja", xor, BROJA, Atoms{0.0, 0.0, 0.0, 1.0}},
+		{"XOR ccs", xor, ICCS, Atoms{0.0, 0.0, 0.0, 1.0}},
+		{"XOR dep", xor, IDep, Atoms{0.0, 0.0, 0.0, 1.0}},
+		{"RDN imin", rdn, IMin, Atoms{1.0, 0.0, 0.0, 0.0}},
+		{"RDN broja", rdn, BROJA, Atoms{1.0, 0.0, 0.0, 0.0}},
+		{"RDN ccs", rdn, ICCS, Atoms{1.0, 0.0, 0.0, 0.0}},
+		{"RDN dep", rdn, IDep, Atoms{1.0, 0.0, 0.0, 0.0}},
+		{"COPY imin", copy, IMin, Atoms{1.0, 0.0, 0.0, 1.0}},
+		{"COPY broja", copy, BROJA, Atoms{0.0, 1.0, 1.0, 0.0}},
+		{"COPY ccs", copy, ICCS, Atoms{0.0, 1.0, 1.0, 0.0}},
+		{"COPY dep", copy, IDep, Atoms{0.0, 1.0, 1.0, 0.0}},
+		{"AND imin", and, IMin, Atoms{iAnd, 0.0, 0.0, 0.5}},
+		{"AND broja", and, BROJA, Atoms{iAnd, 0.0, 0.0, 0.5}},
+		{"AND ccs", and, ICCS, Atoms{0.1038, 0.2075, 0.2075, 0.2925}},
+		{"AND dep", and, IDep, Atoms{0.0817, 0.2296, 0.2296, 0.2704}},
+	}
+	s := discrete.Stopping{MaxIterations: 1000, Tolerance: 1e-10, Criterion: discrete.CriterionMarginal}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := Decompose(context.Background(), tt.p, tt.measure, s, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, a := range []struct {
+				name      string
+				got, want float64
+			}{{"redundant", got.Redundant, tt.want.Redundant},
+				{"unique 1", got.Unique1, tt.want.Unique1},
+				{"unique 2", got.Unique2, tt.want.Unique2},
+				{"synergistic", got.Synergistic, tt.want.Synergistic}} {
+				if math.Abs(a.got-a.want) > 1e-4 {
+					t.Errorf("%s = %v, want %v", a.name, a.got, a.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecomposeUnknownMeasure(t *testing.T) {
+	s := discrete.Stopping{MaxIterations: 10}
+	if _, _, err := Decompose(context.Background(), gate(2, func(x1, x2 int) int { return x1 }), "mmi", s, nil); err == nil {
+		t.Errorf("Decompose() with an unknown measure did not return an error")
+	}
+}
@@ -0,0 +1,245 @@
+package sparse
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/kzahedi/goent/sm"
+)
+
+// IterativeScaling approximates the projection of a joint distribution onto
+// the family of distributions that are defined by the features (marginals).
+// In contrast to the dense iterative scaling, the estimate is restricted to
+// the support of the projection, i.e. to the states whose cells in all
+// marginals are observed (the join of the observed marginals). All other
+// states have probability zero in the projection. The memory and the time
+// per iteration therefore grow with the size of the join and not with the
+// size of the alphabet.
+type IterativeScaling struct {
+	Support   []sm.SparseMatrixIndex
+	PTarget   []float64
+	PEstimate []float64
+	features  []feature
+}
+
+// feature is a marginal of the joint distribution
+type feature struct {
+	// cell maps each state of the support to its cell of the marginal
+	cell []int
+	// target is the marginal of the target distribution
+	target []float64
+}
+
+// NewIterativeScaling prepares the iterative scaling of the distribution p
+// with the given features. Each feature lists the variables (dimensions of
+// the indices of p) of one marginal. The estimate is initialised with the
+// uniform distribution on the join of the observed marginals, which contains
+// the observed support of p.
+func NewIterativeScaling(p sm.SparseMatrix, features map[string][]int) *IterativeScaling {
+	// sorted, so that the order of the updates is reproducible
+	var names []string
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	is := IterativeScaling{}
+	is.Support, is.PTarget = joinSupport(p, features, names)
+
+	n := len(is.Support)
+	is.PEstimate = make([]float64, n, n)
+	for i := range is.PEstimate {
+		is.PEstimate[i] = 1.0 / float64(n)
+	}
+
+	for _, name := range names {
+		cells := map[string]int{}
+		f := feature{cell: make([]int, n, n)}
+		for j, index := range is.Support {
+			k := cellKey(index, features[name])
+			c, ok := cells[k]
+			if ok == false {
+				c = len(cells)
+				cells[k] = c
+				f.target = append(f.target, 0.0)
+			}
+			f.cell[j] = c
+			f.target[c] += is.PTarget[j]
+		}
+		is.features = append(is.features, f)
+	}
+	return &is
+}
+
+// joinSupport returns all states whose cells in all features are observed in
+// p, in lexicographic order, together with their probabilities in p (zero
+// for unobserved states).
+func joinSupport(p sm.SparseMatrix, features map[string][]int, names []string) ([]sm.SparseMatrixIndex, []float64) {
+	var support []sm.SparseMatrixIndex
+	var target []float64
+	walkJoin(p, features, names, func(index sm.SparseMatrixIndex, v float64) bool {
+		s := make(sm.SparseMatrixIndex, len(index), len(index))
+		copy(s, index)
+		support = append(support, s)
+		target = append(target, v)
+		return true
+	})
+	return support, target
+}
+
+// JoinSize returns the number of states whose cells in all features are
+// observed in p, i.e. the size of the support of the iterative scaling (see
+// NewIterativeScaling), without storing the states. If limit is positive,
+// the counting stops at limit + 1 states.
+func JoinSize(p sm.SparseMatrix, features map[string][]int, limit int) (n int) {
+	var names []string
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	walkJoin(p, features, names, func(sm.SparseMatrixIndex, float64) bool {
+		n++
+		return limit <= 0 || n <= limit
+	})
+	return
+}
+
+// walkJoin calls visit with each state of the join of the observed marginals
+// of p and its probability in p (zero for unobserved states), until visit
+// returns false. The states are enumerated in lexicographic order one
+// dimension at a time from the observed values of each dimension. A partial
+// state is discarded as soon as the cell of a feature whose variables are all
+// assigned is not observed.
+func walkJoin(p sm.SparseMatrix, features map[string][]int, names []string, visit func(sm.SparseMatrixIndex, float64) bool) {
+	observed := map[string]float64{}
+	var indices []sm.SparseMatrixIndex
+	var values [][]int
+	var all []int
+	for _, index := range p.Indices {
+		v, _ := p.Get(index)
+		if v <= 0.0 {
+			continue
+		}
+		indices = append(indices, index)
+		if values == nil {
+			values = make([][]int, len(index), len(index))
+			all = make([]int, len(index), len(index))
+			for d := range all {
+				all[d] = d
+			}
+		}
+		observed[cellKey(index, all)] += v
+		for d, x := range index {
+			values[d] = append(values[d], x)
+		}
+	}
+	for d := range values {
+		values[d] = uniqueInts(values[d])
+	}
+
+	// cells[d] are the observed cells of the features that are complete once
+	// dimension d is assigned
+	cells := make([][]map[string]bool, len(values), len(values))
+	vars := make([][][]int, len(values), len(values))
+	for _, name := range names {
+		last := 0
+		for _, v := range features[name] {
+			if v > last {
+				last = v
+			}
+		}
+		c := map[string]bool{}
+		for _, index := range indices {
+			c[cellKey(index, features[name])] = true
+		}
+		cells[last] = append(cells[last], c)
+		vars[last] = append(vars[last], features[name])
+	}
+
+	index := make(sm.SparseMatrixIndex, len(values), len(values))
+	var join func(d int) bool
+	join = func(d int) bool {
+		if d == len(values) {
+			return visit(index, observed[cellKey(index, all)])
+		}
+		for _, x := range values[d] {
+			index[d] = x
+			ok := true
+			for i, c := range cells[d] {
+				if c[cellKey(index, vars[d][i])] == false {
+					ok = false
+					break
+				}
+			}
+			if ok && join(d+1) == false {
+				return false
+			}
+		}
+		return true
+	}
+	if len(values) > 0 {
+		join(0)
+	}
+}
+
+func uniqueInts(x []int) []int {
+	sort.Ints(x)
+	var r []int
+	for i, v := range x {
+		if i == 0 || v != x[i-1] {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+func cellKey(index sm.SparseMatrixIndex, variables []int) string {
+	var b []byte
+	for _, v := range variables {
+		b = strconv.AppendInt(b, int64(index[v]), 10)
+		b = append(b, ',')
+	}
+	return string(b)
+}
+
+func (f feature) marginal(p []float64) []float64 {
+	m := make([]float64, len(f.target), len(f.target))
+	for j, c := range f.cell {
+		m[c] += p[j]
+	}
+	return m
+}
+
+// Iterate fits the estimate to each of the marginals once
+func (is *IterativeScaling) Iterate() {
+	for _, f := range is.features {
+		pe := f.marginal(is.PEstimate)
+		for j, c := range f.cell {
+			if pe[c] > 0.0 {
+				is.PEstimate[j] *= f.target[c] / pe[c]
+			}
+		}
+	}
+}
+
+// KL returns D(PTarget||PEstimate) in bits
+func (is *IterativeScaling) KL() (r float64) {
+	for j, p := range is.PTarget {
+		if p > 0.0 && is.PEstimate[j] > 0.0 {
+			r += p * math.Log2(p/is.PEstimate[j])
+		}
+	}
+	return
+}
+
+// MaxViolation returns the largest absolute difference between a marginal of
+// the target and the same marginal of the estimate
+func (is *IterativeScaling) MaxViolation() (v float64) {
+	for _, f := range is.features {
+		for c, pe := range f.marginal(is.PEstimate) {
+			v = math.Max(v, math.Abs(f.target[c]-pe))
+		}
+	}
+	return
+}
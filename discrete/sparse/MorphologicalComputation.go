@@ -1,8 +1,13 @@
 package sparse
 
 import (
+	"context"
+	"math"
+
 	"github.com/kzahedi/goent/discrete/sparse"
 	"github.com/kzahedi/goent/sm"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/progress"
 )
 
 // MorphologicalComputationWSparse quantifies morphological computation as the information that is contained in
@@ -67,242 +72,139 @@ func MorphologicalComputationMI(pw2w1 sm.SparseMatrix, pa1s1 sm.SparseMatrix) fl
 	return sparse.MutualInformationBase2(pw2w1) - sparse.MutualInformationBase2(pa1s1)
 }
 
-// MorphologicalComputationSYSparse quantifies morphological computation as the synergistic information that
-// W and A contain about W'. For more details, please read
+// MorphologicalComputationSY quantifies morphological computation as the synergistic information that
+// W and A contain about W'. The iterative scaling is restricted to the
+// join of the observed marginals of pw2w1a1 (see IterativeScaling). For more details, please read
 // TODO Paper reference
-// func MorphologicalComputationSY(pw2w1a1 [][][]float64, iterations int, eta bool) float64 {
-// 	split := discrete.IterativeScaling{}
-
-// 	split.NrOfVariables = 3
-// 	w2Dim := len(pw2w1a1)
-// 	w1Dim := len(pw2w1a1[0])
-// 	a1Dim := len(pw2w1a1[0][0])
-// 	split.NrOfStates = []int{w2Dim, w1Dim, a1Dim}
-
-// 	split.CreateAlphabet()
-
-// 	split.PTarget = make([]float64, w2Dim*w1Dim*a1Dim, w2Dim*w1Dim*a1Dim)
-// 	for i, a := range split.Alphabet {
-// 		split.PTarget[i] = pw2w1a1[a[0]][a[1]][a[2]]
-// 	}
-
-// 	split.Features = make(map[string][]int)
-// 	split.Features["W,W'"] = []int{0, 2}
-// 	split.Features["A,W'"] = []int{1, 2}
-// 	split.Features["W,A"] = []int{0, 1}
-
-// 	split.Init()
-// 	var bar *pb.ProgressBar
-
-// 	if eta == true {
-// 		bar = pb.StartNew(iterations)
-// 	}
-
-// 	for i := 0; i < iterations; i++ {
-// 		split.Iterate()
-// 		if eta == true {
-// 			bar.Increment()
-// 		}
-// 	}
-
-// 	if eta == true {
-// 		bar.Finish()
-// 	}
-
-// 	return stat.KullbackLeibler(split.PTarget, split.PEstimate) / math.Log(2)
-// }
-
-// // MorphologicalComputationSyNidSparse quantifies morphological computation as the synergistic
-// // information that W and A contain about W', excluding the input distribution
-// // (W,A). For more details, please read
-// // TODO Paper reference
-// func MorphologicalComputationSyNid(pw2w1a1 [][][]float64, iterations int, eta bool) float64 {
-// 	split := discrete.IterativeScaling{}
-
-// 	split.NrOfVariables = 3
-// 	w2Dim := len(pw2w1a1)
-// 	w1Dim := len(pw2w1a1[0])
-// 	a1Dim := len(pw2w1a1[0][0])
-// 	split.NrOfStates = []int{w2Dim, w1Dim, a1Dim}
-
-// 	split.CreateAlphabet()
-
-// 	split.PTarget = make([]float64, w2Dim*w1Dim*a1Dim, w2Dim*w1Dim*a1Dim)
-// 	for i, a := range split.Alphabet {
-// 		split.PTarget[i] = pw2w1a1[a[0]][a[1]][a[2]]
-// 	}
-
-// 	split.Features = make(map[string][]int)
-// 	split.Features["W,W'"] = []int{0, 2}
-// 	split.Features["A,W'"] = []int{1, 2}
-
-// 	var bar *pb.ProgressBar
-
-// 	if eta == true {
-// 		bar = pb.StartNew(iterations)
-// 	}
-
-// 	split.Init()
-// 	for i := 0; i < iterations; i++ {
-// 		split.Iterate()
-// 		if eta == true {
-// 			bar.Increment()
-// 		}
-// 	}
-
-// 	if eta == true {
-// 		bar.Finish()
-// 	}
-
-// 	return stat.KullbackLeibler(split.PTarget, split.PEstimate) / math.Log(2)
-// }
-
-// // MorphologicalComputationWpSparse calculates the unique information W -> W'. For more details,
-// // please see
-// // Ghazi-Zahedi, Keyan and Langer, Carlotta and Ay, Nihat,
-// // Morphological Computation: Synergy of Body and Brain, Entropy, 2017
-// // func MorphologicalComputationWp(pw2w1a1 sm.SparseMatrix, iterations int, eta bool) float64 {
-// // 	return MorphologicalComputationW(pw2w1a1) - MorphologicalComputationSY(pw2w1a1, iterations, eta)
-// // }
-
-// // MorphologicalComputationIntrinsicCASparse [...]
-// // For more details, please read
-// // K. Zahedi and N. Ay. Quantifying morphological computation. Entropy, 15(5):1887–1915, 2013.
-// // http://www.mdpi.com/1099-4300/15/5/1887 (open access)
-// func MorphologicalComputationIntrinsicCA(ps2s1a1 [][][]float64, sbins int) float64 {
-// 	s2Dim := len(ps2s1a1)
-// 	s1Dim := len(ps2s1a1[0])
-// 	a1Dim := len(ps2s1a1[0][0])
-// 	ps1a1 := discrete.Create2D(s1Dim, a1Dim)
-// 	ps2doa1 := discrete.Create2D(s2Dim, a1Dim)
-// 	ps2dos1 := discrete.Create2D(s2Dim, s1Dim)
-// 	pa1Cs1 := discrete.Create2D(a1Dim, s1Dim)
-// 	ps1 := make([]float64, s1Dim, s1Dim)
-
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for s1 := 0; s1 < s1Dim; s1++ {
-// 			for a1 := 0; a1 < a1Dim; a1++ {
-// 				ps1a1[s1][a1] += ps2s1a1[s2][s1][a1]
-// 			}
-// 		}
-// 	}
-
-// 	for s1 := 0; s1 < s1Dim; s1++ {
-// 		for a1 := 0; a1 < a1Dim; a1++ {
-// 			ps1[s1] += ps1a1[s1][a1]
-// 		}
-// 	}
-
-// 	for s1 := 0; s1 < s1Dim; s1++ {
-// 		for a1 := 0; a1 < a1Dim; a1++ {
-// 			if ps1[s1] != 0.0 {
-// 				if ps1[s1] > 0.0 {
-// 					pa1Cs1[a1][s1] = ps1a1[s1][a1] / ps1[s1]
-// 				}
-// 			}
-// 		}
-// 	}
-
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for a1 := 0; a1 < a1Dim; a1++ {
-// 			for s1 := 0; s1 < s1Dim; s1++ {
-// 				if ps1a1[s1][a1] > 0.0 {
-// 					ps2doa1[s2][a1] += ps1[s1] * ps2s1a1[s2][s1][a1] / ps1a1[s1][a1]
-// 				}
-// 			}
-// 		}
-// 	}
-
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for s1 := 0; s1 < s1Dim; s1++ {
-// 			for a1 := 0; a1 < a1Dim; a1++ {
-// 				ps2dos1[s2][s1] += pa1Cs1[a1][s1] * ps2doa1[s2][a1]
-// 			}
-// 		}
-// 	}
-
-// 	r := 0.0
-
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for s1 := 0; s1 < s1Dim; s1++ {
-// 			for a1 := 0; a1 < a1Dim; a1++ {
-// 				if ps2dos1[s2][s1] > 0.0 && ps2doa1[s2][a1] > 0.0 {
-// 					r += ps1a1[s1][a1] * ps2doa1[s2][a1] * math.Log2(ps2doa1[s2][a1]/ps2dos1[s2][s1])
-// 				}
-// 			}
-// 		}
-// 	}
+func MorphologicalComputationSY(ctx context.Context, pw2w1a1 sm.SparseMatrix, s discrete.Stopping, r progress.Reporter) (float64, discrete.Convergence, error) {
+	split := NewIterativeScaling(pw2w1a1, discrete.SYFeatures)
+	c, err := discrete.Iterate(ctx, split, s, "MI_SY iterative scaling (sparse matrix)", r)
+	if err != nil {
+		return 0.0, c, err
+	}
+	return split.KL(), c, nil
+}
 
-// 	return 1.0 - r/math.Log2(float64(sbins))
-// }
+// MorphologicalComputationSyNid quantifies morphological computation as the synergistic
+// information that W and A contain about W', excluding the input distribution
+// (W,A). The iterative scaling is restricted to the join of the observed
+// marginals of pw2w1a1 (see IterativeScaling). For more details, please read
+// TODO Paper reference
+func MorphologicalComputationSyNid(ctx context.Context, pw2w1a1 sm.SparseMatrix, s discrete.Stopping, r progress.Reporter) (float64, discrete.Convergence, error) {
+	split := NewIterativeScaling(pw2w1a1, discrete.SyNidFeatures)
+	c, err := discrete.Iterate(ctx, split, s, "MI_SY_NID iterative scaling (sparse matrix)", r)
+	if err != nil {
+		return 0.0, c, err
+	}
+	return split.KL(), c, nil
+}
 
-// // MorphologicalComputationIntrinsicCW [...]
-// // For more details, please read
-// // K. Zahedi and N. Ay. Quantifying morphological computation. Entropy, 15(5):1887–1915, 2013.
-// // http://www.mdpi.com/1099-4300/15/5/1887 (open access)
-// func MorphologicalComputationIntrinsicCW(ps2s1a1 [][][]float64) (r float64) {
-// 	s2Dim := len(ps2s1a1)
-// 	s1Dim := len(ps2s1a1[0])
-// 	a1Dim := len(ps2s1a1[0][0])
-// 	ps2Cs1 := discrete.Create2D(s2Dim, s1Dim)
-// 	ps2Cs1Hat := discrete.Create2D(s2Dim, s1Dim)
-// 	ps2Cs1a1 := discrete.Create3D(s2Dim, s1Dim, a1Dim)
-// 	pa1Cs1 := discrete.Create2D(s2Dim, a1Dim)
-// 	ps1a1 := discrete.Create2D(s1Dim, a1Dim)
-// 	ps1 := make([]float64, s1Dim, s1Dim)
-// 	pa1 := make([]float64, a1Dim, a1Dim)
+// MorphologicalComputationWp calculates the unique information W -> W'. For more details,
+// please see
+// Ghazi-Zahedi, Keyan and Langer, Carlotta and Ay, Nihat,
+// Morphological Computation: Synergy of Body and Brain, Entropy, 2017
+func MorphologicalComputationWp(ctx context.Context, pw2w1a1 sm.SparseMatrix, s discrete.Stopping, r progress.Reporter) (float64, discrete.Convergence, error) {
+	sy, c, err := MorphologicalComputationSY(ctx, pw2w1a1, s, r)
+	if err != nil {
+		return 0.0, c, err
+	}
+	return MorphologicalComputationW(pw2w1a1) - sy, c, nil
+}
 
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for s1 := 0; s1 < s1Dim; s1++ {
-// 			for a1 := 0; a1 < a1Dim; a1++ {
-// 				ps1a1[s1][a1] += ps2s1a1[s2][s1][a1]
-// 			}
-// 		}
-// 	}
+// SynergyHierarchy returns the hierarchy of the interaction orders of the
+// three sources W, S, and A about W' (see discrete.Hierarchy). The iterative
+// scalings are restricted to the join of the observed marginals of pw2w1s1a1
+// (see IterativeScaling).
+func SynergyHierarchy(ctx context.Context, pw2w1s1a1 sm.SparseMatrix, s discrete.Stopping, r progress.Reporter) (discrete.Hierarchy, discrete.Convergence, error) {
+	var kl [2]float64
+	var c discrete.Convergence
+	var support []sm.SparseMatrixIndex
+	var target []float64
+	for i, stage := range []string{"MI_SY3 iterative scaling (order 1, sparse matrix)", "MI_SY3 iterative scaling (order 2, sparse matrix)"} {
+		split := NewIterativeScaling(pw2w1s1a1, discrete.HierarchyFeatures[i])
+		ci, err := discrete.Iterate(ctx, split, s, stage, r)
+		if i == 0 {
+			c = ci
+		} else {
+			c = discrete.MergeConvergence(c, ci)
+		}
+		if err != nil {
+			return discrete.Hierarchy{}, c, err
+		}
+		kl[i] = split.KL()
+		support, target = split.Support, split.PTarget
+	}
 
-// 	for s1 := 0; s1 < s1Dim; s1++ {
-// 		for a1 := 0; a1 < a1Dim; a1++ {
-// 			ps1[s1] += ps1a1[s1][a1]
-// 			pa1[a1] += ps1a1[s1][a1]
-// 		}
-// 	}
+	// I(W';W,S,A)
+	pw2 := map[int]float64{}
+	pw1s1a1 := map[[3]int]float64{}
+	for j, index := range support {
+		pw2[index[0]] += target[j]
+		pw1s1a1[[3]int{index[1], index[2], index[3]}] += target[j]
+	}
+	mi := 0.0
+	for j, index := range support {
+		if target[j] <= 0.0 {
+			continue
+		}
+		mi += target[j] * math.Log2(target[j]/(pw2[index[0]]*pw1s1a1[[3]int{index[1], index[2], index[3]}]))
+	}
+	return discrete.Hierarchy{Individual: mi - kl[0], Pairwise: kl[0] - kl[1], Triple: kl[1]}, c, nil
+}
 
-// 	for s1 := 0; s1 < s1Dim; s1++ {
-// 		for a1 := 0; a1 < a1Dim; a1++ {
-// 			pa1Cs1[a1][s1] = ps1a1[s1][a1] / ps1[s1]
-// 		}
-// 	}
+// MorphologicalComputationIntrinsicCA [...]
+// For more details, please read
+// K. Zahedi and N. Ay. Quantifying morphological computation. Entropy, 15(5):1887–1915, 2013.
+// http://www.mdpi.com/1099-4300/15/5/1887 (open access)
+func MorphologicalComputationIntrinsicCA(ps2s1a1 sm.SparseMatrix, sbins int) float64 {
+	ps1a1 := map[[2]int]float64{}
+	ps1 := map[int]float64{}
+	for _, index := range ps2s1a1.Indices {
+		v, _ := ps2s1a1.Get(index)
+		ps1a1[[2]int{index[1], index[2]}] += v
+		ps1[index[1]] += v
+	}
 
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for s1 := 0; s1 < s1Dim; s1++ {
-// 			for a1 := 0; a1 < a1Dim; a1++ {
-// 				ps2Cs1a1[s2][s1][a1] = ps2s1a1[s2][s1][a1] / ps1a1[s1][a1]
-// 			}
-// 		}
-// 	}
+	// ps2doa1[a1][s2] = sum_s1 p(s1) p(s2|s1,a1)
+	ps2doa1 := map[int]map[int]float64{}
+	for _, index := range ps2s1a1.Indices {
+		v, _ := ps2s1a1.Get(index)
+		s2, s1, a1 := index[0], index[1], index[2]
+		if ps1a1[[2]int{s1, a1}] > 0.0 {
+			if ps2doa1[a1] == nil {
+				ps2doa1[a1] = map[int]float64{}
+			}
+			ps2doa1[a1][s2] += ps1[s1] * v / ps1a1[[2]int{s1, a1}]
+		}
+	}
 
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for s1 := 0; s1 < s1Dim; s1++ {
-// 			for a1 := 0; a1 < a1Dim; a1++ {
-// 				ps2Cs1[s2][s1] += ps2Cs1a1[s2][s1][a1] * pa1Cs1[a1][s1]
-// 				for s3 := 0; s3 < s1Dim; s3++ {
-// 					ps2Cs1Hat[s2][s1] += pa1Cs1[a1][s1] * ps2Cs1a1[s2][s3][a1] * pa1Cs1[a1][s3] * ps1[s3] / pa1[a1]
-// 				}
-// 			}
-// 		}
-// 	}
+	// ps2dos1[s1][s2] = sum_a1 p(a1|s1) p(s2|do(a1))
+	ps2dos1 := map[int]map[int]float64{}
+	for s1a1, v := range ps1a1 {
+		s1, a1 := s1a1[0], s1a1[1]
+		if ps2dos1[s1] == nil {
+			ps2dos1[s1] = map[int]float64{}
+		}
+		for s2, q := range ps2doa1[a1] {
+			ps2dos1[s1][s2] += v / ps1[s1] * q
+		}
+	}
 
-// 	for s2 := 0; s2 < s2Dim; s2++ {
-// 		for s1 := 0; s1 < s1Dim; s1++ {
-// 			r += ps2Cs1[s2][s1] * math.Log2(ps2Cs1[s2][s1]/ps2Cs1Hat[s2][s1])
-// 		}
-// 	}
+	r := 0.0
+	for s1a1, v := range ps1a1 {
+		s1, a1 := s1a1[0], s1a1[1]
+		for s2, q := range ps2doa1[a1] {
+			if q > 0.0 && ps2dos1[s1][s2] > 0.0 {
+				r += v * q * math.Log2(q/ps2dos1[s1][s2])
+			}
+		}
+	}
 
-// 	return r
-// }
+	return 1.0 - r/math.Log2(float64(sbins))
+}
 
-// // MorphologicalComputationINSparse quantifies morphological computation as the in-sourcable
-// // complexity of the world process.
-// // func MorphologicalComputationIN(pa1s1 [][]float64, abins int) float64 {
-// // 	return math.Log2(float64(abins)) - discrete.MutualInformationBase2(pa1s1)
-// // }
+// MorphologicalComputationIN quantifies morphological computation as the in-sourcable
+// complexity of the world process.
+func MorphologicalComputationIN(pa1s1 sm.SparseMatrix, abins int) float64 {
+	return math.Log2(float64(abins)) - sparse.MutualInformationBase2(pa1s1)
+}
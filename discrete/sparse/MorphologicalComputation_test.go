@@ -0,0 +1,194 @@
+package sparse
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/kzahedi/goent/sm"
+	"github.com/kzahedi/gomi/discrete"
+)
+
+var pw2w1a1 = [][][]float64{
+	{{0.20, 0.05}, {0.10, 0.15}},
+	{{0.05, 0.15}, {0.20, 0.10}},
+}
+
+func toSparse(p [][][]float64) sm.SparseMatrix {
+	s := sm.CreateSparseMatrix()
+	for i := range p {
+		for j := range p[i] {
+			for k, v := range p[i][j] {
+				if v > 0.0 {
+					s.Add(sm.SparseMatrixIndex{i, j, k}, v)
+				}
+			}
+		}
+	}
+	return s
+}
+
+// on a distribution with full support, the sparse and the dense
+// implementations must agree
+func TestMorphologicalComputationSparseEqualsDense(t *testing.T) {
+	ctx := context.Background()
+	s := discrete.Stopping{MaxIterations: 1000, Tolerance: 1e-12, Criterion: discrete.CriterionMarginal}
+	p := toSparse(pw2w1a1)
+
+	tests := []struct {
+		name   string
+		dense  func() (float64, error)
+		sparse func() (float64, error)
+	}{
+		{"MI_SY",
+			func() (float64, error) {
+				r, _, err := discrete.MorphologicalComputationSY(ctx, pw2w1a1, s, nil)
+				return r, err
+			},
+			func() (float64, error) { r, _, err := MorphologicalComputationSY(ctx, p, s, nil); return r, err }},
+		{"MI_SY_NID",
+			func() (float64, error) {
+				r, _, err := discrete.MorphologicalComputationSyNid(ctx, pw2w1a1, s, nil)
+				return r, err
+			},
+			func() (float64, error) { r, _, err := MorphologicalComputationSyNid(ctx, p, s, nil); return r, err }},
+		{"CA",
+			func() (float64, error) { return discrete.MorphologicalComputationIntrinsicCA(pw2w1a1, 2), nil },
+			func() (float64, error) { return MorphologicalComputationIntrinsicCA(p, 2), nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.dense()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.sparse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("sparse = %f, dense = %f", got, want)
+			}
+		})
+	}
+}
+
+// if W' = W XOR A, all pairwise marginals are uniform, so the projection has
+// full support although only half of the states are observed
+func TestMorphologicalComputationXOR(t *testing.T) {
+	ctx := context.Background()
+	s := discrete.Stopping{MaxIterations: 1000, Tolerance: 1e-12, Criterion: discrete.CriterionMarginal}
+	xor := [][][]float64{
+		{{0.25, 0.0}, {0.0, 0.25}},
+		{{0.0, 0.25}, {0.25, 0.0}},
+	}
+	p := toSparse(xor)
+
+	tests := []struct {
+		name   string
+		dense  func() (float64, error)
+		sparse func() (float64, error)
+	}{
+		{"MI_SY",
+			func() (float64, error) {
+				r, _, err := discrete.MorphologicalComputationSY(ctx, xor, s, nil)
+				return r, err
+			},
+			func() (float64, error) { r, _, err := MorphologicalComputationSY(ctx, p, s, nil); return r, err }},
+		{"MI_SY_NID",
+			func() (float64, error) {
+				r, _, err := discrete.MorphologicalComputationSyNid(ctx, xor, s, nil)
+				return r, err
+			},
+			func() (float64, error) { r, _, err := MorphologicalComputationSyNid(ctx, p, s, nil); return r, err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.dense()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.sparse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(want-1.0) > 1e-6 || math.Abs(got-want) > 1e-6 {
+				t.Errorf("sparse = %f, dense = %f, want 1", got, want)
+			}
+		})
+	}
+}
+
+func TestMorphologicalComputationSYSupport(t *testing.T) {
+	p := toSparse([][][]float64{
+		{{0.5, 0.0}, {0.0, 0.0}},
+		{{0.0, 0.0}, {0.0, 0.5}},
+	})
+	is := NewIterativeScaling(p, discrete.SYFeatures)
+	if len(is.Support) != 2 {
+		t.Errorf("len(Support) = %d, want 2", len(is.Support))
+	}
+	if n := JoinSize(p, discrete.SYFeatures, 0); n != len(is.Support) {
+		t.Errorf("JoinSize() = %d, want %d", n, len(is.Support))
+	}
+	r, c, err := MorphologicalComputationSY(context.Background(), p, discrete.Stopping{MaxIterations: 100, Tolerance: 1e-9, Criterion: discrete.CriterionMarginal}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Converged == false || math.Abs(r) > 1e-9 {
+		t.Errorf("MorphologicalComputationSY() = %f (converged %t), want 0 (converged)", r, c.Converged)
+	}
+}
+
+// hierarchyGate returns p(w',w,s,a) of three independent uniform binary
+// sources and W' = f(w,s,a)
+func hierarchyGate(f func(w, s, a int) int) sm.SparseMatrix {
+	p := sm.CreateSparseMatrix()
+	for w := 0; w < 2; w++ {
+		for s := 0; s < 2; s++ {
+			for a := 0; a < 2; a++ {
+				p.Add(sm.SparseMatrixIndex{f(w, s, a), w, s, a}, 0.125)
+			}
+		}
+	}
+	return p
+}
+
+// the join of the pairwise marginals of W' = W xor S contains all 16 states,
+// although only eight are observed
+func TestJoinSize(t *testing.T) {
+	p := hierarchyGate(func(w, s, a int) int { return w ^ s })
+	features := map[string][]int{"W',W": {0, 1}, "W',S": {0, 2}, "W,S": {1, 2}}
+	if n := JoinSize(p, features, 0); n != 16 {
+		t.Errorf("JoinSize() = %d, want 16", n)
+	}
+	if n := JoinSize(p, features, 5); n != 6 {
+		t.Errorf("JoinSize() with limit 5 = %d, want 6", n)
+	}
+}
+
+func TestSynergyHierarchy(t *testing.T) {
+	tests := []struct {
+		name string
+		p    sm.SparseMatrix
+		want discrete.Hierarchy
+	}{
+		{"copy of W", hierarchyGate(func(w, s, a int) int { return w }), discrete.Hierarchy{Individual: 1.0}},
+		{"XOR of W and S", hierarchyGate(func(w, s, a int) int { return w ^ s }), discrete.Hierarchy{Pairwise: 1.0}},
+		{"XOR of W, S, and A", hierarchyGate(func(w, s, a int) int { return w ^ s ^ a }), discrete.Hierarchy{Triple: 1.0}},
+	}
+	s := discrete.Stopping{MaxIterations: 1000, Tolerance: 1e-12, Criterion: discrete.CriterionMarginal}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := SynergyHierarchy(context.Background(), tt.p, s, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got.Individual-tt.want.Individual) > 1e-6 ||
+				math.Abs(got.Pairwise-tt.want.Pairwise) > 1e-6 ||
+				math.Abs(got.Triple-tt.want.Triple) > 1e-6 {
+				t.Errorf("SynergyHierarchy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
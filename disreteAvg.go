@@ -1,27 +1,34 @@
 package gomi
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"os"
 
 	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/discrete/pid"
+	"github.com/kzahedi/gomi/progress"
 )
 
 // DiscreteAvgCalculations ...
-func DiscreteAvgCalculations(p Parameters, d Data) {
+func DiscreteAvgCalculations(ctx context.Context, p Parameters, d Data, r progress.Reporter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	switch p.MeasureName {
 	case "MI_W":
 		miwDiscreteAvg(p, d)
 	case "MI_A":
 		miaDiscreteAvg(p, d)
 	case "MI_A_Prime":
-		miaPrimeDiscreteAvg(p, d)
+		return miaPrimeDiscreteAvg(p, d)
 	case "MI_MI":
 		mimiDiscreteAvg(p, d)
 	case "MI_SY":
-		misyDiscreteAvg(p, d)
+		return misyDiscreteAvg(ctx, p, d, r)
 	case "MI_SY_NID":
-		misynidDiscreteAvg(p, d)
+		return misynidDiscreteAvg(ctx, p, d, r)
 	case "MI_CA":
 		micaDiscreteAvg(p, d)
 	case "MI_WA":
@@ -29,18 +36,27 @@ func DiscreteAvgCalculations(p Parameters, d Data) {
 	case "MI_WS":
 		miwsDiscreteAvg(p, d)
 	case "MI_Wp":
-		miwpDiscreteAvg(p, d)
+		return miwpDiscreteAvg(ctx, p, d, r)
 	case "CA":
-		caDiscreteAvg(p, d)
+		return caDiscreteAvg(p, d)
+	case "CW":
+		return cwDiscreteAvg(p, d)
 	case "UI":
 		uiDiscreteAvg(p, d)
 	case "CI":
 		ciDiscreteAvg(p, d)
 	case "MI_IN":
-		miinDiscreteAvg(p, d)
+		return miinDiscreteAvg(p, d)
+	case "PID":
+		return pidDiscreteAvg(ctx, p, d, r)
+	case "MI_SY3":
+		return misy3DiscreteAvg(ctx, p, d, r)
+	case "PID3":
+		pid3DiscreteAvg(p, d)
 	default:
-		fmt.Println(fmt.Sprintf("unknown measure given %s in the context of discrete-avg measures.", p.MeasureName))
+		return fmt.Errorf("unknown measure given %s in the context of discrete-avg measures.", p.MeasureName)
 	}
+	return nil
 }
 
 func miwDiscreteAvg(p Parameters, data Data) {
@@ -77,13 +93,16 @@ func miaDiscreteAvg(p Parameters, data Data) {
 	writeOutputAvg(p, result, "MI_A discrete", output)
 }
 
-func miaPrimeDiscreteAvg(p Parameters, data Data) {
+func miaPrimeDiscreteAvg(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_A Prime Discrete Avg")
 	}
 
-	wBins := CalculateWBins(p, data)
+	wBins, err := CalculateWBins(p, data)
+	if err != nil {
+		return err
+	}
 
 	pw2a1w1 := MakePW2A1W1(data, p)
 
@@ -94,6 +113,7 @@ func miaPrimeDiscreteAvg(p Parameters, data Data) {
 	result := 1.0 - discrete.MorphologicalComputationA(pw2a1w1)/math.Log2(float64(wBins))
 
 	writeOutputAvg(p, result, "MI_A_Prime discrete", output)
+	return nil
 }
 
 func mimiDiscreteAvg(p Parameters, data Data) {
@@ -114,7 +134,7 @@ func mimiDiscreteAvg(p Parameters, data Data) {
 	// TODO: results look wrong
 }
 
-func misyDiscreteAvg(p Parameters, data Data) {
+func misyDiscreteAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_SY Discrete Avg")
@@ -126,12 +146,18 @@ func misyDiscreteAvg(p Parameters, data Data) {
 		fmt.Println(p)
 	}
 
-	result := discrete.MorphologicalComputationSY(pw2a1w1, p.Iterations, p.Verbose)
+	result, c, err := discrete.MorphologicalComputationSY(ctx, pw2a1w1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_SY", c)
 
 	writeOutputAvg(p, result, "MI_SY discrete", output)
+	return nil
 }
 
-func misynidDiscreteAvg(p Parameters, data Data) {
+func misynidDiscreteAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_SY_NID Discrete Avg")
@@ -143,9 +169,15 @@ func misynidDiscreteAvg(p Parameters, data Data) {
 		fmt.Println(p)
 	}
 
-	result := discrete.MorphologicalComputationSyNid(pw2a1w1, p.Iterations, p.Verbose)
+	result, c, err := discrete.MorphologicalComputationSyNid(ctx, pw2a1w1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_SY_NID", c)
 
 	writeOutputAvg(p, result, "MI_SY_NID discrete", output)
+	return nil
 }
 
 func miwaDiscreteAvg(p Parameters, data Data) {
@@ -182,7 +214,7 @@ func miwsDiscreteAvg(p Parameters, data Data) {
 	writeOutputAvg(p, result, "MI_WS discrete", output)
 }
 
-func miwpDiscreteAvg(p Parameters, data Data) {
+func miwpDiscreteAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_Wp Discrete Avg")
@@ -194,12 +226,18 @@ func miwpDiscreteAvg(p Parameters, data Data) {
 		fmt.Println(p)
 	}
 
-	result := discrete.MorphologicalComputationWp(pw2a1w1, p.Iterations, p.Verbose)
+	result, c, err := discrete.MorphologicalComputationWp(ctx, pw2a1w1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_Wp", c)
 
 	writeOutputAvg(p, result, "MI_Wp discrete", output)
+	return nil
 }
 
-func caDiscreteAvg(p Parameters, data Data) {
+func caDiscreteAvg(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("CA Discrete Avg")
@@ -207,7 +245,10 @@ func caDiscreteAvg(p Parameters, data Data) {
 
 	ps2s1a1 := MakePS2S1A1(data, p)
 
-	sBins := CalculateSBins(p, data)
+	sBins, err := CalculateSBins(p, data)
+	if err != nil {
+		return err
+	}
 
 	if p.Verbose == true {
 		fmt.Println(p)
@@ -215,15 +256,36 @@ func caDiscreteAvg(p Parameters, data Data) {
 
 	result := discrete.MorphologicalComputationIntrinsicCA(ps2s1a1, sBins)
 	writeOutputAvg(p, result, "CA discrete", output)
+	return nil
 }
 
-func miinDiscreteAvg(p Parameters, data Data) {
+func cwDiscreteAvg(p Parameters, data Data) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("CW Discrete Avg")
+	}
+
+	ps2s1a1 := MakePS2S1A1(data, p)
+
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	result := discrete.MorphologicalComputationIntrinsicCW(ps2s1a1)
+	writeOutputAvg(p, result, "CW discrete", output)
+	return nil
+}
+
+func miinDiscreteAvg(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_IN Discrete Avg")
 	}
 
-	aBins := CalculateABins(p, data)
+	aBins, err := CalculateABins(p, data)
+	if err != nil {
+		return err
+	}
 
 	pa1s1 := MakePA1S1(data, p)
 
@@ -233,6 +295,91 @@ func miinDiscreteAvg(p Parameters, data Data) {
 
 	result := discrete.MorphologicalComputationIN(pa1s1, aBins)
 	writeOutputAvg(p, result, "MI_IN discrete", output)
+	return nil
+}
+
+// pidDiscreteAvg decomposes I(W';W,A) into the redundant, the unique, and the
+// synergistic information of W and A (see pid.Decompose)
+func pidDiscreteAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("PID Discrete Avg")
+	}
+
+	pw2w1a1 := MakePW2W1A1(data, p)
+
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	atoms, c, err := pid.Decompose(ctx, pw2w1a1, p.Redundancy, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	if c.Criterion != "" {
+		output.SetConvergence(c)
+		warnIfNotConverged("PID", c)
+	}
+
+	atoms = pid.Atoms{Redundant: p.inUnit(atoms.Redundant), Unique1: p.inUnit(atoms.Unique1),
+		Unique2: p.inUnit(atoms.Unique2), Synergistic: p.inUnit(atoms.Synergistic)}
+	output.SetPIDResult(p.Redundancy, atoms)
+	writeOutputRow(p, []string{"redundant", "unique_w", "unique_a", "synergistic"},
+		[]float64{atoms.Redundant, atoms.Unique1, atoms.Unique2, atoms.Synergistic},
+		fmt.Sprintf("PID (%s) discrete", p.Redundancy), output)
+	return nil
+}
+
+// misy3DiscreteAvg decomposes I(W';W,S,A) into the contributions of the
+// interaction orders of W, S, and A (see discrete.SynergyHierarchy)
+func misy3DiscreteAvg(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_SY3 Discrete Avg")
+	}
+
+	pw2w1s1a1 := MakePW2W1S1A1(data, p)
+
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	h, c, err := discrete.SynergyHierarchy(ctx, pw2w1s1a1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_SY3", c)
+
+	writeOutputHierarchy(p, h, "MI_SY3 discrete", output)
+	return nil
+}
+
+// pid3DiscreteAvg decomposes I(W';W,S,A) into the 18 atoms of the partial
+// information lattice of W, S, and A with the redundancy I_min (see
+// pid.Decompose3)
+func pid3DiscreteAvg(p Parameters, data Data) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("PID3 Discrete Avg")
+	}
+
+	pw2w1s1a1 := MakePW2W1S1A1(data, p)
+
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	atoms := pid.Decompose3(pw2w1s1a1)
+	columns := make([]string, len(atoms), len(atoms))
+	values := make([]float64, len(atoms), len(atoms))
+	for i := range atoms {
+		atoms[i].Value = p.inUnit(atoms[i].Value)
+		columns[i] = atoms[i].Name([3]string{"W", "S", "A"})
+		values[i] = atoms[i].Value
+	}
+	output.SetPID3Result(atoms)
+	writeOutputRow(p, columns, values, "PID3 (imin) discrete", output)
 }
 
 func micaDiscreteAvg(p Parameters, data Data) {
@@ -259,3 +406,12 @@ func uiDiscreteAvg(p Parameters, data Data) {
 func ciDiscreteAvg(p Parameters, data Data) {
 	fmt.Println("CI Discrete Avg is not implemented for discrete data yet.")
 }
+
+// warnIfNotConverged prints a warning, if the iterative scaling reached the
+// maximal number of iterations before the tolerance
+func warnIfNotConverged(label string, c discrete.Convergence) {
+	if c.Tolerance > 0.0 && c.Converged == false {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf("Warning: %s did not converge within %d iterations (%s criterion %g > tolerance %g). Increase the number of iterations (-i) or the tolerance (-tol).",
+			label, c.Iterations, c.Criterion, c.Achieved, c.Tolerance))
+	}
+}
@@ -1,46 +1,54 @@
 package gomi
 
 import (
+	"context"
 	"fmt"
 	"math"
 
 	"github.com/kzahedi/gomi/discrete/sparse"
+	"github.com/kzahedi/gomi/progress"
 )
 
 // DiscreteAvgCalculationsSparse ...
-func DiscreteAvgCalculationsSparse(p Parameters, d Data) {
+func DiscreteAvgCalculationsSparse(ctx context.Context, p Parameters, d Data, r progress.Reporter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	switch p.MeasureName {
 	case "MI_W":
 		miwDiscreteAvgSparse(p, d)
 	case "MI_A":
 		miaDiscreteAvgSparse(p, d)
 	case "MI_A_Prime":
-		miaPrimeDiscreteAvgSparse(p, d)
+		return miaPrimeDiscreteAvgSparse(p, d)
 	case "MI_MI":
 		mimiDiscreteAvgSparse(p, d)
 	case "MI_SY":
-		// misyDiscreteAvgSparse(p, d)
+		return misyDiscreteAvgSparse(ctx, p, d, r)
+	case "MI_SY3":
+		return misy3DiscreteAvgSparse(ctx, p, d, r)
 	case "MI_SY_NID":
-		// misynidDiscreteAvgSparse(p, d)
+		return misynidDiscreteAvgSparse(ctx, p, d, r)
 	case "MI_CA":
-		// micaDiscreteAvgSparse(p, d)
+		micaDiscreteAvgSparse(p, d)
 	case "MI_WA":
-		// miwaDiscreteAvgSparse(p, d)
+		miwaDiscreteAvgSparse(p, d)
 	case "MI_WS":
-		// miwsDiscreteAvgSparse(p, d)
+		miwsDiscreteAvgSparse(p, d)
 	case "MI_Wp":
-		// miwpDiscreteAvgSparse(p, d)
+		return miwpDiscreteAvgSparse(ctx, p, d, r)
 	case "CA":
-		// caDiscreteAvgSparse(p, d)
+		return caDiscreteAvgSparse(p, d)
 	case "UI":
-		// uiDiscreteAvgSparse(p, d)
+		uiDiscreteAvgSparse(p, d)
 	case "CI":
-		// ciDiscreteAvgSparse(p, d)
+		ciDiscreteAvgSparse(p, d)
 	case "MI_IN":
-		// miinDiscreteAvgSparse(p, d)
+		return miinDiscreteAvgSparse(p, d)
 	default:
-		fmt.Println(fmt.Sprintf("unknown measure given %s in the context of discrete-avg measures.", p.MeasureName))
+		return fmt.Errorf("unknown measure given %s in the context of discrete-avg measures.", p.MeasureName)
 	}
+	return nil
 }
 
 func miwDiscreteAvgSparse(p Parameters, data Data) {
@@ -77,13 +85,16 @@ func miaDiscreteAvgSparse(p Parameters, data Data) {
 	writeOutputAvg(p, result, "MI_A discrete (sparse matrix)", output)
 }
 
-func miaPrimeDiscreteAvgSparse(p Parameters, data Data) {
+func miaPrimeDiscreteAvgSparse(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_A Prime Discrete Avg")
 	}
 
-	wBins := CalculateWBins(p, data)
+	wBins, err := CalculateWBins(p, data)
+	if err != nil {
+		return err
+	}
 
 	pw2a1w1 := MakePW2A1W1Sparse(data, p)
 
@@ -94,6 +105,7 @@ func miaPrimeDiscreteAvgSparse(p Parameters, data Data) {
 	result := 1.0 - sparse.MorphologicalComputationA(pw2a1w1)/math.Log2(float64(wBins))
 
 	writeOutputAvg(p, result, "MI_A_Prime discrete (sparse matrix)", output)
+	return nil
 }
 
 func mimiDiscreteAvgSparse(p Parameters, data Data) {
@@ -114,148 +126,197 @@ func mimiDiscreteAvgSparse(p Parameters, data Data) {
 	// TODO: results look wrong
 }
 
-// func misyDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("MI_SY Discrete Avg")
-// 	}
+func misyDiscreteAvgSparse(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_SY Discrete Avg - Sparse Matrix")
+	}
 
-// 	pw2a1w1 := MakePW2A1W1(data, p)
+	pw2a1w1 := MakePW2A1W1Sparse(data, p)
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	result, c, err := sparse.MorphologicalComputationSY(ctx, pw2a1w1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_SY", c)
+
+	writeOutputAvg(p, result, "MI_SY discrete (sparse matrix)", output)
+	return nil
+}
+
+func misy3DiscreteAvgSparse(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_SY3 Discrete Avg - Sparse Matrix")
+	}
+
+	pw2w1s1a1 := MakePW2W1S1A1Sparse(data, p)
+
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationSY(pw2a1w1, p.Iterations, p.Verbose)
+	h, c, err := sparse.SynergyHierarchy(ctx, pw2w1s1a1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_SY3", c)
 
-// 	writeOutputAvg(p, result, "MI_SY discrete", output)
-// }
+	writeOutputHierarchy(p, h, "MI_SY3 discrete (sparse matrix)", output)
+	return nil
+}
 
-// func misynidDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("MI_SY_NID Discrete Avg")
-// 	}
+func misynidDiscreteAvgSparse(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_SY_NID Discrete Avg - Sparse Matrix")
+	}
 
-// 	pw2a1w1 := MakePW2A1W1(data, p)
+	pw2a1w1 := MakePW2A1W1Sparse(data, p)
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationSyNid(pw2a1w1, p.Iterations, p.Verbose)
+	result, c, err := sparse.MorphologicalComputationSyNid(ctx, pw2a1w1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_SY_NID", c)
 
-// 	writeOutputAvg(p, result, "MI_SY_NID discrete", output)
-// }
+	writeOutputAvg(p, result, "MI_SY_NID discrete (sparse matrix)", output)
+	return nil
+}
 
-// func miwaDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("MI_WA Discrete Avg")
-// 	}
+func miwaDiscreteAvgSparse(p Parameters, data Data) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_WA Discrete Avg - Sparse Matrix")
+	}
 
-// 	pw2w1a1 := MakePW2W1A1(data, p)
+	pw2w1a1 := MakePW2W1A1Sparse(data, p)
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationWA(pw2w1a1)
+	result := sparse.MorphologicalComputationWA(pw2w1a1)
 
-// 	writeOutputAvg(p, result, "MI_WA discrete", output)
-// }
+	writeOutputAvg(p, result, "MI_WA discrete (sparse matrix)", output)
+}
 
-// func miwsDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("MI_WS Discrete Avg")
-// 	}
+func miwsDiscreteAvgSparse(p Parameters, data Data) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_WS Discrete Avg - Sparse Matrix")
+	}
 
-// 	pw2w1s1 := MakePW2W1S1(data, p)
+	pw2w1s1 := MakePW2W1S1Sparse(data, p)
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationWS(pw2w1s1)
+	result := sparse.MorphologicalComputationWS(pw2w1s1)
 
-// 	writeOutputAvg(p, result, "MI_WS discrete", output)
-// }
+	writeOutputAvg(p, result, "MI_WS discrete (sparse matrix)", output)
+}
 
-// func miwpDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("MI_Wp Discrete Avg")
-// 	}
+func miwpDiscreteAvgSparse(ctx context.Context, p Parameters, data Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_Wp Discrete Avg - Sparse Matrix")
+	}
 
-// 	pw2a1w1 := MakePW2A1W1(data, p)
+	pw2a1w1 := MakePW2A1W1Sparse(data, p)
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationWp(pw2a1w1, p.Iterations, p.Verbose)
+	result, c, err := sparse.MorphologicalComputationWp(ctx, pw2a1w1, p.stopping(), r)
+	if err != nil {
+		return err
+	}
+	output.SetConvergence(c)
+	warnIfNotConverged("MI_Wp", c)
 
-// 	writeOutputAvg(p, result, "MI_Wp discrete", output)
-// }
+	writeOutputAvg(p, result, "MI_Wp discrete (sparse matrix)", output)
+	return nil
+}
 
-// func caDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("CA Discrete Avg")
-// 	}
+func caDiscreteAvgSparse(p Parameters, data Data) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("CA Discrete Avg - Sparse Matrix")
+	}
 
-// 	ps2s1a1 := MakePS2S1A1(data, p)
+	ps2s1a1 := MakePS2S1A1Sparse(data, p)
 
-// 	sBins := CalculateSBins(p, data)
+	sBins, err := CalculateSBins(p, data)
+	if err != nil {
+		return err
+	}
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationIntrinsicCA(ps2s1a1, sBins)
-// 	writeOutputAvg(p, result, "CA discrete", output)
-// }
+	result := sparse.MorphologicalComputationIntrinsicCA(ps2s1a1, sBins)
+	writeOutputAvg(p, result, "CA discrete (sparse matrix)", output)
+	return nil
+}
 
-// func miinDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("MI_IN Discrete Avg")
-// 	}
+func miinDiscreteAvgSparse(p Parameters, data Data) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_IN Discrete Avg - Sparse Matrix")
+	}
 
-// 	aBins := CalculateABins(p, data)
+	aBins, err := CalculateABins(p, data)
+	if err != nil {
+		return err
+	}
 
-// 	pa1s1 := MakePA1S1(data, p)
+	pa1s1 := MakePA1S1Sparse(data, p)
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationIN(pa1s1, aBins)
-// 	writeOutputAvg(p, result, "MI_IN discrete", output)
-// }
+	result := sparse.MorphologicalComputationIN(pa1s1, aBins)
+	writeOutputAvg(p, result, "MI_IN discrete (sparse matrix)", output)
+	return nil
+}
 
-// func micaDiscreteAvgSparse(p Parameters, data Data) {
-// 	var output Output
-// 	if p.Verbose {
-// 		fmt.Println("MI_CA Discrete Avg")
-// 	}
+func micaDiscreteAvgSparse(p Parameters, data Data) {
+	var output Output
+	if p.Verbose {
+		fmt.Println("MI_CA Discrete Avg - Sparse Matrix")
+	}
 
-// 	pw2w1 := MakePW2W1(data, p)
-// 	pw2a1 := MakePW2A1(data, p)
+	pw2w1 := MakePW2W1Sparse(data, p)
+	pw2a1 := MakePW2A1Sparse(data, p)
 
-// 	if p.Verbose == true {
-// 		fmt.Println(p)
-// 	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
 
-// 	result := discrete.MorphologicalComputationCA(pw2w1, pw2a1)
-// 	writeOutputAvg(p, result, "MI_CA discrete", output)
-// }
+	result := sparse.MorphologicalComputationCA(pw2w1, pw2a1)
+	writeOutputAvg(p, result, "MI_CA discrete (sparse matrix)", output)
+}
 
-// func uiDiscreteAvgSparse(p Parameters, data Data) {
-// 	fmt.Println("UI Discrete Avg is not implemented for discrete data yet.")
-// }
+func uiDiscreteAvgSparse(p Parameters, data Data) {
+	fmt.Println("UI Discrete Avg is not implemented for discrete data yet.")
+}
 
-// func ciDiscreteAvgSparse(p Parameters, data Data) {
-// 	fmt.Println("CI Discrete Avg is not implemented for discrete data yet.")
-// }
+func ciDiscreteAvgSparse(p Parameters, data Data) {
+	fmt.Println("CI Discrete Avg is not implemented for discrete data yet.")
+}
@@ -1,21 +1,26 @@
 package gomi
 
 import (
+	"context"
 	"fmt"
 	"math"
 
 	"github.com/kzahedi/gomi/discrete/state"
+	"github.com/kzahedi/gomi/progress"
 )
 
 // DiscreteSDCalculations ...
-func DiscreteSDCalculations(p Parameters, d Data) {
+func DiscreteSDCalculations(ctx context.Context, p Parameters, d Data, r progress.Reporter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	switch p.MeasureName {
 	case "MI_W":
 		miwDiscreteSD(p, d)
 	case "MI_A":
 		miaDiscreteSD(p, d)
 	case "MI_A_Prime":
-		miaPrimeDiscreteSD(p, d)
+		return miaPrimeDiscreteSD(p, d)
 	case "MI_MI":
 		mimiDiscreteSD(p, d)
 	case "MI_SY":
@@ -35,10 +40,11 @@ func DiscreteSDCalculations(p Parameters, d Data) {
 	case "CI":
 		ciDiscreteSD(p, d)
 	case "MI_IN":
-		miinDiscreteSD(p, d)
+		return miinDiscreteSD(p, d)
 	default:
-		fmt.Println(fmt.Sprintf("unknown measure given %s in the context of discrete-state-dependent measures.", p.MeasureName))
+		return fmt.Errorf("unknown measure given %s in the context of discrete-state-dependent measures.", p.MeasureName)
 	}
+	return nil
 }
 
 func miwDiscreteSD(p Parameters, data Data) {
@@ -54,7 +60,7 @@ func miwDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationW(w2w1a1)
-	writeOutputSD(p, result, "MI_W discrete", output)
+	writeOutputSD(p, data, result, "MI_W discrete", output)
 
 }
 
@@ -71,16 +77,19 @@ func miaDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationA(w2a1w1)
-	writeOutputSD(p, result, "MI_A discrete", output)
+	writeOutputSD(p, data, result, "MI_A discrete", output)
 }
 
-func miaPrimeDiscreteSD(p Parameters, data Data) {
+func miaPrimeDiscreteSD(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_A Prime Discrete Avg")
 	}
 
-	wBins := CalculateWBins(p, data)
+	wBins, err := CalculateWBins(p, data)
+	if err != nil {
+		return err
+	}
 	z := math.Log2(float64(wBins))
 
 	w2a1w1 := MakeW2A1W1Discrete(data, p)
@@ -95,7 +104,8 @@ func miaPrimeDiscreteSD(p Parameters, data Data) {
 		result[i] = 1.0 - v/z
 	}
 
-	writeOutputSD(p, result, "MI_A_Prime discrete", output)
+	writeOutputSD(p, data, result, "MI_A_Prime discrete", output)
+	return nil
 }
 
 func mimiDiscreteSD(p Parameters, data Data) {
@@ -112,7 +122,7 @@ func mimiDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationMI(w2w1, a1s1)
-	writeOutputSD(p, result, "MI_MI discrete", output)
+	writeOutputSD(p, data, result, "MI_MI discrete", output)
 }
 
 func micaDiscreteSD(p Parameters, data Data) {
@@ -129,7 +139,7 @@ func micaDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationCA(w2w1, w2a1)
-	writeOutputSD(p, result, "MI_CA discrete", output)
+	writeOutputSD(p, data, result, "MI_CA discrete", output)
 }
 
 func miwaDiscreteSD(p Parameters, data Data) {
@@ -145,7 +155,7 @@ func miwaDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationWA(w2w1a1)
-	writeOutputSD(p, result, "MI_WA discrete", output)
+	writeOutputSD(p, data, result, "MI_WA discrete", output)
 
 }
 
@@ -162,7 +172,7 @@ func miwsDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationWS(w2w1s1)
-	writeOutputSD(p, result, "MI_WS discrete", output)
+	writeOutputSD(p, data, result, "MI_WS discrete", output)
 }
 
 func caDiscreteSD(p Parameters, data Data) {
@@ -179,16 +189,19 @@ func caDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationCA(w2w1, w2a1)
-	writeOutputSD(p, result, "MI_CA discrete", output)
+	writeOutputSD(p, data, result, "MI_CA discrete", output)
 }
 
-func miinDiscreteSD(p Parameters, data Data) {
+func miinDiscreteSD(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_IN Prime Discrete SD")
 	}
 
-	aBins := CalculateABins(p, data)
+	aBins, err := CalculateABins(p, data)
+	if err != nil {
+		return err
+	}
 	a1s1 := MakeA1S1Discrete(data, p)
 
 	if p.Verbose == true {
@@ -196,7 +209,8 @@ func miinDiscreteSD(p Parameters, data Data) {
 	}
 
 	result := state.MorphologicalComputationIN(a1s1, aBins)
-	writeOutputSD(p, result, "MI_IN discrete", output)
+	writeOutputSD(p, data, result, "MI_IN discrete", output)
+	return nil
 }
 
 func uiDiscreteSD(p Parameters, data Data) {
@@ -1,21 +1,26 @@
 package gomi
 
 import (
+	"context"
 	"fmt"
 	"math"
 
 	"github.com/kzahedi/gomi/discrete/state/sparse"
+	"github.com/kzahedi/gomi/progress"
 )
 
 // DiscreteSDCalculationsSparse ...
-func DiscreteSDCalculationsSparse(p Parameters, d Data) {
+func DiscreteSDCalculationsSparse(ctx context.Context, p Parameters, d Data, r progress.Reporter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	switch p.MeasureName {
 	case "MI_W":
 		miwDiscreteSDSparse(p, d)
 	case "MI_A":
 		miaDiscreteSDSparse(p, d)
 	case "MI_A_Prime":
-		miaPrimeDiscreteSDSparse(p, d)
+		return miaPrimeDiscreteSDSparse(p, d)
 	case "MI_MI":
 		mimiDiscreteSDSparse(p, d)
 	case "MI_SY":
@@ -35,10 +40,11 @@ func DiscreteSDCalculationsSparse(p Parameters, d Data) {
 	case "CI":
 		ciDiscreteSDSparse(p, d)
 	case "MI_IN":
-		miinDiscreteSDSparse(p, d)
+		return miinDiscreteSDSparse(p, d)
 	default:
-		fmt.Println(fmt.Sprintf("unknown measure given %s in the context of discrete-state-dependent measures.", p.MeasureName))
+		return fmt.Errorf("unknown measure given %s in the context of discrete-state-dependent measures.", p.MeasureName)
 	}
+	return nil
 }
 
 func miwDiscreteSDSparse(p Parameters, data Data) {
@@ -54,7 +60,7 @@ func miwDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationW(w2w1a1)
-	writeOutputSD(p, result, "MI_W discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_W discrete (sparse matrix)", output)
 
 }
 
@@ -71,16 +77,19 @@ func miaDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationA(w2a1w1)
-	writeOutputSD(p, result, "MI_A discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_A discrete (sparse matrix)", output)
 }
 
-func miaPrimeDiscreteSDSparse(p Parameters, data Data) {
+func miaPrimeDiscreteSDSparse(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_A Prime Discrete Avg")
 	}
 
-	wBins := CalculateWBins(p, data)
+	wBins, err := CalculateWBins(p, data)
+	if err != nil {
+		return err
+	}
 	z := math.Log2(float64(wBins))
 
 	w2a1w1 := MakeW2A1W1Discrete(data, p)
@@ -95,7 +104,8 @@ func miaPrimeDiscreteSDSparse(p Parameters, data Data) {
 		result[i] = 1.0 - v/z
 	}
 
-	writeOutputSD(p, result, "MI_A_Prime discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_A_Prime discrete (sparse matrix)", output)
+	return nil
 }
 
 func mimiDiscreteSDSparse(p Parameters, data Data) {
@@ -112,7 +122,7 @@ func mimiDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationMI(w2w1, a1s1)
-	writeOutputSD(p, result, "MI_MI discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_MI discrete (sparse matrix)", output)
 }
 
 func micaDiscreteSDSparse(p Parameters, data Data) {
@@ -129,7 +139,7 @@ func micaDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationCA(w2w1, w2a1)
-	writeOutputSD(p, result, "MI_CA discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_CA discrete (sparse matrix)", output)
 }
 
 func miwaDiscreteSDSparse(p Parameters, data Data) {
@@ -145,7 +155,7 @@ func miwaDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationWA(w2w1a1)
-	writeOutputSD(p, result, "MI_WA discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_WA discrete (sparse matrix)", output)
 
 }
 
@@ -162,7 +172,7 @@ func miwsDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationWS(w2w1s1)
-	writeOutputSD(p, result, "MI_WS discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_WS discrete (sparse matrix)", output)
 }
 
 func caDiscreteSDSparse(p Parameters, data Data) {
@@ -179,16 +189,19 @@ func caDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationCA(w2w1, w2a1)
-	writeOutputSD(p, result, "MI_CA discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_CA discrete (sparse matrix)", output)
 }
 
-func miinDiscreteSDSparse(p Parameters, data Data) {
+func miinDiscreteSDSparse(p Parameters, data Data) error {
 	var output Output
 	if p.Verbose {
 		fmt.Println("MI_IN Prime Discrete SD")
 	}
 
-	aBins := CalculateABins(p, data)
+	aBins, err := CalculateABins(p, data)
+	if err != nil {
+		return err
+	}
 	a1s1 := MakeA1S1Discrete(data, p)
 
 	if p.Verbose == true {
@@ -196,7 +209,8 @@ func miinDiscreteSDSparse(p Parameters, data Data) {
 	}
 
 	result := sparse.MorphologicalComputationIN(a1s1, aBins)
-	writeOutputSD(p, result, "MI_IN discrete (sparse matrix)", output)
+	writeOutputSD(p, data, result, "MI_IN discrete (sparse matrix)", output)
+	return nil
 }
 
 func uiDiscreteSDSparse(p Parameters, data Data) {
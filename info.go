@@ -0,0 +1,378 @@
+package gomi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	entropy "github.com/kzahedi/goent/discrete"
+	sparse "github.com/kzahedi/goent/discrete/sparse"
+	dstate "github.com/kzahedi/goent/discrete/state"
+	dstatesparse "github.com/kzahedi/goent/discrete/state/sparse"
+	"github.com/kzahedi/gomi/continuous"
+	cstate "github.com/kzahedi/gomi/continuous/state"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// QueryTerm is a random variable (W, S, or A) at a time offset, e.g. W[t+1]
+type QueryTerm struct {
+	Variable string
+	Offset   int
+}
+
+// Query is an information-theoretic quantity over the random variables W, S,
+// and A at time offsets, e.g. I(W[t+1]; W[t] | A[t]) or H(S[t]). Kind is "H"
+// for the (conditional) entropy H(X|Z) and "I" for the (conditional) mutual
+// information I(X;Y|Z). Y is empty for entropies and Z may be empty.
+type Query struct {
+	Kind    string
+	X, Y, Z []QueryTerm
+}
+
+// isQuery returns true, if the measure name is a query (see ParseQuery)
+func isQuery(name string) bool {
+	return strings.HasPrefix(name, "H(") || strings.HasPrefix(name, "I(")
+}
+
+// ParseQuery parses a query of the form
+//    H(X) | H(X|Z) | I(X;Y) | I(X;Y|Z)
+// where X, Y, and Z are comma-separated lists of terms V[t], V[t+n], or
+// V[t-n], with V one of W, S, and A. V is short for V[t]. White space is
+// ignored.
+func ParseQuery(s string) (Query, error) {
+	var q Query
+	e := strings.Join(strings.Fields(s), "")
+	if isQuery(e) == false || strings.HasSuffix(e, ")") == false {
+		return q, fmt.Errorf("query %q must be of the form H(X), H(X|Z), I(X;Y), or I(X;Y|Z)", s)
+	}
+	q.Kind = e[:1]
+	body := e[2 : len(e)-1]
+
+	var err error
+	if i := strings.Index(body, "|"); i >= 0 {
+		if q.Z, err = parseQueryTerms(body[i+1:]); err != nil {
+			return q, fmt.Errorf("query %q: %s", s, err)
+		}
+		body = body[:i]
+	}
+	parts := strings.Split(body, ";")
+	switch {
+	case q.Kind == "H" && len(parts) != 1:
+		return q, fmt.Errorf("query %q: an entropy has no ';'", s)
+	case q.Kind == "I" && len(parts) != 2:
+		return q, fmt.Errorf("query %q: a mutual information requires exactly one ';'", s)
+	}
+	if q.X, err = parseQueryTerms(parts[0]); err != nil {
+		return q, fmt.Errorf("query %q: %s", s, err)
+	}
+	if q.Kind == "I" {
+		if q.Y, err = parseQueryTerms(parts[1]); err != nil {
+			return q, fmt.Errorf("query %q: %s", s, err)
+		}
+	}
+	return q, nil
+}
+
+// parseQueryTerms parses a comma-separated list of terms, e.g. W[t+1],A
+func parseQueryTerms(s string) ([]QueryTerm, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty list of variables")
+	}
+	var r []QueryTerm
+	for _, f := range strings.Split(s, ",") {
+		if f == "" {
+			return nil, fmt.Errorf("empty variable in %q", s)
+		}
+		t := QueryTerm{Variable: f[:1]}
+		if isVariable(t.Variable) == false {
+			return nil, fmt.Errorf("unknown variable %q, use W, S, or A", f)
+		}
+		switch offset := f[1:]; {
+		case offset == "" || offset == "[t]":
+		case strings.HasPrefix(offset, "[t") && strings.HasSuffix(offset, "]"):
+			n, err := strconv.Atoi(strings.TrimPrefix(offset[2:len(offset)-1], "+"))
+			if err != nil || (offset[2] != '+' && offset[2] != '-') {
+				return nil, fmt.Errorf("cannot parse the time offset of %q, use e.g. %s[t+1]", f, t.Variable)
+			}
+			t.Offset = n
+		default:
+			return nil, fmt.Errorf("cannot parse %q, use e.g. %s[t] or %s[t+1]", f, t.Variable, t.Variable)
+		}
+		r = append(r, t)
+	}
+	return r, nil
+}
+
+// String returns the query in its canonical form, e.g. I(W[t+1];W[t]|A[t])
+func (q Query) String() string {
+	list := func(terms []QueryTerm) string {
+		s := make([]string, len(terms), len(terms))
+		for i, t := range terms {
+			s[i] = t.String()
+		}
+		return strings.Join(s, ",")
+	}
+	s := list(q.X)
+	if q.Kind == "I" {
+		s = fmt.Sprintf("%s;%s", s, list(q.Y))
+	}
+	if len(q.Z) > 0 {
+		s = fmt.Sprintf("%s|%s", s, list(q.Z))
+	}
+	return fmt.Sprintf("%s(%s)", q.Kind, s)
+}
+
+// String returns the term, e.g. W[t+1]
+func (t QueryTerm) String() string {
+	switch {
+	case t.Offset > 0:
+		return fmt.Sprintf("%s[t+%d]", t.Variable, t.Offset)
+	case t.Offset < 0:
+		return fmt.Sprintf("%s[t%d]", t.Variable, t.Offset)
+	}
+	return fmt.Sprintf("%s[t]", t.Variable)
+}
+
+// terms returns all terms of the query in the order X, Y, Z
+func (q Query) terms() []QueryTerm {
+	return append(append(append([]QueryTerm{}, q.X...), q.Y...), q.Z...)
+}
+
+// offsets returns the smallest and the largest time offset of the query
+func (q Query) offsets() (min, max int) {
+	for i, t := range q.terms() {
+		if i == 0 || t.Offset < min {
+			min = t.Offset
+		}
+		if i == 0 || t.Offset > max {
+			max = t.Offset
+		}
+	}
+	return
+}
+
+// spec returns the specification of the query, which is available in all
+// modes. The distribution contains one random variable per term.
+func (q Query) spec() measureSpec {
+	s := measureSpec{modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD}
+	variables := ""
+	for _, t := range q.terms() {
+		variables += t.Variable
+		switch t.Variable {
+		case "W":
+			s.W = true
+		case "S":
+			s.S = true
+		case "A":
+			s.A = true
+		}
+	}
+	s.distributions = []string{variables}
+	return s
+}
+
+// TransferEntropy returns the query of the transfer entropy from the source
+// to the target with the target history k and the source history l
+//    TE = I(Y[t+1]; X[t],...,X[t-l+1] | Y[t],...,Y[t-k+1])
+func TransferEntropy(source, target string, k, l int) Query {
+	q := Query{Kind: "I", X: []QueryTerm{{Variable: target, Offset: 1}}}
+	for i := 0; i < l; i++ {
+		q.Y = append(q.Y, QueryTerm{Variable: source, Offset: -i})
+	}
+	for i := 0; i < k; i++ {
+		q.Z = append(q.Z, QueryTerm{Variable: target, Offset: -i})
+	}
+	return q
+}
+
+// ActiveInformationStorage returns the query of the active information
+// storage of the target with the history k
+//    AIS = I(Y[t+1]; Y[t],...,Y[t-k+1])
+func ActiveInformationStorage(target string, k int) Query {
+	q := Query{Kind: "I", X: []QueryTerm{{Variable: target, Offset: 1}}}
+	for i := 0; i < k; i++ {
+		q.Y = append(q.Y, QueryTerm{Variable: target, Offset: -i})
+	}
+	return q
+}
+
+// query returns the query that is evaluated for the selected measure: the
+// parsed query (see ParseQuery), or the query of TE or AIS, which is given
+// by p.Source, p.Target, p.History, and p.SourceHistory. It returns false
+// for all other measures.
+func (p Parameters) query() (Query, bool, error) {
+	switch {
+	case isQuery(p.MeasureName):
+		q, err := ParseQuery(p.MeasureName)
+		return q, true, err
+	case p.MeasureName != "TE" && p.MeasureName != "AIS":
+		return Query{}, false, nil
+	case isVariable(p.Target) == false:
+		return Query{}, true, fmt.Errorf("the target (-target) of %s must be W, S, or A, got %s", p.MeasureName, p.Target)
+	case p.History < 1:
+		return Query{}, true, fmt.Errorf("the history (-history) of %s must be at least 1, got %d", p.MeasureName, p.History)
+	case p.MeasureName == "AIS":
+		return ActiveInformationStorage(p.Target, p.History), true, nil
+	case isVariable(p.Source) == false:
+		return Query{}, true, fmt.Errorf("the source (-source) of TE must be W, S, or A, got %s", p.Source)
+	case p.Source == p.Target:
+		return Query{}, true, fmt.Errorf("the source and the target of TE must differ, got %s", p.Source)
+	case p.SourceHistory < 1:
+		return Query{}, true, fmt.Errorf("the source history (-shistory) of TE must be at least 1, got %d", p.SourceHistory)
+	}
+	return TransferEntropy(p.Source, p.Target, p.History, p.SourceHistory), true, nil
+}
+
+// rows returns the number of samples of the query for n rows of data. The
+// offsets are shifted such that the smallest offset is 0, i.e. sample i
+// contains the earliest term at row i of the input and belongs to time
+// t = i - min (see pointwiseTable).
+func (q Query) rows(n int) int {
+	min, max := q.offsets()
+	return n - (max - min)
+}
+
+// QueryDiscrete returns the relabelled samples (x, y, z) of the query. For
+// entropies, y equals x, because H(X|Z) = I(X;X|Z). z is 0, if Z is empty.
+func QueryDiscrete(q Query, d Data, p Parameters) [][]int {
+	d.Discretise(p)
+	min, _ := q.offsets()
+	variables := map[string][][]int{"W": d.Discretised.W, "S": d.Discretised.S, "A": d.Discretised.A}
+	n := q.rows(len(variables[q.X[0].Variable]))
+
+	group := func(terms []QueryTerm) []int {
+		if len(terms) == 0 {
+			return make([]int, n, n)
+		}
+		joint := make([][]int, n, n)
+		for i := 0; i < n; i++ {
+			for _, t := range terms {
+				joint[i] = append(joint[i], variables[t.Variable][i+t.Offset-min]...)
+			}
+		}
+		return relabel(joint)
+	}
+
+	x := group(q.X)
+	y := x
+	if q.Kind == "I" {
+		y = group(q.Y)
+	}
+	z := group(q.Z)
+	r := make([][]int, n, n)
+	for i := range r {
+		r[i] = []int{x[i], y[i], z[i]}
+	}
+	return r
+}
+
+// QueryContinuous returns the samples of the query, one row per time step
+// with the columns of the terms in the order X, Y, Z, the column indices of
+// X, Y, and Z, the variables of the terms, e.g. "WWA" (see
+// NormaliseContinuous), and the column indices of each term
+func QueryContinuous(q Query, d Data) (data [][]float64, x, y, z []int, variables string, blocks [][]int) {
+	min, _ := q.offsets()
+	source := map[string][][]float64{"W": d.W, "S": d.S, "A": d.A}
+	n := q.rows(len(source[q.X[0].Variable]))
+	data = make([][]float64, n, n)
+	index := 0
+	add := func(terms []QueryTerm) (indices []int) {
+		for _, t := range terms {
+			for i := 0; i < n; i++ {
+				data[i] = append(data[i], source[t.Variable][i+t.Offset-min]...)
+			}
+			var block []int
+			for range source[t.Variable][0] {
+				block = append(block, index)
+				index++
+			}
+			indices = append(indices, block...)
+			blocks = append(blocks, block)
+			variables += t.Variable
+		}
+		return
+	}
+	x = add(q.X)
+	y = add(q.Y)
+	z = add(q.Z)
+	return
+}
+
+// QueryCalculations evaluates the query of the selected measure (see
+// Parameters.query) with the back end that is selected by the parameters
+// (discrete, sparse, continuous, averaged or state-dependent) and writes the
+// result to p.Output. Discrete results are given in bits, continuous
+// results in nats.
+func QueryCalculations(ctx context.Context, p Parameters, d Data, r progress.Reporter) error {
+	q, ok, err := p.query()
+	if err != nil {
+		return err
+	}
+	if ok == false {
+		return fmt.Errorf("measure %s is not a query", p.MeasureName)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.Verbose {
+		fmt.Println("Query", q)
+	}
+	var output Output
+	name := q.String()
+	if isQuery(p.MeasureName) == false {
+		name = fmt.Sprintf("%s = %s", p.MeasureName, q)
+	}
+
+	if p.UseContinuous == false {
+		xyz := QueryDiscrete(q, d, p)
+		label := fmt.Sprintf("%s discrete", name)
+		if p.UseSparseMatrix {
+			label = fmt.Sprintf("%s (sparse matrix)", label)
+		}
+		switch {
+		case p.UseStateDependent && p.UseSparseMatrix:
+			writeOutputSD(p, d, dstatesparse.ConditionalMutualInformationBase2(xyz), label, output)
+		case p.UseStateDependent:
+			writeOutputSD(p, d, dstate.ConditionalMutualInformationBase2(xyz), label, output)
+		case p.UseSparseMatrix:
+			writeOutputAvg(p, sparse.ConditionalMutualInformationBase2(entropy.Empirical3DSparse(xyz)), label, output)
+		default:
+			writeOutputAvg(p, entropy.ConditionalMutualInformationBase2(entropy.Empirical3D(xyz)), label, output)
+		}
+		return nil
+	}
+
+	data, x, y, z, variables, blocks := QueryContinuous(q, d)
+	categorical := p.categoricalColumns(variables, blocks...)
+	if data, err = normaliseMixed(data, variables, &p, categorical); err != nil {
+		return err
+	}
+	label := fmt.Sprintf("%s continuous", name)
+
+	if p.UseStateDependent {
+		var result []float64
+		if q.Kind == "H" {
+			result, err = cstate.ConditionalEntropy(ctx, data, x, z, categorical, p.ContinuousMode, p.K, r)
+		} else {
+			result, err = cstate.Information(ctx, data, continuous.Term{X: x, Y: y, Z: z}, categorical, p.ContinuousMode, p.K, r)
+		}
+		if err != nil {
+			return err
+		}
+		writeOutputSD(p, d, result, label, output)
+		return nil
+	}
+
+	var result float64
+	if q.Kind == "H" {
+		result, err = continuous.ConditionalEntropy(ctx, data, x, z, categorical, p.ContinuousMode, p.K, r)
+	} else {
+		result, err = continuous.Information(ctx, data, continuous.Term{X: x, Y: y, Z: z}, categorical, p.ContinuousMode, p.K, r)
+	}
+	if err != nil {
+		return err
+	}
+	writeOutputAvg(p, result, label, output)
+	return nil
+}
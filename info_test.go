@@ -0,0 +1,101 @@
+package gomi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"I(W[t+1]; W[t] | A[t])", "I(W[t+1];W[t]|A[t])", false},
+		{"H(S)", "H(S[t])", false},
+		{"H(A[t] | S[t])", "H(A[t]|S[t])", false},
+		{"I(S[t+1]; S[t], A[t-1])", "I(S[t+1];S[t],A[t-1])", false},
+		{"I(W[t+1])", "", true},
+		{"H(W; A)", "", true},
+		{"I(X; W)", "", true},
+		{"I(W[t1]; A)", "", true},
+		{"I(W[s]; A)", "", true},
+		{"H(W,)", "", true},
+		{"MI_W", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && q.String() != tt.want {
+				t.Errorf("ParseQuery() = %s, want %s", q, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryDiscrete(t *testing.T) {
+	var d Data
+	d.W = [][]float64{{0.0}, {1.0}, {0.0}, {1.0}}
+	d.A = [][]float64{{1.0}, {1.0}, {0.0}, {0.0}}
+	p := CreateParametersContainer()
+	p.GlobalBins = 2
+	tests := []struct {
+		query string
+		want  [][]int
+	}{
+		{"I(W[t+1]; W[t] | A[t])", [][]int{{0, 0, 0}, {1, 1, 0}, {0, 0, 1}}},
+		{"I(W[t]; W[t-1] | A[t-1])", [][]int{{0, 0, 0}, {1, 1, 0}, {0, 0, 1}}},
+		{"H(A[t])", [][]int{{0, 0, 0}, {0, 0, 0}, {1, 1, 0}, {1, 1, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := QueryDiscrete(q, d, p); reflect.DeepEqual(got, tt.want) == false {
+				t.Errorf("QueryDiscrete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransferQuery(t *testing.T) {
+	tests := []struct {
+		measure, source, target string
+		k, l                    int
+		want                    string
+		wantErr                 bool
+	}{
+		{"TE", "A", "W", 1, 1, "I(W[t+1];A[t]|W[t])", false},
+		{"TE", "A", "W", 2, 1, "I(W[t+1];A[t]|W[t],W[t-1])", false},
+		{"TE", "S", "A", 1, 2, "I(A[t+1];S[t],S[t-1]|A[t])", false},
+		{"AIS", "", "S", 2, 0, "I(S[t+1];S[t],S[t-1])", false},
+		{"TE", "W", "W", 1, 1, "", true},
+		{"TE", "A", "W", 0, 1, "", true},
+		{"TE", "A", "W", 1, 0, "", true},
+		{"TE", "X", "W", 1, 1, "", true},
+		{"AIS", "", "X", 1, 0, "", true},
+	}
+	for _, tt := range tests {
+		p := CreateParametersContainer()
+		p.MeasureName = tt.measure
+		p.Source = tt.source
+		p.Target = tt.target
+		p.History = tt.k
+		p.SourceHistory = tt.l
+		q, ok, err := p.query()
+		if ok == false {
+			t.Fatalf("query() of %s returned false", tt.measure)
+		}
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("query() of %s %s->%s error = %v, wantErr %v", tt.measure, tt.source, tt.target, err, tt.wantErr)
+		}
+		if err == nil && q.String() != tt.want {
+			t.Errorf("query() = %s, want %s", q, tt.want)
+		}
+	}
+}
@@ -160,6 +160,48 @@ func MakeW2W1S1(d Data, p Parameters) ([][]float64, []int, []int, []int) {
 	return w2w1s1, w2indices, w1indices, s1indices
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// A1, S1 continuous
+////////////////////////////////////////////////////////////////////////////////
+
+// MakeA1S1 returns a slice with (a,s) and list of indices, which indicate
+// which columns contain which information
+func MakeA1S1(d Data, p Parameters) ([][]float64, []int, []int) {
+	checkA(d)
+	checkS(d)
+
+	aDim := len(d.A[0])
+	sDim := len(d.S[0])
+	n := len(d.A)
+	m := aDim + sDim
+	a1s1 := make([][]float64, n, n)
+
+	for i := 0; i < n; i++ {
+		a1s1[i] = make([]float64, m, m)
+		for ai := 0; ai < aDim; ai++ {
+			a1s1[i][ai] = d.A[i][ai]
+		}
+		for si := 0; si < sDim; si++ {
+			a1s1[i][aDim+si] = d.S[i][si]
+		}
+	}
+
+	var a1indices []int
+	var s1indices []int
+
+	index := 0
+	for ai := 0; ai < aDim; ai++ {
+		a1indices = append(a1indices, index)
+		index++
+	}
+	for si := 0; si < sDim; si++ {
+		s1indices = append(s1indices, index)
+		index++
+	}
+
+	return a1s1, a1indices, s1indices
+}
+
 // NormaliseContinuousData ...
 func NormaliseContinuousData(data [][]float64, minArray, maxArray [][]float64, p *Parameters) [][]float64 {
 
@@ -1,7 +1,6 @@
 package gomi
 
 import (
-	"github.com/kzahedi/goent/dh"
 	entropy "github.com/kzahedi/goent/discrete"
 	"github.com/kzahedi/goent/sm"
 )
@@ -17,29 +16,10 @@ func MakeW2W1A1Discrete(d Data, p Parameters) [][]int {
 	checkW(d)
 	checkA(d)
 
-	var wbins []int
-	var abins []int
-
 	d.Discretise(p)
 
-	if len(p.WBins) > 0 {
-		wbins = p.WBins
-	} else {
-		for i := 0; i < len(d.Discretised.W[0]); i++ {
-			wbins = append(wbins, p.GlobalBins)
-		}
-	}
-
-	if len(p.ABins) > 0 {
-		abins = p.ABins
-	} else {
-		for i := 0; i < len(d.Discretised.A[0]); i++ {
-			abins = append(abins, p.GlobalBins)
-		}
-	}
-
-	w := dh.MakeUnivariateRelabelled(d.Discretised.W, wbins)
-	a := dh.MakeUnivariateRelabelled(d.Discretised.A, abins)
+	w := relabel(d.Discretised.W)
+	a := relabel(d.Discretised.A)
 
 	w2w1a1 := make([][]int, len(w)-1, len(w)-1)
 
@@ -77,29 +57,10 @@ func MakeW2A1W1Discrete(d Data, p Parameters) [][]int {
 	checkW(d)
 	checkA(d)
 
-	var wbins []int
-	var abins []int
-
 	d.Discretise(p)
 
-	if len(p.WBins) > 0 {
-		wbins = p.WBins
-	} else {
-		for i := 0; i < len(d.Discretised.W[0]); i++ {
-			wbins = append(wbins, p.GlobalBins)
-		}
-	}
-
-	if len(p.ABins) > 0 {
-		abins = p.ABins
-	} else {
-		for i := 0; i < len(d.Discretised.A[0]); i++ {
-			abins = append(abins, p.GlobalBins)
-		}
-	}
-
-	w := dh.MakeUnivariateRelabelled(d.Discretised.W, wbins)
-	a := dh.MakeUnivariateRelabelled(d.Discretised.A, abins)
+	w := relabel(d.Discretised.W)
+	a := relabel(d.Discretised.A)
 
 	w2a1w1 := make([][]int, len(w)-1, len(w)-1)
 
@@ -136,19 +97,9 @@ func MakePW2A1W1Sparse(d Data, p Parameters) sm.SparseMatrix {
 func MakeW2W1Discrete(d Data, p Parameters) [][]int {
 	checkW(d)
 
-	var wbins []int
-
 	d.Discretise(p)
 
-	if len(p.WBins) > 0 {
-		wbins = p.WBins
-	} else {
-		for i := 0; i < len(d.Discretised.W[0]); i++ {
-			wbins = append(wbins, p.GlobalBins)
-		}
-	}
-
-	w := dh.MakeUnivariateRelabelled(d.Discretised.W, wbins)
+	w := relabel(d.Discretised.W)
 
 	w2w1 := make([][]int, len(w)-1, len(w)-1)
 
@@ -185,29 +136,10 @@ func MakeA1S1Discrete(d Data, p Parameters) [][]int {
 	checkA(d)
 	checkS(d)
 
-	var sbins []int
-	var abins []int
-
 	d.Discretise(p)
 
-	if len(p.ABins) > 0 {
-		abins = p.ABins
-	} else {
-		for i := 0; i < len(d.Discretised.A[0]); i++ {
-			abins = append(abins, p.GlobalBins)
-		}
-	}
-
-	if len(p.SBins) > 0 {
-		sbins = p.SBins
-	} else {
-		for i := 0; i < len(d.Discretised.S[0]); i++ {
-			sbins = append(sbins, p.GlobalBins)
-		}
-	}
-
-	s := dh.MakeUnivariateRelabelled(d.Discretised.S, sbins)
-	a := dh.MakeUnivariateRelabelled(d.Discretised.A, abins)
+	s := relabel(d.Discretised.S)
+	a := relabel(d.Discretised.A)
 
 	a1s1 := make([][]int, len(a), len(a))
 
@@ -244,29 +176,10 @@ func MakeS2S1A1Discrete(d Data, p Parameters) [][]int {
 	checkS(d)
 	checkA(d)
 
-	var sbins []int
-	var abins []int
-
 	d.Discretise(p)
 
-	if len(p.SBins) > 0 {
-		sbins = p.SBins
-	} else {
-		for i := 0; i < len(d.Discretised.S[0]); i++ {
-			sbins = append(sbins, p.GlobalBins)
-		}
-	}
-
-	if len(p.ABins) > 0 {
-		abins = p.ABins
-	} else {
-		for i := 0; i < len(d.Discretised.A[0]); i++ {
-			abins = append(abins, p.GlobalBins)
-		}
-	}
-
-	s := dh.MakeUnivariateRelabelled(d.Discretised.S, sbins)
-	a := dh.MakeUnivariateRelabelled(d.Discretised.A, abins)
+	s := relabel(d.Discretised.S)
+	a := relabel(d.Discretised.A)
 
 	s2s1a1 := make([][]int, len(s)-1, len(s)-1)
 
@@ -287,6 +200,13 @@ func MakePS2S1A1(d Data, p Parameters) [][][]float64 {
 	return ps2s1a1
 }
 
+// MakePS2S1A1Sparse return the joint distribution p(s',s,a) as SparseMatrix
+func MakePS2S1A1Sparse(d Data, p Parameters) sm.SparseMatrix {
+	s2s1a1 := MakeS2S1A1Discrete(d, p)
+	ps2s1a1 := entropy.Empirical3DSparse(s2s1a1)
+	return ps2s1a1
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // W2, W1, S1
 ////////////////////////////////////////////////////////////////////////////////
@@ -297,29 +217,10 @@ func MakeW2W1S1Discrete(d Data, p Parameters) [][]int {
 	checkW(d)
 	checkS(d)
 
-	var wbins []int
-	var sbins []int
-
 	d.Discretise(p)
 
-	if len(p.WBins) > 0 {
-		wbins = p.WBins
-	} else {
-		for i := 0; i < len(d.Discretised.W[0]); i++ {
-			wbins = append(wbins, p.GlobalBins)
-		}
-	}
-
-	if len(p.SBins) > 0 {
-		sbins = p.SBins
-	} else {
-		for i := 0; i < len(d.Discretised.S[0]); i++ {
-			sbins = append(sbins, p.GlobalBins)
-		}
-	}
-
-	w := dh.MakeUnivariateRelabelled(d.Discretised.W, wbins)
-	s := dh.MakeUnivariateRelabelled(d.Discretised.S, sbins)
+	w := relabel(d.Discretised.W)
+	s := relabel(d.Discretised.S)
 
 	w2w1s1 := make([][]int, len(w)-1, len(w)-1)
 
@@ -340,39 +241,95 @@ func MakePW2W1S1(d Data, p Parameters) [][][]float64 {
 	return pw2w1s1
 }
 
+// MakePW2W1S1Sparse return the joint distribution p(w',w,s) as SparseMatrix
+func MakePW2W1S1Sparse(d Data, p Parameters) sm.SparseMatrix {
+	w2w1s1 := MakeW2W1S1Discrete(d, p)
+	pw2w1s1 := entropy.Empirical3DSparse(w2w1s1)
+	return pw2w1s1
+}
+
 ////////////////////////////////////////////////////////////////////////////////
-// W2, A1
+// W2, W1, S1, A1
 ////////////////////////////////////////////////////////////////////////////////
 
-// MakeW2A1Discrete returns a slice with (w',a)
-// The retuned slice has two columns.
-func MakeW2A1Discrete(d Data, p Parameters) [][]int {
+// MakeW2W1S1A1Discrete returns a slice with (w',w,s,a)
+// The retuned slice has four columns.
+func MakeW2W1S1A1Discrete(d Data, p Parameters) [][]int {
 	checkW(d)
+	checkS(d)
 	checkA(d)
 
-	var wbins []int
-	var abins []int
-
 	d.Discretise(p)
 
-	if len(p.WBins) > 0 {
-		wbins = p.WBins
-	} else {
-		for i := 0; i < len(d.Discretised.W[0]); i++ {
-			wbins = append(wbins, p.GlobalBins)
-		}
+	w := relabel(d.Discretised.W)
+	s := relabel(d.Discretised.S)
+	a := relabel(d.Discretised.A)
+
+	w2w1s1a1 := make([][]int, len(w)-1, len(w)-1)
+
+	for i := 0; i < len(w)-1; i++ {
+		w2w1s1a1[i] = make([]int, 4, 4)
+		w2w1s1a1[i][0] = w[i+1]
+		w2w1s1a1[i][1] = w[i]
+		w2w1s1a1[i][2] = s[i]
+		w2w1s1a1[i][3] = a[i]
+	}
+
+	return w2w1s1a1
+}
+
+// MakePW2W1S1A1 returns the joint distribution p(w',w,s,a)
+func MakePW2W1S1A1(d Data, p Parameters) [][][][]float64 {
+	w2w1s1a1 := MakeW2W1S1A1Discrete(d, p)
+	return empirical4D(w2w1s1a1)
+}
+
+// MakePW2W1S1A1Sparse returns the joint distribution p(w',w,s,a) as
+// SparseMatrix
+func MakePW2W1S1A1Sparse(d Data, p Parameters) sm.SparseMatrix {
+	w2w1s1a1 := MakeW2W1S1A1Discrete(d, p)
+	pw2w1s1a1 := sm.CreateSparseMatrix()
+	for _, row := range w2w1s1a1 {
+		pw2w1s1a1.Add(sm.SparseMatrixIndex{row[0], row[1], row[2], row[3]}, 1.0/float64(len(w2w1s1a1)))
 	}
+	return pw2w1s1a1
+}
 
-	if len(p.ABins) > 0 {
-		abins = p.ABins
-	} else {
-		for i := 0; i < len(d.Discretised.A[0]); i++ {
-			abins = append(abins, p.GlobalBins)
+// empirical4D returns the relative frequencies of the rows of data, which
+// has four columns of labels starting at 0
+func empirical4D(data [][]int) [][][][]float64 {
+	var n [4]int
+	for _, row := range data {
+		for i, v := range row {
+			if v >= n[i] {
+				n[i] = v + 1
+			}
 		}
 	}
+	p := make([][][][]float64, n[0], n[0])
+	for i := range p {
+		p[i] = entropy.Create3D(n[1], n[2], n[3])
+	}
+	for _, row := range data {
+		p[row[0]][row[1]][row[2]][row[3]] += 1.0 / float64(len(data))
+	}
+	return p
+}
 
-	w := dh.MakeUnivariateRelabelled(d.Discretised.W, wbins)
-	a := dh.MakeUnivariateRelabelled(d.Discretised.A, abins)
+////////////////////////////////////////////////////////////////////////////////
+// W2, A1
+////////////////////////////////////////////////////////////////////////////////
+
+// MakeW2A1Discrete returns a slice with (w',a)
+// The retuned slice has two columns.
+func MakeW2A1Discrete(d Data, p Parameters) [][]int {
+	checkW(d)
+	checkA(d)
+
+	d.Discretise(p)
+
+	w := relabel(d.Discretised.W)
+	a := relabel(d.Discretised.A)
 
 	w2a1 := make([][]int, len(w)-1, len(w)-1)
 
@@ -391,3 +348,10 @@ func MakePW2A1(d Data, p Parameters) [][]float64 {
 	pw2a1 := entropy.Empirical2D(w2a1)
 	return pw2a1
 }
+
+// MakePW2A1Sparse return the joint distribution p(w',a) as SparseMatrix
+func MakePW2A1Sparse(d Data, p Parameters) sm.SparseMatrix {
+	w2a1 := MakeW2A1Discrete(d, p)
+	pw2a1 := entropy.Empirical2DSparse(w2a1)
+	return pw2a1
+}
@@ -0,0 +1,111 @@
+package gomi
+
+// Modes in which a measure can be calculated
+const (
+	modeDiscreteAvg = 1 << iota
+	modeDiscreteSD
+	modeSparseAvg
+	modeSparseSD
+	modeContinuousAvg
+	modeContinuousSD
+)
+
+// measureSpec describes which random variables a measure requires and in
+// which modes it is implemented
+type measureSpec struct {
+	W, S, A bool
+	// iterative measures use iterative scaling and require Iterations > 0
+	iterative bool
+	modes     int
+	// distributions lists the joint distributions that are built, e.g. "WWA"
+	// for p(w',w,a)
+	distributions []string
+	// normalisedBy is the variable whose alphabet size normalises the measure
+	normalisedBy string
+	// dimensionless measures are ratios of information and have no unit
+	dimensionless bool
+}
+
+var measureSpecs = map[string]measureSpec{
+	"MI_W":       {W: true, A: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"WWA"}},
+	"MI_A":       {W: true, A: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"WAW"}},
+	"MI_A_Prime": {W: true, A: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"WAW"}, normalisedBy: "W", dimensionless: true},
+	"MI_MI":      {W: true, S: true, A: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"WW", "AS"}},
+	"MI_SY":      {W: true, A: true, iterative: true, modes: modeDiscreteAvg | modeSparseAvg, distributions: []string{"WAW"}},
+	"MI_SY_NID":  {W: true, A: true, iterative: true, modes: modeDiscreteAvg | modeSparseAvg, distributions: []string{"WAW"}},
+	"MI_CA":      {W: true, A: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"WW", "WA"}},
+	"MI_WA":      {W: true, A: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"WWA"}},
+	"MI_WS":      {W: true, S: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"WWS"}},
+	"MI_Wp":      {W: true, A: true, iterative: true, modes: modeDiscreteAvg | modeSparseAvg, distributions: []string{"WAW"}},
+	"CA":         {S: true, A: true, modes: modeDiscreteAvg | modeSparseAvg | modeContinuousAvg, distributions: []string{"SSA"}, normalisedBy: "S", dimensionless: true},
+	"CW":         {S: true, A: true, modes: modeDiscreteAvg | modeContinuousAvg, distributions: []string{"SSA"}},
+	"UI":         {},
+	"CI":         {},
+	"MI_IN":      {S: true, A: true, modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD, distributions: []string{"AS"}, normalisedBy: "A"},
+	"PID":        {W: true, A: true, iterative: true, modes: modeDiscreteAvg, distributions: []string{"WWA"}},
+	"MI_SY3":     {W: true, S: true, A: true, iterative: true, modes: modeDiscreteAvg | modeSparseAvg, distributions: []string{"WWSA"}},
+	"PID3":       {W: true, S: true, A: true, modes: modeDiscreteAvg, distributions: []string{"WWSA"}},
+	// the variables and distributions of TE and AIS are given by the
+	// source and the target (see Parameters.query)
+	"TE":  {modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD},
+	"AIS": {modes: modeDiscreteAvg | modeDiscreteSD | modeSparseAvg | modeSparseSD | modeContinuousAvg | modeContinuousSD},
+}
+
+// lookupMeasure returns the specification of the selected measure or query
+// (see Parameters.query) and false, if it is unknown or invalid
+func (p Parameters) lookupMeasure() (measureSpec, bool) {
+	if q, ok, err := p.query(); ok {
+		if err != nil {
+			return measureSpec{}, false
+		}
+		return q.spec(), true
+	}
+	spec, known := measureSpecs[p.MeasureName]
+	return spec, known
+}
+
+// mode returns the mode that is selected by the parameters
+func (p Parameters) mode() int {
+	switch {
+	case p.UseContinuous && p.UseStateDependent:
+		return modeContinuousSD
+	case p.UseContinuous:
+		return modeContinuousAvg
+	case p.UseSparseMatrix && p.UseStateDependent:
+		return modeSparseSD
+	case p.UseSparseMatrix:
+		return modeSparseAvg
+	case p.UseStateDependent:
+		return modeDiscreteSD
+	}
+	return modeDiscreteAvg
+}
+
+func modeName(mode int) string {
+	switch mode {
+	case modeDiscreteAvg:
+		return "discrete, averaged"
+	case modeDiscreteSD:
+		return "discrete, state-dependent"
+	case modeSparseAvg:
+		return "discrete (sparse matrix), averaged"
+	case modeSparseSD:
+		return "discrete (sparse matrix), state-dependent"
+	case modeContinuousAvg:
+		return "continuous, averaged"
+	case modeContinuousSD:
+		return "continuous, state-dependent"
+	}
+	return "unknown"
+}
+
+// availableIn returns the names of all measures that are implemented in
+// the given mode
+func availableIn(mode int) (names []string) {
+	for _, name := range MeasureNames {
+		if measureSpecs[name].modes&mode != 0 {
+			names = append(names, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,205 @@
+package gomi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/continuous/state"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// mixedMeasures are the continuous measures that have a mixed estimator for
+// categorical variables (see continuous.MixedConditionalMutualInformationLocal).
+// CA and CW treat categorical variables on their partition instead.
+var mixedMeasures = []string{"MI_W", "MI_A", "MI_A_Prime", "MI_MI", "MI_CA", "MI_WA", "MI_WS", "MI_IN", "TE", "AIS"}
+
+func isVariable(v string) bool {
+	return v == "W" || v == "S" || v == "A"
+}
+
+func hasMixedEstimator(measure string) bool {
+	for _, m := range mixedMeasures {
+		if m == measure {
+			return true
+		}
+	}
+	return false
+}
+
+// isCategorical returns true, if the variable (W, S, or A) is categorical
+func (p Parameters) isCategorical(v string) bool {
+	for _, c := range p.Categorical {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+// categoricalColumns returns the columns of the blocks of the variables,
+// e.g. w', w, a for "WWA", that belong to categorical variables
+func (p Parameters) categoricalColumns(variables string, blocks ...[]int) []int {
+	var r []int
+	for i, v := range variables {
+		if p.isCategorical(string(v)) {
+			r = append(r, blocks[i]...)
+		}
+	}
+	return r
+}
+
+// normaliseMixed normalises data with NormaliseContinuous, but keeps the raw
+// values of the categorical columns, so that neither the normalisation nor
+// the noise changes the categories
+func normaliseMixed(data [][]float64, variables string, p *Parameters, categorical []int) ([][]float64, error) {
+	raw := columns(data, categorical)
+	r, err := NormaliseContinuous(data, variables, p)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range raw {
+		for j, c := range categorical {
+			r[i][c] = row[j]
+		}
+	}
+	return r, nil
+}
+
+// mixedData returns the normalised data of the measure p.MeasureName, the
+// indices of w', w, s, and a (nil, if not used by the measure), and the
+// categorical columns
+func mixedData(p *Parameters, d Data, output *Output) (data [][]float64, w2, w1, s1, a1, categorical []int, err error) {
+	switch p.MeasureName {
+	case "MI_WS":
+		data, w2, w1, s1 = MakeW2W1S1(d, *p)
+		if p.LogData {
+			output.SetW2W1S1Raw(data)
+		}
+		categorical = p.categoricalColumns("WWS", w2, w1, s1)
+		if data, err = normaliseMixed(data, "WWS", p, categorical); err == nil && p.LogData {
+			output.SetW2W1S1Normalised(data)
+		}
+	case "MI_MI":
+		data, w2, w1, s1, a1 = MakeW2W1S1A1(d, *p)
+		if p.LogData {
+			output.SetW2W1S1A1Raw(data)
+		}
+		categorical = p.categoricalColumns("WWSA", w2, w1, s1, a1)
+		if data, err = normaliseMixed(data, "WWSA", p, categorical); err == nil && p.LogData {
+			output.SetW2W1S1A1Normalised(data)
+		}
+	case "MI_IN":
+		data, a1, s1 = MakeA1S1(d, *p)
+		if p.LogData {
+			output.SetA1S1Raw(data)
+		}
+		categorical = p.categoricalColumns("AS", a1, s1)
+		if data, err = normaliseMixed(data, "AS", p, categorical); err == nil && p.LogData {
+			output.SetA1S1Normalised(data)
+		}
+	default:
+		data, w2, w1, a1 = MakeW2W1A1(d, *p)
+		if p.LogData {
+			output.SetW2W1A1Raw(data)
+		}
+		categorical = p.categoricalColumns("WWA", w2, w1, a1)
+		if data, err = normaliseMixed(data, "WWA", p, categorical); err == nil && p.LogData {
+			output.SetW2W1A1Normalised(data)
+		}
+	}
+	return
+}
+
+// mixedContinuousAvg returns the averaged result of the measure with the
+// mixed estimator and writes it to a file as specified in the parameters p
+func mixedContinuousAvg(ctx context.Context, p Parameters, d Data, r progress.Reporter) (result float64, err error) {
+	var output Output
+	if p.Verbose {
+		fmt.Println(p.MeasureName, "Continuous Avg (mixed)")
+	}
+	data, w2, w1, s1, a1, categorical, err := mixedData(&p, d, &output)
+	if err != nil {
+		return
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	switch p.MeasureName {
+	case "MI_W":
+		result, err = continuous.MorphologicalComputationWMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_A":
+		result, err = continuous.MorphologicalComputationAMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_A_Prime":
+		var z float64
+		result, z, err = continuous.MorphologicalComputationAPrimeMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+		output.SetNormaliser("I(W';W,A)", z)
+	case "MI_MI":
+		result, err = continuous.MorphologicalComputationMIMixed(ctx, data, w2, w1, s1, a1, categorical, p.K, r)
+	case "MI_CA":
+		result, err = continuous.MorphologicalComputationCAMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_WA":
+		result, err = continuous.MorphologicalComputationWAMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_WS":
+		result, err = continuous.MorphologicalComputationWSMixed(ctx, data, w2, w1, s1, categorical, p.K, r)
+	case "MI_IN":
+		var h float64
+		result, h, err = continuous.MorphologicalComputationINMixed(ctx, data, a1, s1, categorical, p.K, r)
+		output.SetNormaliser("H(A)", h)
+	default:
+		err = fmt.Errorf("measure %s has no mixed estimator", p.MeasureName)
+	}
+	if err != nil {
+		return
+	}
+	writeOutputAvg(p, result, fmt.Sprintf("%s continuous (mixed Estimator)", p.MeasureName), output)
+	return
+}
+
+// mixedContinuousSD computes the state-dependent result of the measure with
+// the mixed estimator and writes it to a file as specified in the parameters p
+func mixedContinuousSD(ctx context.Context, p Parameters, d Data, r progress.Reporter) error {
+	var output Output
+	if p.Verbose {
+		fmt.Println(p.MeasureName, "Continuous SD (mixed)")
+	}
+	data, w2, w1, s1, a1, categorical, err := mixedData(&p, d, &output)
+	if err != nil {
+		return err
+	}
+	if p.Verbose == true {
+		fmt.Println(p)
+	}
+
+	var result []float64
+	switch p.MeasureName {
+	case "MI_W":
+		result, err = state.MorphologicalComputationWMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_A":
+		result, err = state.MorphologicalComputationAMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_A_Prime":
+		var z float64
+		result, z, err = state.MorphologicalComputationAPrimeMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+		output.SetNormaliser("I(W';W,A)", z)
+	case "MI_MI":
+		result, err = state.MorphologicalComputationMIMixed(ctx, data, w2, w1, s1, a1, categorical, p.K, r)
+	case "MI_CA":
+		result, err = state.MorphologicalComputationCAMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_WA":
+		result, err = state.MorphologicalComputationWAMixed(ctx, data, w2, w1, a1, categorical, p.K, r)
+	case "MI_WS":
+		result, err = state.MorphologicalComputationWSMixed(ctx, data, w2, w1, s1, categorical, p.K, r)
+	case "MI_IN":
+		var h float64
+		result, h, err = state.MorphologicalComputationINMixed(ctx, data, a1, s1, categorical, p.K, r)
+		output.SetNormaliser("H(A)", h)
+	default:
+		err = fmt.Errorf("measure %s has no mixed estimator", p.MeasureName)
+	}
+	if err != nil {
+		return err
+	}
+	writeOutputSD(p, d, result, fmt.Sprintf("%s continuous (mixed Estimator)", p.MeasureName), output)
+	return nil
+}
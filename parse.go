@@ -6,16 +6,51 @@ import (
 )
 
 func parseIntString(s string) (r []int) {
-	if len(s) == 0 {
+	r, err := parseIntList(s)
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// parseIntList parses a comma-separated list of integers, e.g. "1,2,3"
+func parseIntList(s string) (r []int, err error) {
+	if len(strings.TrimSpace(s)) == 0 {
 		return
 	}
 	lst := strings.Split(s, ",")
 	for _, v := range lst {
-		f, err := strconv.ParseInt(v, 10, 64)
+		f, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		r = append(r, int(f))
 	}
 	return
 }
+
+// parseStringList parses a comma-separated list of strings, e.g. "W,A"
+func parseStringList(s string) (r []string) {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			r = append(r, v)
+		}
+	}
+	return
+}
+
+// parseFloatList parses a comma-separated list of floats, e.g. "-0.1,0.2"
+func parseFloatList(s string) (r []float64, err error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return
+	}
+	lst := strings.Split(s, ",")
+	for _, v := range lst {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, f)
+	}
+	return
+}
@@ -0,0 +1,123 @@
+package gomi
+
+import (
+	"math"
+
+	entropy "github.com/kzahedi/goent/discrete"
+)
+
+// minSamplesPerCell is the average number of samples per cell of the joint
+// distribution that the adaptive partition aims for
+const minSamplesPerCell = 5
+
+// partitionBins returns the number of bins per column of an adaptive
+// partition, such that a joint distribution of the given number of columns
+// has on average at least minSamplesPerCell samples per cell. At least two
+// bins are used.
+func partitionBins(samples, columns int) int {
+	if columns < 1 {
+		return 2
+	}
+	b := int(math.Floor(math.Pow(float64(samples)/minSamplesPerCell, 1.0/float64(columns))))
+	if b < 2 {
+		return 2
+	}
+	return b
+}
+
+// adaptivePartition discretises each column of data into bins of (nearly)
+// equal frequency, i.e. the bin boundaries are the empirical quantiles of the
+// column. Equal values fall into the same bin.
+func adaptivePartition(data [][]float64, bins int) [][]int {
+	ranks := rankTransform(data)
+	r := make([][]int, len(ranks), len(ranks))
+	for i, row := range ranks {
+		r[i] = make([]int, len(row), len(row))
+		for j, u := range row {
+			b := int(u * float64(bins))
+			if b >= bins {
+				b = bins - 1
+			}
+			r[i][j] = b
+		}
+	}
+	return r
+}
+
+// partition returns the adaptive partition of the data of the variable, or
+// the categories themselves, if the variable is categorical
+func (p Parameters) partition(v string, data [][]float64, bins int) [][]int {
+	if p.isCategorical(v) == false {
+		return adaptivePartition(data, bins)
+	}
+	labels := map[float64]int{}
+	r := make([][]int, len(data), len(data))
+	for i, row := range data {
+		r[i] = make([]int, len(row), len(row))
+		for j, x := range row {
+			label, ok := labels[x]
+			if ok == false {
+				label = len(labels)
+				labels[x] = label
+			}
+			r[i][j] = label
+		}
+	}
+	return r
+}
+
+// columns returns the selected columns of data
+func columns(data [][]float64, indices []int) [][]float64 {
+	r := make([][]float64, len(data), len(data))
+	for i, row := range data {
+		r[i] = make([]float64, len(indices), len(indices))
+		for j, index := range indices {
+			r[i][j] = row[index]
+		}
+	}
+	return r
+}
+
+// MakePS2S1A1Partition returns the joint distribution p(s',s,a) of continuous
+// sensor and actuator data on an adaptive partition, the number of bins per
+// column and the size of the alphabet of S. The data is normalised as
+// specified in p first (see NormaliseContinuous), which only matters if
+// noise is added, because the partition only depends on the ranks.
+// Categorical variables (see Parameters.Categorical) are not binned, their
+// categories are used instead.
+func MakePS2S1A1Partition(d Data, p *Parameters, output *Output) ([][][]float64, int, int, error) {
+	a1s1, a1Indices, s1Indices := MakeA1S1(d, *p)
+	if p.LogData {
+		output.SetA1S1Raw(a1s1)
+	}
+	a1s1, err := normaliseMixed(a1s1, "AS", p, p.categoricalColumns("AS", a1Indices, s1Indices))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if p.LogData {
+		output.SetA1S1Normalised(a1s1)
+	}
+
+	bins := partitionBins(len(a1s1)-1, 2*len(s1Indices)+len(a1Indices))
+	sBins, err := alphabetSize("S", columnBins(nil, bins, len(s1Indices)))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	s := relabel(p.partition("S", columns(a1s1, s1Indices), bins))
+	a := relabel(p.partition("A", columns(a1s1, a1Indices), bins))
+	if p.isCategorical("S") {
+		// the alphabet of S is given by the categories
+		sBins = 0
+		for _, v := range s {
+			if v >= sBins {
+				sBins = v + 1
+			}
+		}
+	}
+
+	s2s1a1 := make([][]int, len(s)-1, len(s)-1)
+	for i := 0; i < len(s)-1; i++ {
+		s2s1a1[i] = []int{s[i+1], s[i], a[i]}
+	}
+	return entropy.Empirical3D(s2s1a1), bins, sBins, nil
+}
@@ -0,0 +1,29 @@
+package gomi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAdaptivePartition(t *testing.T) {
+	data := [][]float64{{0.5}, {0.1}, {0.1}, {0.9}, {0.3}, {0.7}}
+	want := [][]int{{1}, {0}, {0}, {2}, {1}, {2}}
+	if got := adaptivePartition(data, 3); reflect.DeepEqual(got, want) == false {
+		t.Errorf("adaptivePartition() = %v, want %v", got, want)
+	}
+}
+
+func TestPartitionBins(t *testing.T) {
+	tests := []struct {
+		samples, columns, want int
+	}{
+		{1000, 3, 5},
+		{10, 3, 2},
+		{5000, 1, 1000},
+	}
+	for _, tt := range tests {
+		if got := partitionBins(tt.samples, tt.columns); got != tt.want {
+			t.Errorf("partitionBins(%d, %d) = %d, want %d", tt.samples, tt.columns, got, tt.want)
+		}
+	}
+}
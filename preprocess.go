@@ -0,0 +1,183 @@
+package gomi
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Normalisation of continuous data
+const (
+	// NormalisationAuto uses the domains, if they are given for all
+	// variables, and min-max scaling otherwise
+	NormalisationAuto = "auto"
+	// NormalisationDomain scales each column from its domain to [0,1]
+	NormalisationDomain = "domain"
+	// NormalisationMinMax scales each column from its observed range to [0,1]
+	NormalisationMinMax = "minmax"
+	// NormalisationZScore centres each column and scales it to unit variance
+	NormalisationZScore = "zscore"
+	// NormalisationRank replaces each value by its normalised rank in the
+	// column (empirical copula), ties get the average rank
+	NormalisationRank = "rank"
+)
+
+// NormalisationModes lists all normalisation modes
+var NormalisationModes = []string{NormalisationAuto, NormalisationDomain, NormalisationMinMax, NormalisationZScore, NormalisationRank}
+
+// NormaliseContinuous preprocesses continuous data before it is passed to an
+// estimator. The variables name the blocks of columns of data, e.g. "WWA"
+// for (w',w,a). The data is normalised according to p.Normalisation. If
+// p.Noise is positive, uniform noise in [-p.Noise, p.Noise] is added
+// afterwards to break ties. The noise is drawn from the stream "noise" of
+// p.Random(). The normalisation is recorded in p (see Output.SetParameters).
+func NormaliseContinuous(data [][]float64, variables string, p *Parameters) ([][]float64, error) {
+	mode := p.Normalisation
+	min, max, hasDomains := p.domains(variables)
+	if mode == NormalisationAuto {
+		mode = NormalisationMinMax
+		if hasDomains {
+			mode = NormalisationDomain
+		}
+	}
+
+	var r [][]float64
+	switch mode {
+	case NormalisationDomain:
+		if hasDomains == false {
+			return nil, fmt.Errorf("normalisation %s requires the domains of %s", mode, variables)
+		}
+		if len(data) > 0 && len(min) != len(data[0]) {
+			return nil, fmt.Errorf("%d domains are given for %s, but the data has %d columns", len(min), variables, len(data[0]))
+		}
+		r = NormaliseContinuousData(data, [][]float64{min}, [][]float64{max}, p)
+	case NormalisationMinMax:
+		r = NormaliseContinuousDataByColumn(data, p)
+	case NormalisationZScore:
+		r, p.NormalisationMean, p.NormalisationSD = zScore(data)
+	case NormalisationRank:
+		r = rankTransform(data)
+	default:
+		return nil, fmt.Errorf("unknown normalisation %q", p.Normalisation)
+	}
+	p.NormalisationApplied = mode
+
+	if p.Noise > 0.0 {
+		addNoise(r, p.Noise, p.Random().Stream("noise", 0))
+	}
+
+	return r, nil
+}
+
+// addNoise adds uniform noise in [-amplitude, amplitude] to each value
+func addNoise(data [][]float64, amplitude float64, rng *rand.Rand) {
+	for _, row := range data {
+		for j := range row {
+			row[j] += amplitude * (2.0*rng.Float64() - 1.0)
+		}
+	}
+}
+
+// domains returns the concatenated domains of the variables, e.g. "WWA",
+// and false if a domain is missing
+func (p Parameters) domains(variables string) (min, max []float64, ok bool) {
+	for _, v := range variables {
+		var vmin, vmax []float64
+		switch v {
+		case 'W':
+			vmin, vmax = p.WorldMin, p.WorldMax
+		case 'S':
+			vmin, vmax = p.SensorMin, p.SensorMax
+		case 'A':
+			vmin, vmax = p.ActuatorMin, p.ActuatorMax
+		}
+		if len(vmin) == 0 || len(vmin) != len(vmax) {
+			return nil, nil, false
+		}
+		min = append(min, vmin...)
+		max = append(max, vmax...)
+	}
+	return min, max, true
+}
+
+// zScore returns the column-wise standardised data, the means and the
+// standard deviations. Constant columns are only centred.
+func zScore(data [][]float64) (r [][]float64, mean, sd []float64) {
+	if len(data) == 0 {
+		return data, nil, nil
+	}
+	n := float64(len(data))
+	m := len(data[0])
+	mean = make([]float64, m, m)
+	sd = make([]float64, m, m)
+	for _, row := range data {
+		for j, v := range row {
+			mean[j] += v / n
+		}
+	}
+	for _, row := range data {
+		for j, v := range row {
+			sd[j] += (v - mean[j]) * (v - mean[j]) / n
+		}
+	}
+	for j := range sd {
+		sd[j] = math.Sqrt(sd[j])
+	}
+
+	r = make([][]float64, len(data), len(data))
+	for i, row := range data {
+		r[i] = make([]float64, m, m)
+		for j, v := range row {
+			r[i][j] = v - mean[j]
+			if sd[j] > 0.0 {
+				r[i][j] /= sd[j]
+			}
+		}
+	}
+	return
+}
+
+// rankTransform replaces each value by rank/(n+1), where rank is the rank
+// of the value in its column (1, ..., n). Equal values get the average of
+// their ranks.
+func rankTransform(data [][]float64) [][]float64 {
+	n := len(data)
+	if n == 0 {
+		return data
+	}
+	m := len(data[0])
+	r := make([][]float64, n, n)
+	for i := range r {
+		r[i] = make([]float64, m, m)
+	}
+
+	order := make([]int, n, n)
+	for j := 0; j < m; j++ {
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return data[order[a]][j] < data[order[b]][j] })
+		for first := 0; first < n; {
+			last := first
+			for last+1 < n && data[order[last+1]][j] == data[order[first]][j] {
+				last++
+			}
+			rank := float64(first+last)/2.0 + 1.0
+			for k := first; k <= last; k++ {
+				r[order[k]][j] = rank / float64(n+1)
+			}
+			first = last + 1
+		}
+	}
+	return r
+}
+
+func isKnownNormalisation(mode string) bool {
+	for _, m := range NormalisationModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package gomi
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormaliseContinuous(t *testing.T) {
+	data := func() [][]float64 {
+		return [][]float64{{1.0, 10.0}, {2.0, 10.0}, {2.0, 30.0}, {3.0, 20.0}}
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		domains bool
+		applied string
+		want    [][]float64
+		wantErr bool
+	}{
+		{name: "Rank with ties", mode: NormalisationRank, applied: NormalisationRank,
+			want: [][]float64{{0.2, 0.3}, {0.5, 0.3}, {0.5, 0.8}, {0.8, 0.6}}},
+		{name: "Z-score", mode: NormalisationZScore, applied: NormalisationZScore,
+			want: [][]float64{{-1.414214, -0.904534}, {0.0, -0.904534}, {0.0, 1.507557}, {1.414214, 0.301511}}},
+		{name: "Auto without domains", mode: NormalisationAuto, applied: NormalisationMinMax},
+		{name: "Auto with domains", mode: NormalisationAuto, domains: true, applied: NormalisationDomain},
+		{name: "Domain without domains", mode: NormalisationDomain, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CreateParametersContainer()
+			p.Normalisation = tt.mode
+			if tt.domains {
+				p.SetWMinMax([]float64{0.0}, []float64{4.0})
+				p.SetAMinMax([]float64{0.0}, []float64{40.0})
+			}
+			got, err := NormaliseContinuous(data(), "WA", &p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormaliseContinuous() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if p.NormalisationApplied != tt.applied {
+				t.Errorf("NormaliseContinuous() applied %s, want %s", p.NormalisationApplied, tt.applied)
+			}
+			for i := range tt.want {
+				for j := range tt.want[i] {
+					if math.Abs(got[i][j]-tt.want[i][j]) > 1e-6 {
+						t.Errorf("NormaliseContinuous() = %v, want %v", got, tt.want)
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNormaliseContinuousNoise(t *testing.T) {
+	run := func(seed int64) [][]float64 {
+		p := CreateParametersContainer()
+		p.Normalisation = NormalisationRank
+		p.Noise = 1e-6
+		p.Seed = seed
+		r, err := NormaliseContinuous([][]float64{{1.0}, {1.0}, {1.0}}, "W", &p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	a, b, c := run(1), run(1), run(2)
+	if reflect.DeepEqual(a, b) == false {
+		t.Errorf("same seed gives different data %v and %v", a, b)
+	}
+	if reflect.DeepEqual(a, c) {
+		t.Errorf("different seeds give the same data %v", a)
+	}
+	if a[0][0] == a[1][0] || a[1][0] == a[2][0] {
+		t.Errorf("noise does not break the ties: %v", a)
+	}
+}
@@ -0,0 +1,69 @@
+// Package progress provides the reporting of progress of long computations,
+// e.g. iterative scaling or the continuous estimators.
+package progress
+
+import (
+	pb "gopkg.in/cheggaaa/pb.v1"
+)
+
+// Reporter receives progress updates. A computation consists of one or more
+// stages, and done counts the completed steps out of total steps of the
+// current stage.
+type Reporter interface {
+	Report(stage string, done, total int)
+}
+
+// Func adapts an ordinary function to the Reporter interface
+type Func func(stage string, done, total int)
+
+// Report calls f(stage, done, total)
+func (f Func) Report(stage string, done, total int) {
+	f(stage, done, total)
+}
+
+type none struct{}
+
+func (none) Report(stage string, done, total int) {}
+
+// None is a Reporter that discards all updates
+var None Reporter = none{}
+
+// Bar is a Reporter that draws one progress bar per stage on the terminal
+type Bar struct {
+	stage string
+	bar   *pb.ProgressBar
+}
+
+// NewBar returns a Reporter that draws progress bars on the terminal
+func NewBar() *Bar {
+	return &Bar{}
+}
+
+// Report updates the progress bar, or starts a new one if the stage changed
+func (b *Bar) Report(stage string, done, total int) {
+	if b.bar == nil || b.stage != stage {
+		b.Finish()
+		b.stage = stage
+		b.bar = pb.New(total).Prefix(stage + " ").Start()
+	}
+	b.bar.Set(done)
+	if done >= total {
+		b.Finish()
+	}
+}
+
+// Finish finishes the current progress bar
+func (b *Bar) Finish() {
+	if b.bar != nil {
+		b.bar.Finish()
+		b.bar = nil
+	}
+}
+
+// OrNone returns r, or None if r is nil
+func OrNone(r Reporter) Reporter {
+	if r == nil {
+		return None
+	}
+	return r
+}
@@ -0,0 +1,40 @@
+// Package random provides the seeded random number source of a computation.
+// All components that need random numbers (e.g. tie-breaking noise,
+// surrogates, bootstraps, subsampling) draw them from streams of one Source,
+// so that the results only depend on the seed and the data.
+package random
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+)
+
+// Source is the random number source of one computation
+type Source struct {
+	seed int64
+}
+
+// New returns the source for the given seed
+func New(seed int64) Source {
+	return Source{seed: seed}
+}
+
+// Seed returns the seed of the source
+func (s Source) Seed() int64 {
+	return s.seed
+}
+
+// Stream returns the random number generator that is identified by name and
+// index. Its numbers only depend on the seed, the name, and the index, and
+// not on other streams. Parallel workers that use one stream each (e.g.
+// indexed by the surrogate they compute) therefore draw the same numbers
+// regardless of the order in which they are scheduled. A stream must not be
+// shared between goroutines.
+func (s Source) Stream(name string, index int) *rand.Rand {
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, s.seed)
+	h.Write([]byte(name))
+	binary.Write(h, binary.LittleEndian, int64(index))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
@@ -0,0 +1,35 @@
+package random
+
+import (
+	"testing"
+)
+
+func draw(s Source, name string, index int) (r [3]float64) {
+	rng := s.Stream(name, index)
+	for i := range r {
+		r[i] = rng.Float64()
+	}
+	return
+}
+
+func TestStream(t *testing.T) {
+	s := New(42)
+	a := draw(s, "noise", 0)
+	tests := []struct {
+		name  string
+		other [3]float64
+		equal bool
+	}{
+		{"Same seed, name, and index", draw(New(42), "noise", 0), true},
+		{"Other seed", draw(New(43), "noise", 0), false},
+		{"Other name", draw(s, "surrogate", 0), false},
+		{"Other index", draw(s, "noise", 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (a == tt.other) != tt.equal {
+				t.Errorf("Stream() = %v and %v, want equal = %t", a, tt.other, tt.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package gomi
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	entropy "github.com/kzahedi/goent/discrete"
+	sparse "github.com/kzahedi/goent/discrete/sparse"
+	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/progress"
+)
+
+// References by which the results are normalised (see Parameters.Reference)
+const (
+	// ReferenceNone reports the raw results only
+	ReferenceNone = "none"
+	// ReferenceEntropy normalises by the entropy H(W') of the next world state
+	// (discrete measures only)
+	ReferenceEntropy = "entropy"
+	// ReferenceInformation normalises by I(W';W,A), the information that the
+	// world and the actuator states contain about the next world state
+	ReferenceInformation = "information"
+	// ReferenceAlphabet normalises by log|W|, the logarithm of the alphabet
+	// size of the world states (discrete measures only)
+	ReferenceAlphabet = "alphabet"
+)
+
+// References lists all references
+var References = []string{ReferenceNone, ReferenceEntropy, ReferenceInformation, ReferenceAlphabet}
+
+// ReferenceTerm is one of the constituent terms of a reference, e.g. H(W')
+type ReferenceTerm struct {
+	Term  string
+	Value float64
+}
+
+func isKnownReference(name string) bool {
+	for _, r := range References {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// useReference returns true, if the results are normalised
+func (p Parameters) useReference() bool {
+	return p.Reference != "" && p.Reference != ReferenceNone
+}
+
+// computeReference evaluates the reference that is selected by p.Reference
+// on the data and stores its value and constituent terms in the returned
+// parameters. H(W') and I(W';W,A) are estimated with the same back end as
+// the measure from the same samples (w[t+1], w[t], a[t]), see
+// referenceTerms. The values are given in p.Unit:
+//    entropy:     H(W')
+//    information: I(W';W,A), H(W'), and H(W'|W,A) = H(W') - I(W';W,A)
+//    alphabet:    log|W|, |W|
+func (p Parameters) computeReference(ctx context.Context, d Data, r progress.Reporter) (Parameters, error) {
+	if p.useReference() == false {
+		return p, nil
+	}
+	// the normalisation of the reference must not replace the one of the
+	// measure
+	c := p
+	unit := UnitBits
+	if p.UseContinuous {
+		unit = UnitNats
+	}
+	switch p.Reference {
+	case ReferenceAlphabet:
+		n, err := CalculateWBins(p, d)
+		if err != nil {
+			return p, err
+		}
+		p.ReferenceValue = ConvertUnit(math.Log2(float64(n)), UnitBits, p.Unit)
+		p.ReferenceTerms = []ReferenceTerm{{"log|W|", p.ReferenceValue}, {"|W|", float64(n)}}
+	case ReferenceEntropy:
+		h, _, err := referenceTerms(ctx, &c, d, "I(W[t+1];W[t])", false, r)
+		if err != nil {
+			return p, err
+		}
+		h = ConvertUnit(h, unit, p.Unit)
+		p.ReferenceValue = h
+		p.ReferenceTerms = []ReferenceTerm{{"H(W')", h}}
+	case ReferenceInformation:
+		h, i, err := referenceTerms(ctx, &c, d, "I(W[t+1];W[t],A[t])", true, r)
+		if err != nil {
+			return p, err
+		}
+		h = ConvertUnit(h, unit, p.Unit)
+		i = ConvertUnit(i, unit, p.Unit)
+		p.ReferenceValue = i
+		p.ReferenceTerms = []ReferenceTerm{{"I(W';W,A)", i}, {"H(W')", h}, {"H(W'|W,A)", h - i}}
+	default:
+		return p, fmt.Errorf("unknown reference %q (available are %v)", p.Reference, References)
+	}
+	if p.Verbose {
+		fmt.Println(p.referenceString(""))
+	}
+	return p, nil
+}
+
+// referenceTerms returns H(X) and, if information is set, I(X;Y) of the
+// query. Both are estimated from the samples of the query, so that H(X)
+// covers the same time steps as the measures.
+func referenceTerms(ctx context.Context, p *Parameters, d Data, query string, information bool, r progress.Reporter) (h, i float64, err error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return
+	}
+	if p.UseContinuous == false {
+		xyz := QueryDiscrete(q, d, *p)
+		// H(X) = I(X;X)
+		xxz := make([][]int, len(xyz), len(xyz))
+		for t, row := range xyz {
+			xxz[t] = []int{row[0], row[0], row[2]}
+		}
+		if p.UseSparseMatrix {
+			h = sparse.ConditionalMutualInformationBase2(entropy.Empirical3DSparse(xxz))
+			i = sparse.ConditionalMutualInformationBase2(entropy.Empirical3DSparse(xyz))
+		} else {
+			h = entropy.ConditionalMutualInformationBase2(entropy.Empirical3D(xxz))
+			i = entropy.ConditionalMutualInformationBase2(entropy.Empirical3D(xyz))
+		}
+		return
+	}
+
+	data, x, y, z, variables, blocks := QueryContinuous(q, d)
+	categorical := p.categoricalColumns(variables, blocks...)
+	if data, err = normaliseMixed(data, variables, p, categorical); err != nil {
+		return
+	}
+	if h, err = continuous.ConditionalEntropy(ctx, data, x, z, categorical, p.ContinuousMode, p.K, r); err != nil || information == false {
+		return
+	}
+	i, err = continuous.Information(ctx, data, continuous.Term{X: x, Y: y, Z: z}, categorical, p.ContinuousMode, p.K, r)
+	return
+}
+
+// normalise returns v divided by the reference, or NaN, if the reference is
+// not positive. v must be given in p.Unit (see inUnit).
+func (p Parameters) normalise(v float64) float64 {
+	if p.ReferenceValue <= 0.0 {
+		return math.NaN()
+	}
+	return v / p.ReferenceValue
+}
+
+// referenceString returns the reference and its constituent terms
+func (p Parameters) referenceString(prefix string) string {
+	s := fmt.Sprintf("%sReference:                 %s = %f", prefix, p.Reference, p.ReferenceValue)
+	for _, t := range p.ReferenceTerms {
+		s = fmt.Sprintf("%s\n%s  %-24s %f", s, prefix, t.Term+":", t.Value)
+	}
+	if p.ReferenceValue <= 0.0 {
+		s = fmt.Sprintf("%s\n%sThe reference is not positive, the normalised values are NaN", s, prefix)
+	}
+	return s
+}
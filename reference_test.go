@@ -0,0 +1,53 @@
+package gomi
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestComputeReference(t *testing.T) {
+	var d Data
+	d.W = [][]float64{{0.0}, {1.0}, {0.0}, {1.0}, {0.0}, {1.0}, {0.0}, {1.0}, {0.0}}
+	d.A = [][]float64{{0.0}, {0.0}, {1.0}, {1.0}, {0.0}, {0.0}, {1.0}, {1.0}, {0.0}}
+	tests := []struct {
+		reference string
+		bins      int
+		want      float64
+		terms     int
+	}{
+		{ReferenceNone, 2, 0.0, 0},
+		{ReferenceEntropy, 2, 1.0, 1},
+		{ReferenceInformation, 2, 1.0, 3},
+		{ReferenceAlphabet, 4, 2.0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			p := CreateParametersContainer()
+			p.GlobalBins = tt.bins
+			p.Reference = tt.reference
+			got, err := p.computeReference(context.Background(), d, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got.ReferenceValue-tt.want) > 1e-10 {
+				t.Errorf("computeReference() = %v, want %v", got.ReferenceValue, tt.want)
+			}
+			if len(got.ReferenceTerms) != tt.terms {
+				t.Errorf("computeReference() returned %d terms, want %d", len(got.ReferenceTerms), tt.terms)
+			}
+		})
+	}
+}
+
+func TestNormaliseNonPositiveReference(t *testing.T) {
+	p := CreateParametersContainer()
+	p.Reference = ReferenceEntropy
+	if v := p.normalise(0.5); math.IsNaN(v) == false {
+		t.Errorf("normalise() with a zero reference = %v, want NaN", v)
+	}
+	p.ReferenceValue = 2.0
+	if v := p.normalise(0.5); v != 0.25 {
+		t.Errorf("normalise() = %v, want 0.25", v)
+	}
+}
@@ -0,0 +1,494 @@
+package gomi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// size of the charts of the report in pixels
+const (
+	chartWidth  = 640
+	chartHeight = 240
+	chartLeft   = 70
+	chartRight  = 20
+	chartTop    = 30
+	chartBottom = 40
+	// line charts with more points are drawn as the envelope (minimum and
+	// maximum) of chartPoints buckets
+	chartPoints = 1000
+)
+
+// reportRun is one result of the report
+type reportRun struct {
+	name   string
+	output Output
+}
+
+// Report writes a self-contained HTML file with the results that are stored
+// in the JSON files (see Output.ExportJSON) to output. For each result, it
+// contains the point-wise values, their moving average over window rows
+// (default n/50), and their distribution as inline SVG charts, and a table
+// of all parameters and the provenance of the data. Several results are
+// compared in a bar chart, and if they are of the same measure and
+// estimator and differ in the number of bins (discrete) or k (continuous),
+// the averaged values are also plotted against it.
+func Report(filenames []string, output string, window int) error {
+	if len(filenames) == 0 {
+		return fmt.Errorf("no result files given")
+	}
+	runs := make([]reportRun, len(filenames), len(filenames))
+	for i, f := range filenames {
+		o, err := ImportJSON(f)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %s", f, err)
+		}
+		runs[i] = reportRun{name: strings.TrimSuffix(filepath.Base(f), filepath.Ext(f)), output: o}
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>gomi report</title>\n")
+	b.WriteString("<style>\nbody { font-family: sans-serif; margin: 2em; }\n")
+	b.WriteString("table { border-collapse: collapse; margin-bottom: 1em; }\n")
+	b.WriteString("td, th { border: 1px solid #ccc; padding: 2px 8px; text-align: left; font-size: 90%; }\n")
+	b.WriteString("svg { display: block; margin-bottom: 1em; }\n</style>\n</head>\n<body>\n")
+	b.WriteString("<h1>gomi report</h1>\n")
+
+	b.WriteString(reportSummary(runs))
+	if len(runs) > 1 {
+		b.WriteString("<h2>Comparison</h2>\n")
+		labels := make([]string, 0, len(runs))
+		values := make([]float64, 0, len(runs))
+		for _, r := range runs {
+			if v, ok := r.output.average(); ok {
+				labels = append(labels, r.name)
+				values = append(values, v)
+			}
+		}
+		b.WriteString(svgBarChart("Averaged value", labels, values))
+		if name, xs, ys, ok := reportSensitivity(runs); ok {
+			b.WriteString(svgLineChart(fmt.Sprintf("Averaged value over %s", name), xs, ys, name))
+		}
+	}
+
+	for _, r := range runs {
+		b.WriteString(fmt.Sprintf("<h2>%s</h2>\n", html.EscapeString(r.name)))
+		if r.output.Result != nil && r.output.Result.PointWise != nil && len(*r.output.Result.PointWise) > 0 {
+			values := *r.output.Result.PointWise
+			w := window
+			if w < 1 {
+				w = len(values) / 50
+			}
+			if w < 1 {
+				w = 1
+			}
+			xs, ys := indexed(values)
+			b.WriteString(svgLineChart("Point-wise values", xs, ys, "row"))
+			xs, ys = movingAverage(values, w)
+			b.WriteString(svgLineChart(fmt.Sprintf("Moving average over %d rows", w), xs, ys, "row"))
+			b.WriteString(svgHistogram("Distribution of the point-wise values", values))
+		}
+		b.WriteString(reportParameters(r.output))
+	}
+	b.WriteString("</body>\n</html>\n")
+
+	return ioutil.WriteFile(output, []byte(b.String()), 0644)
+}
+
+// average returns the averaged result, if the output contains one
+func (o Output) average() (float64, bool) {
+	if o.Result == nil || o.Result.Average == nil {
+		return 0.0, false
+	}
+	return *o.Result.Average, true
+}
+
+// reportSummary returns a table with one row per result
+func reportSummary(runs []reportRun) string {
+	var b strings.Builder
+	b.WriteString("<table>\n<tr><th>result</th><th>measure</th><th>estimator</th><th>averaged</th><th>normalised</th><th>unit</th><th>rows</th></tr>\n")
+	for _, r := range runs {
+		o := r.output
+		cells := []string{r.name, "", "", "", "", "", ""}
+		if o.Measure != nil {
+			if o.Measure.Name != nil {
+				cells[1] = *o.Measure.Name
+			}
+			cells[2] = estimatorString(o.Measure)
+		}
+		if v, ok := o.average(); ok {
+			cells[3] = fmt.Sprintf("%f", v)
+		}
+		if o.Result != nil {
+			if o.Result.Normalised != nil {
+				cells[4] = fmt.Sprintf("%f", *o.Result.Normalised)
+			}
+			if o.Result.Unit != nil {
+				cells[5] = *o.Result.Unit
+			}
+			if o.Result.PointWise != nil {
+				cells[6] = fmt.Sprintf("%d", len(*o.Result.PointWise))
+			}
+		}
+		b.WriteString("<tr>")
+		for _, c := range cells {
+			b.WriteString(fmt.Sprintf("<td>%s</td>", html.EscapeString(c)))
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+	return b.String()
+}
+
+// estimatorString describes the estimator of the measure, e.g. "discrete,
+// bins 30" or "continuous, mode 1, k 30"
+func estimatorString(m *OutputMeasure) string {
+	var parts []string
+	if m.UseContinuous != nil && *m.UseContinuous {
+		parts = append(parts, "continuous")
+		if m.Continuous != nil && m.Continuous.Mode != nil {
+			parts = append(parts, fmt.Sprintf("mode %d", *m.Continuous.Mode))
+		}
+		if m.Continuous != nil && m.Continuous.K != nil {
+			parts = append(parts, fmt.Sprintf("k %d", *m.Continuous.K))
+		}
+	} else {
+		parts = append(parts, "discrete")
+		if m.Discrete != nil && m.Discrete.Bins != nil && m.Discrete.Bins.Global != nil {
+			parts = append(parts, fmt.Sprintf("bins %d", *m.Discrete.Bins.Global))
+		}
+	}
+	if m.UseStateDependent != nil && *m.UseStateDependent {
+		parts = append(parts, "state-dependent")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reportSensitivity returns the averaged values over the number of bins or
+// k, if all results are of the same measure and estimator and only differ
+// in it
+func reportSensitivity(runs []reportRun) (name string, xs, ys []float64, ok bool) {
+	type point struct{ x, y float64 }
+	var points []point
+	var setup string
+	for i, r := range runs {
+		m := r.output.Measure
+		v, hasAverage := r.output.average()
+		if m == nil || m.Name == nil || hasAverage == false {
+			return "", nil, nil, false
+		}
+		var x float64
+		var n string
+		switch {
+		case m.UseContinuous != nil && *m.UseContinuous && m.Continuous != nil && m.Continuous.K != nil:
+			x, n = float64(*m.Continuous.K), "k"
+		case (m.UseContinuous == nil || *m.UseContinuous == false) && m.Discrete != nil && m.Discrete.Bins != nil && m.Discrete.Bins.Global != nil:
+			x, n = float64(*m.Discrete.Bins.Global), "bins"
+		default:
+			return "", nil, nil, false
+		}
+		// everything but the varied parameter must be identical
+		s := strings.Replace(estimatorString(m), fmt.Sprintf("%s %d", n, int(x)), "", 1)
+		s = fmt.Sprintf("%s %s", *m.Name, s)
+		if i == 0 {
+			name, setup = n, s
+		} else if n != name || s != setup {
+			return "", nil, nil, false
+		}
+		points = append(points, point{x, v})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].x < points[j].x })
+	for i, p := range points {
+		if i > 0 && p.x == points[i-1].x {
+			return "", nil, nil, false
+		}
+		xs = append(xs, p.x)
+		ys = append(ys, p.y)
+	}
+	return name, xs, ys, len(xs) > 1
+}
+
+// reportParameters returns a table of all parameters and the provenance of
+// the result, i.e. all entries of the JSON file except for the logged data
+// and the point-wise values
+func reportParameters(o Output) string {
+	o.W2W1A1, o.W2W1S1, o.W2W1S1A1, o.A1S1 = nil, nil, nil, nil
+	if o.Result != nil {
+		r := *o.Result
+		r.PointWise = nil
+		o.Result = &r
+	}
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+	var tree interface{}
+	if err := json.Unmarshal(bytes, &tree); err != nil {
+		return ""
+	}
+	rows := map[string]string{}
+	flatten("", tree, rows)
+	keys := make([]string, 0, len(rows))
+	for k := range rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("<table>\n<tr><th>parameter</th><th>value</th></tr>\n")
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td></tr>\n", html.EscapeString(k), html.EscapeString(rows[k])))
+	}
+	b.WriteString("</table>\n")
+	return b.String()
+}
+
+// flatten stores the leaves of the JSON tree with their dotted paths, e.g.
+// measure.continuous.k. Lists of values are stored as one entry.
+func flatten(prefix string, v interface{}, rows map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, c := range t {
+			key := k
+			if prefix != "" {
+				key = fmt.Sprintf("%s.%s", prefix, k)
+			}
+			flatten(key, c, rows)
+		}
+	case []interface{}:
+		objects := false
+		for _, c := range t {
+			if _, ok := c.(map[string]interface{}); ok {
+				objects = true
+			}
+		}
+		if objects {
+			for i, c := range t {
+				flatten(fmt.Sprintf("%s.%d", prefix, i), c, rows)
+			}
+			return
+		}
+		bytes, _ := json.Marshal(t)
+		rows[prefix] = string(bytes)
+	default:
+		bytes, _ := json.Marshal(t)
+		rows[prefix] = strings.Trim(string(bytes), "\"")
+	}
+}
+
+// indexed returns the values over their row index
+func indexed(values []float64) (xs, ys []float64) {
+	xs = make([]float64, len(values), len(values))
+	for i := range xs {
+		xs[i] = float64(i)
+	}
+	return xs, values
+}
+
+// movingAverage returns the mean of the finite values among the last window
+// values for each row from window-1 on. Rows whose window contains no finite
+// value are omitted.
+func movingAverage(values []float64, window int) (xs, ys []float64) {
+	sum := 0.0
+	n := 0
+	finite := func(v float64) bool { return math.IsNaN(v) == false && math.IsInf(v, 0) == false }
+	for i, v := range values {
+		if finite(v) {
+			sum += v
+			n++
+		}
+		if i >= window && finite(values[i-window]) {
+			sum -= values[i-window]
+			n--
+		}
+		if i >= window-1 && n > 0 {
+			xs = append(xs, float64(i))
+			ys = append(ys, sum/float64(n))
+		}
+	}
+	return
+}
+
+// finiteRange returns the minimum and maximum of the finite values. The
+// range is widened, if it is empty.
+func finiteRange(values []float64) (min, max float64) {
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if min > max {
+		return 0.0, 1.0
+	}
+	if min == max {
+		return min - 0.5, max + 0.5
+	}
+	return
+}
+
+// chart maps values to the plotting area of a chart
+type chart struct {
+	xMin, xMax, yMin, yMax float64
+}
+
+func (c chart) x(v float64) float64 {
+	return chartLeft + (v-c.xMin)/(c.xMax-c.xMin)*(chartWidth-chartLeft-chartRight)
+}
+
+func (c chart) y(v float64) float64 {
+	return chartHeight - chartBottom - (v-c.yMin)/(c.yMax-c.yMin)*(chartHeight-chartTop-chartBottom)
+}
+
+// frame returns the opening svg tag, the title, and the axes with the
+// labels of their ranges
+func (c chart) frame(title, xLabel string, xTicks bool) string {
+	s := fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-size=\"11\">\n", chartWidth, chartHeight)
+	s += fmt.Sprintf("<text x=\"%d\" y=\"16\" font-size=\"13\">%s</text>\n", chartLeft, html.EscapeString(title))
+	s += fmt.Sprintf("<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"none\" stroke=\"#999\"/>\n",
+		chartLeft, chartTop, chartWidth-chartLeft-chartRight, chartHeight-chartTop-chartBottom)
+	s += fmt.Sprintf("<text x=\"%d\" y=\"%.1f\" text-anchor=\"end\">%.4g</text>\n", chartLeft-4, c.y(c.yMax)+4, c.yMax)
+	s += fmt.Sprintf("<text x=\"%d\" y=\"%.1f\" text-anchor=\"end\">%.4g</text>\n", chartLeft-4, c.y(c.yMin), c.yMin)
+	if c.yMin < 0.0 && c.yMax > 0.0 {
+		s += fmt.Sprintf("<line x1=\"%d\" y1=\"%.1f\" x2=\"%d\" y2=\"%.1f\" stroke=\"#ccc\"/>\n", chartLeft, c.y(0.0), chartWidth-chartRight, c.y(0.0))
+		s += fmt.Sprintf("<text x=\"%d\" y=\"%.1f\" text-anchor=\"end\">0</text>\n", chartLeft-4, c.y(0.0)+4)
+	}
+	if xTicks {
+		s += fmt.Sprintf("<text x=\"%d\" y=\"%d\">%.4g</text>\n", chartLeft, chartHeight-chartBottom+14, c.xMin)
+		s += fmt.Sprintf("<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%.4g</text>\n", chartWidth-chartRight, chartHeight-chartBottom+14, c.xMax)
+	}
+	s += fmt.Sprintf("<text x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n", (chartWidth+chartLeft-chartRight)/2, chartHeight-6, html.EscapeString(xLabel))
+	return s
+}
+
+// svgLineChart draws ys over xs. Long series are drawn as the envelope of
+// chartPoints buckets, so that the size of the chart does not grow with the
+// data and single peaks remain visible.
+func svgLineChart(title string, xs, ys []float64, xLabel string) string {
+	if len(xs) == 0 {
+		return ""
+	}
+	px, py := envelope(xs, ys, chartPoints)
+	c := chart{xMin: xs[0], xMax: xs[len(xs)-1]}
+	if c.xMin == c.xMax {
+		c.xMin, c.xMax = c.xMin-0.5, c.xMax+0.5
+	}
+	c.yMin, c.yMax = finiteRange(py)
+
+	var b strings.Builder
+	b.WriteString(c.frame(title, xLabel, true))
+	b.WriteString("<polyline fill=\"none\" stroke=\"#1f77b4\" stroke-width=\"1\" points=\"")
+	for i := range px {
+		if math.IsNaN(py[i]) || math.IsInf(py[i], 0) {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%.1f,%.1f ", c.x(px[i]), c.y(py[i])))
+	}
+	b.WriteString("\"/>\n")
+	if len(px) <= 50 {
+		for i := range px {
+			b.WriteString(fmt.Sprintf("<circle cx=\"%.1f\" cy=\"%.1f\" r=\"2.5\" fill=\"#1f77b4\"/>\n", c.x(px[i]), c.y(py[i])))
+		}
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// envelope returns the points of the series, or, if there are more than n,
+// the minimum and the maximum of each of n/2 buckets
+func envelope(xs, ys []float64, n int) (px, py []float64) {
+	if len(xs) <= n {
+		return xs, ys
+	}
+	buckets := n / 2
+	for k := 0; k < buckets; k++ {
+		start := k * len(xs) / buckets
+		end := (k + 1) * len(xs) / buckets
+		lo, hi := start, start
+		for i := start; i < end; i++ {
+			if ys[i] < ys[lo] {
+				lo = i
+			}
+			if ys[i] > ys[hi] {
+				hi = i
+			}
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		px = append(px, xs[lo], xs[hi])
+		py = append(py, ys[lo], ys[hi])
+	}
+	return
+}
+
+// svgHistogram draws the distribution of the values with sqrt(n) (at most
+// 50) bins
+func svgHistogram(title string, values []float64) string {
+	min, max := finiteRange(values)
+	bins := int(math.Sqrt(float64(len(values))))
+	if bins > 50 {
+		bins = 50
+	}
+	if bins < 1 {
+		bins = 1
+	}
+	counts := make([]float64, bins, bins)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		i := int((v - min) / (max - min) * float64(bins))
+		if i >= bins {
+			i = bins - 1
+		}
+		counts[i]++
+	}
+	_, top := finiteRange(counts)
+	c := chart{xMin: min, xMax: max, yMin: 0.0, yMax: top}
+
+	var b strings.Builder
+	b.WriteString(c.frame(title, "value", true))
+	width := (max - min) / float64(bins)
+	for i, n := range counts {
+		x0 := c.x(min + float64(i)*width)
+		x1 := c.x(min + float64(i+1)*width)
+		b.WriteString(fmt.Sprintf("<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"#1f77b4\" stroke=\"#fff\" stroke-width=\"0.5\"/>\n",
+			x0, c.y(n), x1-x0, c.y(0.0)-c.y(n)))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// svgBarChart draws one bar per label
+func svgBarChart(title string, labels []string, values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	c := chart{xMin: 0.0, xMax: float64(len(values))}
+	c.yMin, c.yMax = finiteRange(append([]float64{0.0}, values...))
+
+	var b strings.Builder
+	b.WriteString(c.frame(title, "", false))
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		x0 := c.x(float64(i) + 0.1)
+		x1 := c.x(float64(i) + 0.9)
+		y0, y1 := c.y(math.Max(v, 0.0)), c.y(math.Min(v, 0.0))
+		b.WriteString(fmt.Sprintf("<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"#1f77b4\"><title>%s: %g</title></rect>\n",
+			x0, y0, x1-x0, y1-y0, html.EscapeString(labels[i]), v))
+		b.WriteString(fmt.Sprintf("<text x=\"%.1f\" y=\"%d\" text-anchor=\"middle\">%s</text>\n",
+			(x0+x1)/2.0, chartHeight-chartBottom+14, html.EscapeString(labels[i])))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
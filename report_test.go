@@ -0,0 +1,72 @@
+package gomi
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, bins := range []int{10, 20} {
+		var o Output
+		o.SetName("MI_W")
+		o.SetUseContinuous(false)
+		o.SetUseStateDependent(true)
+		o.CreateBins()
+		b := bins
+		o.Measure.Discrete.Bins.Global = &b
+		o.SetAvgResult(float64(bins) / 10.0)
+		o.SetPointWiseResult([]float64{0.5, 1.5, 1.0, 2.0, 0.0})
+		f := filepath.Join(dir, "bins"+strings.Repeat("0", bins/10)+".json")
+		o.ExportJSON(f)
+		files = append(files, f)
+	}
+	output := filepath.Join(dir, "report.html")
+	if err := Report(files, output, 2); err != nil {
+		t.Fatal(err)
+	}
+	bytes, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(bytes)
+	for _, want := range []string{"Averaged value over bins", "Moving average over 2 rows", "Distribution of the point-wise values", "measure.discrete.bins.global"} {
+		if strings.Contains(s, want) == false {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+	if n := strings.Count(s, "<svg"); n != 8 {
+		t.Errorf("report contains %d charts, want 8", n)
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []float64
+		wantXs []float64
+		wantYs []float64
+	}{
+		{"finite", []float64{1.0, 2.0, 3.0, 4.0}, []float64{1.0, 2.0, 3.0}, []float64{1.5, 2.5, 3.5}},
+		{"NaN", []float64{1.0, nan, 3.0, 4.0, 5.0}, []float64{1.0, 2.0, 3.0, 4.0}, []float64{1.0, 3.0, 3.5, 4.5}},
+		{"infinite window", []float64{1.0, math.Inf(1), math.Inf(-1), 4.0}, []float64{1.0, 3.0}, []float64{1.0, 4.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xs, ys := movingAverage(tt.values, 2)
+			if len(xs) != len(tt.wantXs) || len(ys) != len(tt.wantYs) {
+				t.Fatalf("movingAverage() = %v, %v, want %v, %v", xs, ys, tt.wantXs, tt.wantYs)
+			}
+			for i := range tt.wantYs {
+				if xs[i] != tt.wantXs[i] || ys[i] != tt.wantYs[i] {
+					t.Errorf("movingAverage()[%d] = %v, %v, want %v, %v", i, xs[i], ys[i], tt.wantXs[i], tt.wantYs[i])
+				}
+			}
+		})
+	}
+}
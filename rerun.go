@@ -0,0 +1,435 @@
+package gomi
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kzahedi/gomi/progress"
+)
+
+// RerunReport summarises the comparison of a recomputed result with the
+// result that is stored in a JSON file
+type RerunReport struct {
+	// Output is the file to which the recomputed result was written
+	Output string
+	// DataChanges lists all data files whose content differs from the
+	// content that was used for the stored result
+	DataChanges []string
+	// Unverifiable lists all data files without stored checksum, e.g. of
+	// results that were written before checksums were stored
+	Unverifiable []string
+	// Differences lists all differences between the stored and the
+	// recomputed result
+	Differences []string
+}
+
+// String returns the string representation of the report
+func (r RerunReport) String() string {
+	s := fmt.Sprintf("Recomputed result written to %s", r.Output)
+	if len(r.DataChanges) == 0 && len(r.Unverifiable) == 0 {
+		s = fmt.Sprintf("%s\nInput data: unchanged", s)
+	}
+	for _, c := range r.DataChanges {
+		s = fmt.Sprintf("%s\nInput data MISMATCH: %s", s, c)
+	}
+	for _, u := range r.Unverifiable {
+		s = fmt.Sprintf("%s\nInput data WARNING: %s", s, u)
+	}
+	if len(r.Differences) == 0 {
+		s = fmt.Sprintf("%s\nResult: identical to the stored result", s)
+	}
+	for _, d := range r.Differences {
+		s = fmt.Sprintf("%s\nResult DIFFERS: %s", s, d)
+	}
+	return s
+}
+
+// Rerun rebuilds the parameters from a JSON result file (see
+// Output.ExportJSON), recomputes the measure, and compares the new result
+// with the stored one. Values that differ by less than tolerance are
+// considered equal. If output is empty, the recomputed result is written
+// next to the JSON file with the suffix "-rerun". The computation reports its
+// progress to r and stops if ctx is cancelled (see Calculate).
+func Rerun(ctx context.Context, filename, output string, tolerance float64, r progress.Reporter) (RerunReport, error) {
+	var report RerunReport
+
+	stored, err := ImportJSON(filename)
+	if err != nil {
+		return report, err
+	}
+	if stored.Result == nil {
+		return report, fmt.Errorf("%s does not contain a result", filename)
+	}
+
+	p := stored.Parameters()
+	if output == "" {
+		output = fmt.Sprintf("%s-rerun.txt", strings.TrimSuffix(filename, filepath.Ext(filename)))
+	}
+	p.Output = output
+	p.LogData = true
+	if jsonFilename(p.Output) == filename {
+		return report, fmt.Errorf("the recomputed result would overwrite %s", filename)
+	}
+	report.Output = p.Output
+
+	report.DataChanges, report.Unverifiable, err = stored.CheckData(p)
+	if err != nil {
+		return report, err
+	}
+
+	if err := p.Validate(); err != nil {
+		return report, err
+	}
+
+	var data Data
+	data.Read(p)
+	if err := Calculate(ctx, p, data, r); err != nil {
+		return report, err
+	}
+
+	recomputed, err := ImportJSON(jsonFilename(p.Output))
+	if err != nil {
+		return report, err
+	}
+	report.Differences = stored.Diff(recomputed, tolerance)
+
+	return report, nil
+}
+
+// Parameters returns the parameters that were used to create the output
+func (o Output) Parameters() Parameters {
+	p := CreateParametersContainer()
+
+	if m := o.Measure; m != nil {
+		if m.Name != nil {
+			p.MeasureName = *m.Name
+		}
+		if m.Seed != nil {
+			p.Seed = *m.Seed
+		}
+		if m.UseContinuous != nil {
+			p.UseContinuous = *m.UseContinuous
+		}
+		if m.UseStateDependent != nil {
+			p.UseStateDependent = *m.UseStateDependent
+		}
+		if t := m.Transfer; t != nil {
+			if t.Source != nil {
+				p.Source = *t.Source
+			}
+			if t.Target != nil {
+				p.Target = *t.Target
+			}
+			if t.History != nil {
+				p.History = *t.History
+			}
+			if t.SourceHistory != nil {
+				p.SourceHistory = *t.SourceHistory
+			}
+		}
+		if c := m.Continuous; c != nil {
+			if c.Mode != nil {
+				p.ContinuousMode = *c.Mode
+			}
+			if c.K != nil {
+				p.K = *c.K
+			}
+			if c.Categorical != nil {
+				p.Categorical = *c.Categorical
+			}
+			// results without a normalisation mode were normalised with the
+			// domains, if given, and min-max scaling otherwise
+			if n := c.Normalisation; n != nil {
+				if n.Mode != nil {
+					p.Normalisation = *n.Mode
+				}
+				if n.Noise != nil {
+					p.Noise = *n.Noise
+				}
+			}
+		}
+		if d := m.Discrete; d != nil {
+			if d.Iterations != nil {
+				p.Iterations = *d.Iterations
+			}
+			// results without convergence information were computed with a
+			// fixed number of iterations
+			p.Tolerance = 0.0
+			if c := d.Convergence; c != nil {
+				if c.Tolerance != nil {
+					p.Tolerance = *c.Tolerance
+				}
+				if c.Criterion != nil {
+					p.Criterion = *c.Criterion
+				}
+				p.Trace = c.Trace != nil
+			}
+			// the result is recomputed with the storage that was selected
+			if d.UseSparseMatrix != nil {
+				p.SetUseSparseMatrix(*d.UseSparseMatrix)
+			}
+			if b := d.Bins; b != nil {
+				if b.Global != nil {
+					p.GlobalBins = *b.Global
+				}
+				if b.W != nil {
+					p.WBins = *b.W
+				}
+				if b.S != nil {
+					p.SBins = *b.S
+				}
+				if b.A != nil {
+					p.ABins = *b.A
+				}
+			}
+		}
+	}
+
+	if res := o.Result; res != nil && res.PID != nil && res.PID.Redundancy != nil {
+		p.Redundancy = *res.PID.Redundancy
+	}
+	if res := o.Result; res != nil && res.Reference != nil && res.Reference.Name != nil {
+		p.Reference = *res.Reference.Name
+	}
+	// outputs without a unit were written in the native unit of the measure
+	p.Unit = p.nativeUnit()
+	if res := o.Result; res != nil && res.Unit != nil {
+		p.Unit = *res.Unit
+	}
+
+	if o.Data == nil {
+		return p
+	}
+
+	if i := o.Data.Indices; i != nil {
+		if i.W != nil {
+			p.WIndices = *i.W
+		}
+		if i.S != nil {
+			p.SIndices = *i.S
+		}
+		if i.A != nil {
+			p.AIndices = *i.A
+		}
+	}
+
+	if f := o.Data.File; f != nil {
+		if f.Global != nil {
+			p.GlobalFile = *f.Global
+		}
+		if f.W != nil {
+			p.WFile = *f.W
+		}
+		if f.S != nil {
+			p.SFile = *f.S
+		}
+		if f.A != nil {
+			p.AFile = *f.A
+		}
+		// the domains are taken from the output and not from the domain
+		// file, because the file might have changed in the meantime
+		if d := f.Domain; d != nil {
+			if d.Name != nil {
+				p.DFile = *d.Name
+			}
+			p.WorldMin, p.WorldMax = d.World.values()
+			p.SensorMin, p.SensorMax = d.Sensor.values()
+			p.ActuatorMin, p.ActuatorMax = d.Actuator.values()
+		}
+	}
+
+	return p
+}
+
+func (m *OutputMinMax) values() (min, max []float64) {
+	min = []float64{}
+	max = []float64{}
+	if m == nil {
+		return
+	}
+	if m.Min != nil {
+		min = *m.Min
+	}
+	if m.Max != nil {
+		max = *m.Max
+	}
+	return
+}
+
+// CheckData compares the checksums of the data files given in the
+// parameters with the checksums that are stored in the output. It returns
+// a description of each file whose content has changed and of each file
+// without stored checksum, whose content cannot be verified.
+func (o Output) CheckData(p Parameters) (changes, unverifiable []string, err error) {
+	var stored OutputDataChecksums
+	if o.Data != nil && o.Data.File != nil && o.Data.File.Checksums != nil {
+		stored = *o.Data.File.Checksums
+	}
+	for _, f := range []struct {
+		label    string
+		name     string
+		checksum *string
+	}{{"global", p.GlobalFile, stored.Global},
+		{"world", p.WFile, stored.W},
+		{"sensors", p.SFile, stored.S},
+		{"actuators", p.AFile, stored.A}} {
+		if f.name == "" {
+			continue
+		}
+		if f.checksum == nil {
+			unverifiable = append(unverifiable, fmt.Sprintf("%s file %s has no stored checksum, cannot verify its content", f.label, f.name))
+			continue
+		}
+		current, err := fileChecksum(f.name)
+		if err != nil {
+			return changes, unverifiable, err
+		}
+		if current != *f.checksum {
+			changes = append(changes, fmt.Sprintf("%s file %s has changed (stored sha256 %s, current sha256 %s)", f.label, f.name, *f.checksum, current))
+		}
+	}
+	return changes, unverifiable, nil
+}
+
+// Diff compares the results of two outputs. Values that differ by less than
+// tolerance are considered equal.
+func (o Output) Diff(other Output, tolerance float64) (differences []string) {
+	var a, b OutputResult
+	if o.Result != nil {
+		a = *o.Result
+	}
+	if other.Result != nil {
+		b = *other.Result
+	}
+
+	if a.Unit != nil && b.Unit != nil && *a.Unit != *b.Unit {
+		differences = append(differences, fmt.Sprintf("unit %s != %s", *a.Unit, *b.Unit))
+	}
+
+	switch {
+	case a.Average == nil && b.Average == nil:
+	case a.Average == nil || b.Average == nil:
+		differences = append(differences, "averaged value is only present in one of the results")
+	case math.Abs(*a.Average-*b.Average) > tolerance:
+		differences = append(differences, fmt.Sprintf("averaged value %f != %f (difference %g)", *a.Average, *b.Average, *b.Average-*a.Average))
+	}
+
+	switch {
+	case a.PID == nil && b.PID == nil:
+	case a.PID == nil || b.PID == nil:
+		differences = append(differences, "partial information decomposition is only present in one of the results")
+	default:
+		differences = append(differences, diffComponents([]string{"redundant", "unique W", "unique A", "synergistic"},
+			[]*float64{a.PID.Redundant, a.PID.UniqueW, a.PID.UniqueA, a.PID.Synergistic},
+			[]*float64{b.PID.Redundant, b.PID.UniqueW, b.PID.UniqueA, b.PID.Synergistic}, tolerance)...)
+	}
+
+	switch {
+	case a.PID3 == nil && b.PID3 == nil:
+	case a.PID3 == nil || b.PID3 == nil:
+		differences = append(differences, "three-source partial information decomposition is only present in one of the results")
+	case len(*a.PID3) != len(*b.PID3):
+		differences = append(differences, fmt.Sprintf("number of atoms %d != %d", len(*a.PID3), len(*b.PID3)))
+	default:
+		names := make([]string, len(*a.PID3), len(*a.PID3))
+		va := make([]*float64, len(*a.PID3), len(*a.PID3))
+		vb := make([]*float64, len(*a.PID3), len(*a.PID3))
+		for i, atom := range *a.PID3 {
+			if atom.Name != nil {
+				names[i] = *atom.Name
+			}
+			va[i], vb[i] = atom.Value, (*b.PID3)[i].Value
+		}
+		differences = append(differences, diffComponents(names, va, vb, tolerance)...)
+	}
+
+	switch {
+	case a.Hierarchy == nil && b.Hierarchy == nil:
+	case a.Hierarchy == nil || b.Hierarchy == nil:
+		differences = append(differences, "hierarchy of interaction orders is only present in one of the results")
+	default:
+		differences = append(differences, diffComponents([]string{"individual", "pairwise", "triple"},
+			[]*float64{a.Hierarchy.Individual, a.Hierarchy.Pairwise, a.Hierarchy.Triple},
+			[]*float64{b.Hierarchy.Individual, b.Hierarchy.Pairwise, b.Hierarchy.Triple}, tolerance)...)
+	}
+
+	switch {
+	case a.Reference == nil && b.Reference == nil:
+	case a.Reference == nil || b.Reference == nil:
+		differences = append(differences, "reference is only present in one of the results")
+	case a.Reference.Value != nil && b.Reference.Value != nil && math.Abs(*a.Reference.Value-*b.Reference.Value) > tolerance:
+		differences = append(differences, fmt.Sprintf("reference %f != %f (difference %g)", *a.Reference.Value, *b.Reference.Value, *b.Reference.Value-*a.Reference.Value))
+	}
+
+	switch {
+	case a.PointWise == nil && b.PointWise == nil:
+	case a.PointWise == nil || b.PointWise == nil:
+		differences = append(differences, "point-wise values are only present in one of the results")
+	case len(*a.PointWise) != len(*b.PointWise):
+		differences = append(differences, fmt.Sprintf("number of point-wise values %d != %d", len(*a.PointWise), len(*b.PointWise)))
+	default:
+		n := 0
+		maxDiff := 0.0
+		first := -1
+		for i, v := range *a.PointWise {
+			d := math.Abs(v - (*b.PointWise)[i])
+			if d > tolerance {
+				n++
+				if first < 0 {
+					first = i
+				}
+			}
+			maxDiff = math.Max(maxDiff, d)
+		}
+		if n > 0 {
+			differences = append(differences, fmt.Sprintf("%d of %d point-wise values differ (first at index %d, maximal difference %g)", n, len(*a.PointWise), first, maxDiff))
+		}
+	}
+	return
+}
+
+// diffComponents compares the named components of two results with several
+// components (e.g. the atoms of PID)
+func diffComponents(names []string, a, b []*float64, tolerance float64) (differences []string) {
+	for i, name := range names {
+		switch {
+		case a[i] == nil && b[i] == nil:
+		case a[i] == nil || b[i] == nil:
+			differences = append(differences, fmt.Sprintf("%s information is only present in one of the results", name))
+		case math.Abs(*a[i]-*b[i]) > tolerance:
+			differences = append(differences, fmt.Sprintf("%s information %f != %f (difference %g)", name, *a[i], *b[i], *b[i]-*a[i]))
+		}
+	}
+	return
+}
+
+// fileChecksum returns the hex-encoded SHA-256 checksum of the file
+func fileChecksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func checksumOrNil(filename string) *string {
+	if filename == "" {
+		return nil
+	}
+	s, err := fileChecksum(filename)
+	if err != nil {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,42 @@
+package gomi
+
+import (
+	"testing"
+)
+
+func TestCheckData(t *testing.T) {
+	dir := t.TempDir()
+	w := writeCsv(t, dir, "w.csv", "0.1\n0.2\n")
+	a := writeCsv(t, dir, "a.csv", "0.3\n0.4\n")
+	checksum, err := fileChecksum(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := "0000"
+
+	tests := []struct {
+		name         string
+		checksums    *OutputDataChecksums
+		changes      int
+		unverifiable int
+	}{
+		{"no stored checksums", nil, 0, 2},
+		{"unchanged and unverifiable", &OutputDataChecksums{W: &checksum}, 0, 1},
+		{"changed", &OutputDataChecksums{W: &checksum, A: &wrong}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CreateParametersContainer()
+			p.SetWFile(w)
+			p.SetAFile(a)
+			o := Output{Data: &OutputData{File: &OutputDataFile{Checksums: tt.checksums}}}
+			changes, unverifiable, err := o.CheckData(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changes) != tt.changes || len(unverifiable) != tt.unverifiable {
+				t.Errorf("CheckData() = %v, %v, want %d changes and %d unverifiable files", changes, unverifiable, tt.changes, tt.unverifiable)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	entropy "github.com/kzahedi/goent/discrete"
+	"github.com/kzahedi/gomi/discrete"
+)
+
+// Binary is the binary sensorimotor loop of K. Zahedi and N. Ay.
+// Quantifying morphological computation. Entropy, 15(5):1887-1915, 2013. All
+// states take the values -1 and 1:
+//    p(s|w)     = exp(Zeta s w) / Z
+//    p(a|s)     = exp(Mu a s) / Z
+//    p(w'|w,a)  = exp(w' (Phi w + Psi a + Chi w a)) / Z
+// Phi is the coupling of the body (W -> W'), Psi the coupling of the
+// controller (A -> W'), and Chi their synergistic interaction.
+type Binary struct {
+	Phi, Psi, Chi, Zeta, Mu float64
+}
+
+func (b Binary) String() string {
+	return fmt.Sprintf("binary sensorimotor loop with phi = %g, psi = %g, chi = %g, zeta = %g, mu = %g", b.Phi, b.Psi, b.Chi, b.Zeta, b.Mu)
+}
+
+// state returns the value (-1 or 1) of the index (0 or 1)
+func state(i int) float64 {
+	return float64(2*i - 1)
+}
+
+// up returns the probability of the value 1 for the field h, i.e.
+// exp(h) / (exp(h) + exp(-h))
+func up(h float64) float64 {
+	return 1.0 / (1.0 + math.Exp(-2.0*h))
+}
+
+// probability returns the probability of the value x for the field h
+func probability(x, h float64) float64 {
+	if x > 0.0 {
+		return up(h)
+	}
+	return 1.0 - up(h)
+}
+
+func (b Binary) world(w, a float64) float64 {
+	return b.Phi*w + b.Psi*a + b.Chi*w*a
+}
+
+func draw(rng *rand.Rand, h float64) float64 {
+	if rng.Float64() < up(h) {
+		return 1.0
+	}
+	return -1.0
+}
+
+// Simulate returns n time steps of the loop. The initial world state is
+// drawn from the stationary distribution.
+func (b Binary) Simulate(rng *rand.Rand, n int) Series {
+	s := Series{W: make([]float64, n, n), S: make([]float64, n, n), A: make([]float64, n, n)}
+	w := -1.0
+	if rng.Float64() < b.stationary()[1] {
+		w = 1.0
+	}
+	for t := 0; t < n; t++ {
+		s.W[t] = w
+		s.S[t] = draw(rng, b.Zeta*w)
+		s.A[t] = draw(rng, b.Mu*s.S[t])
+		w = draw(rng, b.world(w, s.A[t]))
+	}
+	return s
+}
+
+// transition returns p(w'|w) = sum_{s,a} p(s|w) p(a|s) p(w'|w,a)
+func (b Binary) transition(w2, w1 int) (r float64) {
+	for s1 := 0; s1 < 2; s1++ {
+		for a1 := 0; a1 < 2; a1++ {
+			r += probability(state(s1), b.Zeta*state(w1)) *
+				probability(state(a1), b.Mu*state(s1)) *
+				probability(state(w2), b.world(state(w1), state(a1)))
+		}
+	}
+	return
+}
+
+// stationary returns the stationary distribution p(w) of the world states
+func (b Binary) stationary() []float64 {
+	in := b.transition(1, 0)
+	out := b.transition(0, 1)
+	return []float64{out / (in + out), in / (in + out)}
+}
+
+// Distribution returns the stationary joint distribution p(w',w,s,a) and
+// p(s',s,a), where s' is the sensor state of w'
+func (b Binary) Distribution() (pw2w1s1a1 [][][][]float64, ps2s1a1 [][][]float64) {
+	pw := b.stationary()
+	pw2w1s1a1 = make([][][][]float64, 2, 2)
+	for w2 := range pw2w1s1a1 {
+		pw2w1s1a1[w2] = make([][][]float64, 2, 2)
+		for w1 := range pw2w1s1a1[w2] {
+			pw2w1s1a1[w2][w1] = entropy.Create2D(2, 2)
+		}
+	}
+	ps2s1a1 = entropy.Create3D(2, 2, 2)
+	for w2 := 0; w2 < 2; w2++ {
+		for w1 := 0; w1 < 2; w1++ {
+			for s1 := 0; s1 < 2; s1++ {
+				for a1 := 0; a1 < 2; a1++ {
+					p := pw[w1] *
+						probability(state(s1), b.Zeta*state(w1)) *
+						probability(state(a1), b.Mu*state(s1)) *
+						probability(state(w2), b.world(state(w1), state(a1)))
+					pw2w1s1a1[w2][w1][s1][a1] = p
+					for s2 := 0; s2 < 2; s2++ {
+						ps2s1a1[s2][s1][a1] += p * probability(state(s2), b.Zeta*state(w2))
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// Analytic returns the values of the discrete measures of gomi for the
+// stationary distribution of the loop in bits. The measures that use
+// iterative scaling stop as defined by s.
+func (b Binary) Analytic(ctx context.Context, s discrete.Stopping) ([]Value, error) {
+	pw2w1s1a1, ps2s1a1 := b.Distribution()
+	pw2w1a1 := entropy.Create3D(2, 2, 2)
+	pw2a1w1 := entropy.Create3D(2, 2, 2)
+	pw2w1s1 := entropy.Create3D(2, 2, 2)
+	pw2w1 := entropy.Create2D(2, 2)
+	pw2a1 := entropy.Create2D(2, 2)
+	pa1s1 := entropy.Create2D(2, 2)
+	for w2, p3 := range pw2w1s1a1 {
+		for w1, p2 := range p3 {
+			for s1, p1 := range p2 {
+				for a1, p := range p1 {
+					pw2w1a1[w2][w1][a1] += p
+					pw2a1w1[w2][a1][w1] += p
+					pw2w1s1[w2][w1][s1] += p
+					pw2w1[w2][w1] += p
+					pw2a1[w2][a1] += p
+					pa1s1[a1][s1] += p
+				}
+			}
+		}
+	}
+
+	sy, _, err := discrete.MorphologicalComputationSY(ctx, pw2a1w1, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	synid, _, err := discrete.MorphologicalComputationSyNid(ctx, pw2a1w1, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	wp, _, err := discrete.MorphologicalComputationWp(ctx, pw2a1w1, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := discrete.SynergyHierarchy(ctx, pw2w1s1a1, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	return []Value{
+		{"MI_W", discrete.MorphologicalComputationW(pw2w1a1), false},
+		{"MI_A", discrete.MorphologicalComputationA(pw2a1w1), false},
+		// log|W| = 1 bit
+		{"MI_A_Prime", 1.0 - discrete.MorphologicalComputationA(pw2a1w1), true},
+		{"MI_MI", discrete.MorphologicalComputationMI(pw2w1, pa1s1), false},
+		{"MI_SY", sy, false},
+		{"MI_SY_NID", synid, false},
+		{"MI_CA", discrete.MorphologicalComputationCA(pw2w1, pw2a1), false},
+		{"MI_WA", discrete.MorphologicalComputationWA(pw2w1a1), false},
+		{"MI_WS", discrete.MorphologicalComputationWS(pw2w1s1), false},
+		{"MI_Wp", wp, false},
+		{"CA", discrete.MorphologicalComputationIntrinsicCA(ps2s1a1, 2), true},
+		{"CW", discrete.MorphologicalComputationIntrinsicCW(ps2s1a1), false},
+		{"MI_IN", discrete.MorphologicalComputationIN(pa1s1, 2), false},
+		{"MI_SY3", h.Synergy(), false},
+	}, nil
+}
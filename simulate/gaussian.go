@@ -0,0 +1,149 @@
+package simulate
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/kzahedi/gomi/continuous"
+)
+
+// Gaussian is the linear-Gaussian sensorimotor loop
+//    s[t]   = Zeta w[t] + Sigma e_s[t]
+//    a[t]   = Mu s[t] + Sigma e_a[t]
+//    w[t+1] = Phi w[t] + Psi a[t] + Sigma e_w[t]
+// with independent standard normal noise e_s, e_a, and e_w. Phi is the
+// coupling of the body (W -> W') and Psi the coupling of the controller
+// (A -> W'). The loop is stable if |Phi + Psi Mu Zeta| < 1. Its stationary
+// distribution is a multivariate normal distribution, for which all measures
+// that gomi estimates with the linear-Gaussian estimator have closed forms.
+type Gaussian struct {
+	Phi, Psi, Zeta, Mu, Sigma float64
+}
+
+func (g Gaussian) String() string {
+	return fmt.Sprintf("linear-Gaussian sensorimotor loop with phi = %g, psi = %g, zeta = %g, mu = %g, sigma = %g", g.Phi, g.Psi, g.Zeta, g.Mu, g.Sigma)
+}
+
+// columns of the stationary distribution (see Distribution)
+const (
+	gaussianW2 = iota
+	gaussianW1
+	gaussianS1
+	gaussianA1
+)
+
+// rho returns the coefficient of w[t] -> w[t+1] of the closed loop
+func (g Gaussian) rho() float64 {
+	return g.Phi + g.Psi*g.Mu*g.Zeta
+}
+
+// Check returns an error if the loop has no stationary distribution
+func (g Gaussian) Check() error {
+	if math.Abs(g.rho()) >= 1.0 {
+		return fmt.Errorf("the loop is not stable: |phi + psi mu zeta| = %g >= 1", math.Abs(g.rho()))
+	}
+	if g.Sigma <= 0.0 {
+		return fmt.Errorf("sigma must be positive, got %g", g.Sigma)
+	}
+	return nil
+}
+
+// variance returns the stationary variance of w
+//    var(w) = Sigma^2 (Psi^2 Mu^2 + Psi^2 + 1) / (1 - rho^2)
+func (g Gaussian) variance() float64 {
+	r := g.rho()
+	return g.Sigma * g.Sigma * (g.Psi*g.Psi*g.Mu*g.Mu + g.Psi*g.Psi + 1.0) / (1.0 - r*r)
+}
+
+// Simulate returns n time steps of the loop. The initial world state is
+// drawn from the stationary distribution.
+func (g Gaussian) Simulate(rng *rand.Rand, n int) (Series, error) {
+	if err := g.Check(); err != nil {
+		return Series{}, err
+	}
+	s := Series{W: make([]float64, n, n), S: make([]float64, n, n), A: make([]float64, n, n)}
+	w := math.Sqrt(g.variance()) * rng.NormFloat64()
+	for t := 0; t < n; t++ {
+		s.W[t] = w
+		s.S[t] = g.Zeta*w + g.Sigma*rng.NormFloat64()
+		s.A[t] = g.Mu*s.S[t] + g.Sigma*rng.NormFloat64()
+		w = g.Phi*w + g.Psi*s.A[t] + g.Sigma*rng.NormFloat64()
+	}
+	return s, nil
+}
+
+// Distribution returns the stationary distribution of (w', w, s, a). Each
+// variable is a linear combination of w and the noise (e_s, e_a, e_w), which
+// are independent, so that the covariance is the sum of the products of
+// the coefficients weighted by var(w) and 1.
+func (g Gaussian) Distribution() (continuous.Gaussian, error) {
+	if err := g.Check(); err != nil {
+		return continuous.Gaussian{}, err
+	}
+	coefficients := [][]float64{
+		gaussianW2: {g.rho(), g.Psi * g.Mu * g.Sigma, g.Psi * g.Sigma, g.Sigma},
+		gaussianW1: {1.0, 0.0, 0.0, 0.0},
+		gaussianS1: {g.Zeta, g.Sigma, 0.0, 0.0},
+		gaussianA1: {g.Mu * g.Zeta, g.Mu * g.Sigma, g.Sigma, 0.0},
+	}
+	v := g.variance()
+	d := continuous.Gaussian{Mean: make([]float64, 4, 4), Covariance: make([][]float64, 4, 4)}
+	for i, x := range coefficients {
+		d.Covariance[i] = make([]float64, 4, 4)
+		for j, y := range coefficients {
+			d.Covariance[i][j] = x[0] * y[0] * v
+			for k := 1; k < len(x); k++ {
+				d.Covariance[i][j] += x[k] * y[k]
+			}
+		}
+	}
+	return d, nil
+}
+
+// Analytic returns the values of the measures that gomi estimates with the
+// linear-Gaussian estimator for the stationary distribution of the loop in
+// nats. MI_IN is omitted, because the differential entropy H(A) depends on
+// the normalisation of the data.
+func (g Gaussian) Analytic() ([]Value, error) {
+	d, err := g.Distribution()
+	if err != nil {
+		return nil, err
+	}
+	w2 := []int{gaussianW2}
+	w1 := []int{gaussianW1}
+	s1 := []int{gaussianS1}
+	a1 := []int{gaussianA1}
+	measures := []struct {
+		name  string
+		terms []continuous.Term
+	}{
+		{"MI_W", []continuous.Term{{Sign: 1.0, X: w2, Y: w1, Z: a1}}},
+		{"MI_A", []continuous.Term{{Sign: 1.0, X: w2, Y: a1, Z: w1}}},
+		{"MI_MI", []continuous.Term{{Sign: 1.0, X: w2, Y: w1}, {Sign: -1.0, X: a1, Y: s1}}},
+		{"MI_CA", []continuous.Term{{Sign: 1.0, X: w2, Y: w1}, {Sign: -1.0, X: w2, Y: a1}}},
+		{"MI_WA", []continuous.Term{{Sign: 1.0, X: w2, Y: []int{gaussianW1, gaussianA1}}, {Sign: -1.0, X: w2, Y: a1}}},
+		{"MI_WS", []continuous.Term{{Sign: 1.0, X: w2, Y: []int{gaussianW1, gaussianS1}}, {Sign: -1.0, X: w2, Y: s1}}},
+	}
+	var r []Value
+	for _, m := range measures {
+		v := 0.0
+		for _, t := range m.terms {
+			i, err := d.ConditionalMutualInformation(t.X, t.Y, t.Z)
+			if err != nil {
+				return nil, err
+			}
+			v += t.Sign * i
+		}
+		r = append(r, Value{Measure: m.name, Value: v})
+	}
+	// MI_A' = 1 - I(W';A|W) / I(W';W,A)
+	z, err := d.ConditionalMutualInformation(w2, []int{gaussianW1, gaussianA1}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if z > 0.0 {
+		r = append(r, Value{Measure: "MI_A_Prime", Value: 1.0 - r[1].Value/z, Dimensionless: true})
+	}
+	return r, nil
+}
@@ -0,0 +1,68 @@
+// Package simulate generates time series of the world (W), sensor (S), and
+// actuator (A) states of sensorimotor loops whose morphological computation
+// is known analytically. The loops are
+//    w[t] -> s[t] -> a[t] -> w[t+1], with w[t] -> w[t+1]
+// i.e. the sensor state depends on the world state, the actuator state on
+// the sensor state, and the next world state on the current world and
+// actuator states. The samples (w[t+1], w[t], s[t], a[t]) therefore have
+// the alignment that gomi uses for all measures. The analytic values are
+// calculated from the stationary distribution of each loop.
+package simulate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Series is a simulated time series with one column each for W, S, and A
+type Series struct {
+	W, S, A []float64
+}
+
+// Value is the analytic value of a measure
+type Value struct {
+	Measure string
+	Value   float64
+	// Dimensionless values are ratios of information and have no unit
+	Dimensionless bool
+}
+
+// WriteCSV writes the series with the columns w, s, and a (indices 0, 1,
+// and 2) and without header, so that it can be read with -file and -wi 0
+// -si 1 -ai 2
+func (s Series) WriteCSV(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for t := range s.W {
+		w.WriteString(fmt.Sprintf("%s,%s,%s\n",
+			strconv.FormatFloat(s.W[t], 'g', -1, 64),
+			strconv.FormatFloat(s.S[t], 'g', -1, 64),
+			strconv.FormatFloat(s.A[t], 'g', -1, 64)))
+	}
+	return w.Flush()
+}
+
+// WriteValues writes the analytic values as a table with the columns
+// measure and value. Each line of header is written as a comment.
+func WriteValues(filename string, header []string, values []Value) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, h := range header {
+		w.WriteString(fmt.Sprintf("# %s\n", h))
+	}
+	w.WriteString("measure,value\n")
+	for _, v := range values {
+		w.WriteString(fmt.Sprintf("%s,%s\n", v.Measure, strconv.FormatFloat(v.Value, 'g', -1, 64)))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,108 @@
+package simulate
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/kzahedi/gomi/continuous"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/random"
+)
+
+func valueOf(values []Value, measure string) float64 {
+	for _, v := range values {
+		if v.Measure == measure {
+			return v.Value
+		}
+	}
+	return math.NaN()
+}
+
+func TestBinaryAnalytic(t *testing.T) {
+	s := discrete.Stopping{MaxIterations: 1000, Tolerance: 1e-12, Criterion: discrete.CriterionMarginal}
+	tests := []struct {
+		name    string
+		model   Binary
+		measure string
+		want    float64
+	}{
+		{"no controller", Binary{Phi: 1.0, Zeta: 1.0, Mu: 1.0}, "MI_A", 0.0},
+		{"no body", Binary{Psi: 1.0, Zeta: 1.0, Mu: 1.0}, "MI_W", 0.0},
+		{"no coupling", Binary{Zeta: 1.0, Mu: 1.0}, "MI_WA", 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw2w1s1a1, _ := tt.model.Distribution()
+			sum := 0.0
+			for _, p3 := range pw2w1s1a1 {
+				for _, p2 := range p3 {
+					for _, p1 := range p2 {
+						for _, p := range p1 {
+							sum += p
+						}
+					}
+				}
+			}
+			if math.Abs(sum-1.0) > 1e-12 {
+				t.Errorf("Distribution() sums to %v, want 1", sum)
+			}
+			values, err := tt.model.Analytic(context.Background(), s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := valueOf(values, tt.measure); math.Abs(got-tt.want) > 1e-10 {
+				t.Errorf("%s = %v, want %v", tt.measure, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGaussianDistribution(t *testing.T) {
+	g := Gaussian{Phi: 0.5, Psi: 0.3, Zeta: 1.0, Mu: 0.8, Sigma: 1.0}
+	d, err := g.Distribution()
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := g.Simulate(random.New(1).Stream("simulate", 0), 200001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([][]float64, len(series.W)-1, len(series.W)-1)
+	for i := range data {
+		data[i] = []float64{series.W[i+1], series.W[i], series.S[i], series.A[i]}
+	}
+	fitted, err := continuous.FitGaussian(context.Background(), "test", data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range d.Covariance {
+		for j := range d.Covariance[i] {
+			if math.Abs(fitted.Covariance[i][j]-d.Covariance[i][j]) > 0.05 {
+				t.Errorf("covariance[%d][%d] = %v, want %v", i, j, fitted.Covariance[i][j], d.Covariance[i][j])
+			}
+		}
+	}
+}
+
+func TestGaussianAnalytic(t *testing.T) {
+	values, err := Gaussian{Phi: 0.5, Zeta: 1.0, Mu: 1.0, Sigma: 1.0}.Analytic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := valueOf(values, "MI_A"); math.Abs(got) > 1e-10 {
+		t.Errorf("MI_A without controller = %v, want 0", got)
+	}
+	// without sensor, A is independent of W, var(w) = 2, and
+	//    MI_W = 1/2 log(1 + phi^2 var(w) / sigma^2)
+	values, err = Gaussian{Phi: 0.5, Psi: 0.5, Mu: 1.0, Sigma: 1.0}.Analytic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := valueOf(values, "MI_W"), 0.5*math.Log(1.5); math.Abs(got-want) > 1e-10 {
+		t.Errorf("MI_W without sensor = %v, want %v", got, want)
+	}
+	if _, err := (Gaussian{Phi: 0.9, Psi: 0.5, Zeta: 1.0, Mu: 1.0, Sigma: 1.0}).Analytic(); err == nil {
+		t.Errorf("Analytic() of an unstable loop did not return an error")
+	}
+}
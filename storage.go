@@ -0,0 +1,331 @@
+package gomi
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/kzahedi/goent/sm"
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/discrete/sparse"
+)
+
+// Storage of the discrete distributions
+const (
+	// StorageAuto selects dense storage if it fits into the memory budget
+	// and sparse storage otherwise
+	StorageAuto   = "auto"
+	StorageDense  = "dense"
+	StorageSparse = "sparse"
+)
+
+// bytes per value of a dense distribution, per slice header, and per index
+// dimension and value of a sparse matrix entry
+const (
+	bytesFloat       = 8
+	bytesSliceHeader = 24
+	bytesInt         = 8
+	// iterative scaling keeps the target, the estimate, and the marginals
+	denseIterativeFactor = 3
+	// support index, target, estimate and three feature cells per entry
+	// (see sparse.IterativeScaling)
+	bytesSparseIterativeEntry = bytesSliceHeader + 2*bytesFloat + 3*bytesInt
+)
+
+// DistributionEstimate is the estimated memory of one joint distribution
+type DistributionEstimate struct {
+	// Name is the name of the distribution, e.g. p(W',W,A)
+	Name string
+	// Dimensions are the alphabet sizes of the random variables
+	Dimensions []int
+	// Dense and Sparse are the estimated sizes in bytes
+	Dense, Sparse float64
+}
+
+// MemoryEstimate is the estimated memory that is required to calculate a
+// discrete measure with dense and with sparse storage
+type MemoryEstimate struct {
+	Measure string
+	Samples int
+	// Alphabets are the number of observed states of W, S, and A
+	Alphabets     map[string]int
+	Distributions []DistributionEstimate
+	// Dense and Sparse are the estimated totals in bytes
+	Dense, Sparse float64
+	// Budget is the memory budget in bytes, 0 means no limit
+	Budget int64
+	// Join is the number of states of the join of the observed marginals,
+	// over which the iterative scaling runs with sparse storage, 0 if it was
+	// not counted (see countJoin)
+	Join int
+	// iterativeEntries is the estimated size of the join before it is
+	// counted
+	iterativeEntries float64
+}
+
+// EstimateMemory estimates the alphabet sizes and the memory that the
+// distributions of the selected measure require. The alphabet size of a
+// variable is the number of its observed (discretised) states, because the
+// states are relabelled before the distributions are built.
+func EstimateMemory(p Parameters, d Data) MemoryEstimate {
+	d.Discretise(p)
+	e := MemoryEstimate{Measure: p.MeasureName, Budget: p.MemoryBudget, Alphabets: map[string]int{}}
+
+	for _, v := range []struct {
+		name string
+		data [][]int
+	}{{"W", d.Discretised.W}, {"S", d.Discretised.S}, {"A", d.Discretised.A}} {
+		if len(v.data) == 0 {
+			continue
+		}
+		e.Alphabets[v.name] = observedStates(v.data)
+		if e.Samples == 0 || len(v.data)-1 < e.Samples {
+			e.Samples = len(v.data) - 1
+		}
+	}
+
+	spec, _ := p.lookupMeasure()
+	for _, vars := range spec.distributions {
+		de := DistributionEstimate{Name: distributionName(vars)}
+		cells := 1.0
+		for _, v := range vars {
+			de.Dimensions = append(de.Dimensions, e.Alphabets[string(v)])
+			cells *= float64(e.Alphabets[string(v)])
+		}
+		de.Dense = denseBytes(de.Dimensions)
+		entries := math.Min(cells, float64(e.Samples))
+		de.Sparse = entries * float64(bytesSliceHeader+len(vars)*bytesInt+bytesFloat)
+		if spec.iterative {
+			de.Dense *= denseIterativeFactor
+			de.Sparse += entries * bytesSparseIterativeEntry
+			e.iterativeEntries += entries
+		}
+		// the samples (e.g. (w',w,a)) from which the distribution is estimated
+		samples := float64(e.Samples) * float64(bytesSliceHeader+len(vars)*bytesInt)
+		de.Dense += samples
+		de.Sparse += samples
+
+		e.Distributions = append(e.Distributions, de)
+		e.Dense += de.Dense
+		e.Sparse += de.Sparse
+	}
+
+	if p.UseStateDependent {
+		pointWise := float64(e.Samples) * bytesFloat
+		e.Dense += pointWise
+		e.Sparse += pointWise
+	}
+
+	return e
+}
+
+// countJoin replaces the estimated support of the iterative scaling of the
+// sparse storage by the size of the join of the observed marginals (see
+// sparse.JoinSize). The join can be much larger than the number of observed
+// states. The counting stops as soon as the join exceeds the budget.
+func (e *MemoryEstimate) countJoin(p Parameters, d Data) {
+	limit := 0
+	if e.Budget > 0 {
+		limit = int(float64(e.Budget)/bytesSparseIterativeEntry) + 1
+	}
+	var pd sm.SparseMatrix
+	features := []map[string][]int{discrete.SYFeatures}
+	switch p.MeasureName {
+	case "MI_SY3":
+		pd, features = MakePW2W1S1A1Sparse(d, p), discrete.HierarchyFeatures
+	case "MI_SY_NID":
+		pd, features[0] = MakePW2A1W1Sparse(d, p), discrete.SyNidFeatures
+	default:
+		pd = MakePW2A1W1Sparse(d, p)
+	}
+	e.Join = 0
+	for _, f := range features {
+		if n := sparse.JoinSize(pd, f, limit); n > e.Join {
+			e.Join = n
+		}
+	}
+	e.Sparse += (float64(e.Join) - e.iterativeEntries) * bytesSparseIterativeEntry
+	e.iterativeEntries = float64(e.Join)
+}
+
+// denseBytes returns the size of a nested slice with the given dimensions,
+// e.g. [][][]float64 for three dimensions
+func denseBytes(dimensions []int) float64 {
+	r := 0.0
+	n := 1.0
+	for i, d := range dimensions {
+		n *= float64(d)
+		if i < len(dimensions)-1 {
+			r += n * bytesSliceHeader
+		}
+	}
+	return r + n*bytesFloat
+}
+
+func observedStates(data [][]int) (n int) {
+	for _, label := range relabel(data) {
+		if label >= n {
+			n = label + 1
+		}
+	}
+	return
+}
+
+// distributionName returns e.g. p(W',W,A) for "WWA". The first variable is
+// the next state.
+func distributionName(vars string) string {
+	names := make([]string, len(vars), len(vars))
+	for i, v := range vars {
+		names[i] = string(v)
+	}
+	if len(vars) > 1 && vars[0] != 'A' {
+		names[0] = names[0] + "'"
+	}
+	return fmt.Sprintf("p(%s)", strings.Join(names, ","))
+}
+
+// Fits returns true if a storage with the given size fits into the budget
+func (e MemoryEstimate) Fits(bytes float64) bool {
+	return e.Budget == 0 || bytes <= float64(e.Budget)
+}
+
+// String returns a report of the estimate
+func (e MemoryEstimate) String() string {
+	s := fmt.Sprintf("Memory estimate for %s (%d samples)", e.Measure, e.Samples)
+	for _, v := range []string{"W", "S", "A"} {
+		if n, ok := e.Alphabets[v]; ok {
+			s = fmt.Sprintf("%s\n  %s: %d observed states", s, v, n)
+		}
+	}
+	for _, d := range e.Distributions {
+		dims := make([]string, len(d.Dimensions), len(d.Dimensions))
+		for i, n := range d.Dimensions {
+			dims[i] = strconv.Itoa(n)
+		}
+		s = fmt.Sprintf("%s\n  %s: %s states, dense %s, sparse %s", s, d.Name, strings.Join(dims, " x "), humanBytes(d.Dense), humanBytes(d.Sparse))
+	}
+	if e.Join > 0 {
+		s = fmt.Sprintf("%s\n  join of the observed marginals (iterative scaling): %d states", s, e.Join)
+	}
+	budget := "no limit"
+	if e.Budget > 0 {
+		budget = humanBytes(float64(e.Budget))
+	}
+	return fmt.Sprintf("%s\n  total: dense %s, sparse %s, memory budget %s", s, humanBytes(e.Dense), humanBytes(e.Sparse), budget)
+}
+
+// selectStorage sets UseSparseMatrix according to p.Storage and the memory
+// estimate. It returns an error with the estimate if the selected storage,
+// or with StorageAuto any storage, does not fit into the memory budget. For
+// iterative measures, the sparse storage is estimated with the size of the
+// join of the observed marginals, which is counted once if the sparse storage
+// is considered.
+func (p Parameters) selectStorage(d Data) (Parameters, error) {
+	e := EstimateMemory(p, d)
+
+	sparseMode := modeSparseAvg
+	if p.UseStateDependent {
+		sparseMode = modeSparseSD
+	}
+	spec, _ := p.lookupMeasure()
+	sparseAvailable := spec.modes&sparseMode != 0
+	if spec.iterative && sparseAvailable && e.Budget > 0 &&
+		(p.Storage == StorageSparse || (p.Storage != StorageDense && e.Fits(e.Dense) == false)) {
+		e.countJoin(p, d)
+	}
+
+	switch p.Storage {
+	case StorageDense:
+		if e.Fits(e.Dense) == false {
+			return p, fmt.Errorf("dense storage requires about %s, which exceeds the memory budget (-mem), use -storage auto or sparse, or reduce the number of bins\n%s", humanBytes(e.Dense), e)
+		}
+		p.UseSparseMatrix = false
+	case StorageSparse:
+		if e.Fits(e.Sparse) == false {
+			return p, fmt.Errorf("sparse storage requires about %s, which exceeds the memory budget (-mem), reduce the number of bins or increase -mem\n%s", humanBytes(e.Sparse), e)
+		}
+		p.UseSparseMatrix = true
+	default:
+		switch {
+		case e.Fits(e.Dense):
+			p.UseSparseMatrix = false
+		case sparseAvailable && e.Fits(e.Sparse):
+			p.UseSparseMatrix = true
+		case sparseAvailable:
+			return p, fmt.Errorf("neither dense nor sparse storage fits into the memory budget (-mem), reduce the number of bins or increase -mem\n%s", e)
+		default:
+			return p, fmt.Errorf("dense storage exceeds the memory budget (-mem) and %s is not available with sparse storage, reduce the number of bins or increase -mem\n%s", p.MeasureName, e)
+		}
+	}
+
+	if p.Verbose {
+		storage := StorageDense
+		if p.UseSparseMatrix {
+			storage = StorageSparse
+		}
+		fmt.Printf("%s\nUsing %s storage\n", e, storage)
+	}
+	return p, nil
+}
+
+// SetStorage sets the storage of the distributions (StorageAuto,
+// StorageDense, or StorageSparse)
+func (p *Parameters) SetStorage(storage string) error {
+	if isKnownStorage(storage) == false {
+		return fmt.Errorf("unknown storage %q, use %s, %s, or %s", storage, StorageAuto, StorageDense, StorageSparse)
+	}
+	p.Storage = storage
+	p.UseSparseMatrix = storage == StorageSparse
+	return nil
+}
+
+func isKnownStorage(storage string) bool {
+	return storage == StorageAuto || storage == StorageDense || storage == StorageSparse
+}
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}}
+
+// parseByteSize parses a memory size, e.g. 512M, 4G, 4GiB, or 1000 (bytes).
+// The units are powers of 1024.
+func parseByteSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "IB"), "B")
+	factor := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			factor = u.size
+			v = strings.TrimSuffix(v, u.suffix)
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("cannot parse %q as memory size (e.g. 512M, 4G)", s)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+// formatByteSize returns the exact representation of a memory size that is
+// parsed by parseByteSize, e.g. 4G
+func formatByteSize(n int64) string {
+	for _, u := range byteUnits {
+		if n > 0 && n%u.size == 0 {
+			return fmt.Sprintf("%d%s", n/u.size, u.suffix)
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// humanBytes returns an approximate, human readable memory size
+func humanBytes(n float64) string {
+	for _, u := range byteUnits {
+		if n >= float64(u.size) {
+			return fmt.Sprintf("%.1f %siB", n/float64(u.size), u.suffix)
+		}
+	}
+	return fmt.Sprintf("%.0f B", n)
+}
@@ -0,0 +1,137 @@
+package gomi
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"1000", 1000, false},
+		{"512M", 512 << 20, false},
+		{"4G", 4 << 30, false},
+		{"4GiB", 4 << 30, false},
+		{"1.5k", 1536, false},
+		{"0", 0, false},
+		{"-1G", 0, true},
+		{"four", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseByteSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize() = %d, want %d", got, tt.want)
+			}
+			if tt.wantErr == false {
+				if back, _ := parseByteSize(formatByteSize(got)); back != got {
+					t.Errorf("formatByteSize(%d) = %s does not parse back", got, formatByteSize(got))
+				}
+			}
+		})
+	}
+}
+
+func TestSelectStorage(t *testing.T) {
+	var d Data
+	for i := 0; i < 100; i++ {
+		d.W = append(d.W, []float64{float64(i % 10)})
+		d.A = append(d.A, []float64{float64(i % 5)})
+	}
+	p := CreateParametersContainer()
+	p.SetMeasureName("MI_W")
+	p.SetGlobalBins(10)
+
+	e := EstimateMemory(p, d)
+	if e.Alphabets["W"] != 10 || e.Alphabets["A"] != 5 {
+		t.Fatalf("EstimateMemory() alphabets = %v, want W: 10, A: 5", e.Alphabets)
+	}
+	if e.Dense <= e.Sparse {
+		t.Fatalf("EstimateMemory() dense = %f, sparse = %f, want dense > sparse", e.Dense, e.Sparse)
+	}
+	between := int64((e.Dense + e.Sparse) / 2)
+
+	tests := []struct {
+		name       string
+		storage    string
+		budget     int64
+		wantSparse bool
+		wantErr    bool
+	}{
+		{"auto without limit", StorageAuto, 0, false, false},
+		{"auto, dense fits", StorageAuto, int64(e.Dense) + 1, false, false},
+		{"auto, only sparse fits", StorageAuto, between, true, false},
+		{"auto, nothing fits", StorageAuto, 1, false, true},
+		{"dense does not fit", StorageDense, between, false, true},
+		{"sparse fits", StorageSparse, between, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := p
+			q.SetStorage(tt.storage)
+			q.MemoryBudget = tt.budget
+			got, err := q.selectStorage(d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.UseSparseMatrix != tt.wantSparse {
+				t.Errorf("selectStorage() UseSparseMatrix = %t, want %t", got.UseSparseMatrix, tt.wantSparse)
+			}
+		})
+	}
+}
+
+// the sparse storage of iterative measures is estimated with the join of the
+// observed marginals. It equals the observed states if W' is a function of W
+// and exceeds them if W' depends on W and A jointly.
+func TestSelectStorageIterative(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var function, joint Data
+	w := 0
+	for i := 0; i < 100; i++ {
+		function.W = append(function.W, []float64{float64(i % 10)})
+		function.A = append(function.A, []float64{float64(i % 5)})
+		a := rng.Intn(5)
+		joint.W = append(joint.W, []float64{float64(w)})
+		joint.A = append(joint.A, []float64{float64(a)})
+		w = (w + a) % 10
+	}
+
+	tests := []struct {
+		name       string
+		data       Data
+		storage    string
+		wantSparse bool
+		wantErr    bool
+	}{
+		{"function, auto", function, StorageAuto, true, false},
+		{"function, sparse", function, StorageSparse, true, false},
+		{"joint, auto", joint, StorageAuto, false, true},
+		{"joint, sparse", joint, StorageSparse, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CreateParametersContainer()
+			p.SetMeasureName("MI_SY")
+			p.SetGlobalBins(10)
+			e := EstimateMemory(p, tt.data)
+			// the budget only fits the sparse storage if the join is not
+			// larger than the observed states
+			p.MemoryBudget = int64(e.Sparse) + 1
+			p.SetStorage(tt.storage)
+			got, err := p.selectStorage(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.UseSparseMatrix != tt.wantSparse {
+				t.Errorf("selectStorage() UseSparseMatrix = %t, want %t", got.UseSparseMatrix, tt.wantSparse)
+			}
+		})
+	}
+}
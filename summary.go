@@ -0,0 +1,161 @@
+package gomi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// QuantileLevels are the levels of the quantiles of Summary
+var QuantileLevels = []float64{0.01, 0.05, 0.25, 0.5, 0.75, 0.95, 0.99}
+
+// summaryBins is the number of bins of the histogram of Summary
+const summaryBins = 20
+
+// Summary describes the distribution of the local values of a
+// state-dependent result. Values that are not finite (NaN, +-Inf) are not
+// included and only counted in NonFinite.
+type Summary struct {
+	N         int
+	NonFinite int
+	Mean      float64
+	// SD is the sample standard deviation
+	SD     float64
+	Min    float64
+	Max    float64
+	Median float64
+	// Quantiles are the quantiles at QuantileLevels (linear interpolation
+	// between the order statistics)
+	Quantiles []float64
+	// FractionNegative is the fraction of negative values, i.e. of samples
+	// that are misinformative about the target
+	FractionNegative float64
+	// Skewness and the excess Kurtosis describe the asymmetry and the tails of
+	// the distribution (0 for a normal distribution)
+	Skewness float64
+	Kurtosis float64
+	// Histogram has summaryBins bins of equal width from Min to Max. The last
+	// bin includes Max.
+	Edges  []float64
+	Counts []int
+}
+
+// Summarise returns the summary statistics of the values
+func Summarise(values []float64) Summary {
+	var s Summary
+	sorted := make([]float64, 0, len(values))
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			s.NonFinite++
+			continue
+		}
+		sorted = append(sorted, v)
+	}
+	s.N = len(sorted)
+	if s.N == 0 {
+		return s
+	}
+	sort.Float64s(sorted)
+
+	negative := 0
+	for _, v := range sorted {
+		s.Mean += v
+		if v < 0.0 {
+			negative++
+		}
+	}
+	n := float64(s.N)
+	s.Mean /= n
+	s.FractionNegative = float64(negative) / n
+
+	var m2, m3, m4 float64
+	for _, v := range sorted {
+		d := v - s.Mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	if s.N > 1 {
+		s.SD = math.Sqrt(m2 / (n - 1.0))
+	}
+	if m2 > 0.0 {
+		//    skewness = m3 / m2^(3/2), kurtosis = m4 / m2^2 - 3 (central moments)
+		m2, m3, m4 = m2/n, m3/n, m4/n
+		s.Skewness = m3 / math.Pow(m2, 1.5)
+		s.Kurtosis = m4/(m2*m2) - 3.0
+	}
+
+	s.Min = sorted[0]
+	s.Max = sorted[s.N-1]
+	s.Median = quantile(sorted, 0.5)
+	s.Quantiles = make([]float64, len(QuantileLevels), len(QuantileLevels))
+	for i, q := range QuantileLevels {
+		s.Quantiles[i] = quantile(sorted, q)
+	}
+
+	s.Edges = make([]float64, summaryBins+1, summaryBins+1)
+	s.Counts = make([]int, summaryBins, summaryBins)
+	width := (s.Max - s.Min) / summaryBins
+	for i := range s.Edges {
+		s.Edges[i] = s.Min + float64(i)*width
+	}
+	s.Edges[summaryBins] = s.Max
+	for _, v := range sorted {
+		i := summaryBins - 1
+		if width > 0.0 {
+			i = int((v - s.Min) / width)
+		}
+		if i >= summaryBins {
+			i = summaryBins - 1
+		}
+		s.Counts[i]++
+	}
+	return s
+}
+
+// quantile returns the q-quantile of the sorted values, interpolated
+// linearly between the order statistics
+func quantile(sorted []float64, q float64) float64 {
+	h := q * float64(len(sorted)-1)
+	i := int(math.Floor(h))
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// String returns the summary with one statistic per line. Each line starts
+// with prefix.
+func (s Summary) String(prefix string) string {
+	if s.N == 0 {
+		return fmt.Sprintf("%sNo finite values", prefix)
+	}
+	lines := []string{
+		fmt.Sprintf("%sValues: %d", prefix, s.N),
+	}
+	if s.NonFinite > 0 {
+		lines = append(lines, fmt.Sprintf("%sNon-finite values: %d (not included)", prefix, s.NonFinite))
+	}
+	quantiles := make([]string, len(s.Quantiles), len(s.Quantiles))
+	for i, q := range s.Quantiles {
+		quantiles[i] = fmt.Sprintf("%g%%: %f", 100.0*QuantileLevels[i], q)
+	}
+	counts := make([]string, len(s.Counts), len(s.Counts))
+	for i, c := range s.Counts {
+		counts[i] = fmt.Sprintf("%d", c)
+	}
+	lines = append(lines,
+		fmt.Sprintf("%sMedian: %f", prefix, s.Median),
+		fmt.Sprintf("%sStandard deviation: %f", prefix, s.SD),
+		fmt.Sprintf("%sMinimum: %f", prefix, s.Min),
+		fmt.Sprintf("%sMaximum: %f", prefix, s.Max),
+		fmt.Sprintf("%sQuantiles: %s", prefix, strings.Join(quantiles, ", ")),
+		fmt.Sprintf("%sFraction of negative values: %f", prefix, s.FractionNegative),
+		fmt.Sprintf("%sSkewness: %f", prefix, s.Skewness),
+		fmt.Sprintf("%sExcess kurtosis: %f", prefix, s.Kurtosis),
+		fmt.Sprintf("%sHistogram (%d bins of width %f from %f to %f): %s", prefix, len(s.Counts),
+			(s.Max-s.Min)/float64(len(s.Counts)), s.Min, s.Max, strings.Join(counts, ",")),
+	)
+	return strings.Join(lines, "\n")
+}
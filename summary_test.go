@@ -0,0 +1,53 @@
+package gomi
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummarise(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		n        int
+		median   float64
+		sd       float64
+		negative float64
+		q25      float64
+	}{
+		{"symmetric", []float64{-2.0, -1.0, 0.0, 1.0, 2.0}, 5, 0.0, math.Sqrt(2.5), 0.4, -1.0},
+		{"interpolated", []float64{4.0, 1.0, 3.0, 2.0}, 4, 2.5, math.Sqrt(5.0 / 3.0), 0.0, 1.75},
+		{"non-finite", []float64{1.0, math.NaN(), 1.0, math.Inf(1)}, 2, 1.0, 0.0, 0.0, 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Summarise(tt.values)
+			if s.N != tt.n || s.N+s.NonFinite != len(tt.values) {
+				t.Errorf("Summarise() has %d finite and %d non-finite values, want %d of %d finite", s.N, s.NonFinite, tt.n, len(tt.values))
+			}
+			for _, c := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"median", s.Median, tt.median},
+				{"sd", s.SD, tt.sd},
+				{"fraction negative", s.FractionNegative, tt.negative},
+				{"25% quantile", s.Quantiles[2], tt.q25},
+			} {
+				if math.Abs(c.got-c.want) > 1e-12 {
+					t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+				}
+			}
+			count := 0
+			for _, c := range s.Counts {
+				count += c
+			}
+			if count != s.N || len(s.Edges) != len(s.Counts)+1 {
+				t.Errorf("histogram counts %d values with %d edges and %d bins, want %d values", count, len(s.Edges), len(s.Counts), s.N)
+			}
+		})
+	}
+	if s := Summarise([]float64{math.NaN()}); s.N != 0 || s.Quantiles != nil {
+		t.Errorf("Summarise() of no finite values = %+v, want an empty summary", s)
+	}
+}
@@ -14,11 +14,13 @@ type DataDiscretised struct {
 	A [][]int
 }
 
-// Data contains the raw data an the discretised data (if discrete measure are used)
+// Data contains the raw data an the discretised data (if discrete measure are used).
+// Time contains the timestamps of the rows, if given (see Parameters.TimeIndex).
 type Data struct {
 	W           [][]float64
 	S           [][]float64
 	A           [][]float64
+	Time        []float64
 	Discretised DataDiscretised
 }
 
@@ -67,6 +69,12 @@ func (d *Data) Read(p Parameters) {
 		d.W = wData
 		d.S = sData
 		d.A = aData
+		if p.TimeIndex >= 0 {
+			d.Time = make([]float64, len(data), len(data))
+			for i, row := range data {
+				d.Time[i] = row[p.TimeIndex]
+			}
+		}
 		return
 	}
 
@@ -86,31 +94,26 @@ func (d *Data) Read(p Parameters) {
 	}
 }
 
-func discretiseData(data [][]float64, globalBins int, min, max []float64) [][]int {
+func discretiseData(data [][]float64, bins []int, min, max []float64) [][]int {
 	if len(min) == 0 {
 		min, max = dh.GetMinMax(data)
 	}
-	bins := make([]int, len(min), len(min))
-	for i := 0; i < len(min); i++ {
-		bins[i] = globalBins
-	}
 	return dh.Discretise(data, bins, min, max)
 }
 
 // Discretise discretises the available data and stores in the in the
-// Discretised portion of the struct
+// Discretised portion of the struct. The bins of a column are taken from
+// WBins, SBins, and ABins, or from GlobalBins, if no bins are given for the
+// variable (see columnBins).
 func (d *Data) Discretise(p Parameters) {
-	if p.GlobalBins > 0 {
-		if len(d.W) > 0 {
-			d.Discretised.W = discretiseData(d.W, p.GlobalBins, p.WorldMin, p.WorldMax)
-		}
-		if len(d.S) > 0 {
-			d.Discretised.S = discretiseData(d.S, p.GlobalBins, p.SensorMin, p.SensorMax)
-		}
-		if len(d.A) > 0 {
-			d.Discretised.A = discretiseData(d.A, p.GlobalBins, p.ActuatorMin, p.ActuatorMax)
-		}
-		return
+	if len(d.W) > 0 {
+		d.Discretised.W = discretiseData(d.W, columnBins(p.WBins, p.GlobalBins, len(d.W[0])), p.WorldMin, p.WorldMax)
+	}
+	if len(d.S) > 0 {
+		d.Discretised.S = discretiseData(d.S, columnBins(p.SBins, p.GlobalBins, len(d.S[0])), p.SensorMin, p.SensorMax)
+	}
+	if len(d.A) > 0 {
+		d.Discretised.A = discretiseData(d.A, columnBins(p.ABins, p.GlobalBins, len(d.A[0])), p.ActuatorMin, p.ActuatorMax)
 	}
 }
 
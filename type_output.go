@@ -3,8 +3,13 @@ package gomi
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strconv"
 	"time"
+
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/discrete/pid"
 )
 
 // OutputMinMax ...
@@ -15,9 +20,33 @@ type OutputMinMax struct {
 
 // OutputMeasureContinuous ...
 type OutputMeasureContinuous struct {
-	Mode          *int          `json:"mode,omitempty"`
-	K             *int          `json:"k,omitempty"`
-	Normalisation *OutputMinMax `json:"normalisation,omitempty"`
+	Mode          *int                 `json:"mode,omitempty"`
+	K             *int                 `json:"k,omitempty"`
+	Normalisation *OutputNormalisation `json:"normalisation,omitempty"`
+	Normaliser    *OutputNormaliser    `json:"normaliser,omitempty"`
+	// Partition is the number of bins per column of the adaptive partition
+	Partition *int `json:"partition,omitempty"`
+	// Categorical lists the variables that were treated as categorical
+	Categorical *[]string `json:"categorical,omitempty"`
+}
+
+// OutputNormaliser is the estimated term that replaces the alphabet size of a
+// discrete measure (e.g. log|A| of MI_IN) on continuous data
+type OutputNormaliser struct {
+	Term  *string  `json:"term,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+// OutputNormalisation describes the preprocessing of continuous data
+type OutputNormalisation struct {
+	// Mode is the requested normalisation, Applied the one that was used
+	Mode    *string    `json:"mode,omitempty"`
+	Applied *string    `json:"applied,omitempty"`
+	Min     *[]float64 `json:"min,omitempty"`
+	Max     *[]float64 `json:"max,omitempty"`
+	Mean    *[]float64 `json:"mean,omitempty"`
+	SD      *[]float64 `json:"sd,omitempty"`
+	Noise   *float64   `json:"noise,omitempty"`
 }
 
 // OutputBins ...
@@ -28,25 +57,125 @@ type OutputBins struct {
 	Global *int   `json:"global,omitempty"`
 }
 
+// OutputConvergence describes how the iterative scaling stopped
+type OutputConvergence struct {
+	Criterion  *string    `json:"criterion,omitempty"`
+	Tolerance  *float64   `json:"tolerance,omitempty"`
+	Achieved   *float64   `json:"achieved,omitempty"`
+	Iterations *int       `json:"iterations,omitempty"`
+	Converged  *bool      `json:"converged,omitempty"`
+	Trace      *[]float64 `json:"trace,omitempty"`
+}
+
 // OutputMeasureDiscrete ...
 type OutputMeasureDiscrete struct {
-	Iterations *int        `json:"iterations,omitempty"`
-	Bins       *OutputBins `json:"bins,omitempty"`
+	Iterations      *int               `json:"iterations,omitempty"`
+	UseSparseMatrix *bool              `json:"sparse,omitempty"`
+	Bins            *OutputBins        `json:"bins,omitempty"`
+	Convergence     *OutputConvergence `json:"convergence,omitempty"`
 }
 
 // OutputMeasure ...
 type OutputMeasure struct {
 	Name              *string                  `json:"name,omitempty"`
+	Seed              *int64                   `json:"seed,omitempty"`
 	UseContinuous     *bool                    `json:"useContinuous,omitempty"`
 	UseStateDependent *bool                    `json:"stateDependent,omitempty"`
 	Continuous        *OutputMeasureContinuous `json:"continuous,omitempty"`
 	Discrete          *OutputMeasureDiscrete   `json:"discrete,omitempty"`
+	Transfer          *OutputTransfer          `json:"transfer,omitempty"`
+}
+
+// OutputTransfer describes the variables and history lengths of TE and AIS
+type OutputTransfer struct {
+	Source        *string `json:"source,omitempty"`
+	Target        *string `json:"target,omitempty"`
+	History       *int    `json:"history,omitempty"`
+	SourceHistory *int    `json:"sourceHistory,omitempty"`
 }
 
 // OutputResult ...
 type OutputResult struct {
-	Average   *float64   `json:"averaged,omitempty"`
-	PointWise *[]float64 `json:"point-wise,omitempty"`
+	Average   *float64         `json:"averaged,omitempty"`
+	PointWise *[]float64       `json:"point-wise,omitempty"`
+	PID       *OutputPID       `json:"pid,omitempty"`
+	PID3      *[]OutputAtom    `json:"pid3,omitempty"`
+	Hierarchy *OutputHierarchy `json:"hierarchy,omitempty"`
+	// Normalised is the averaged value divided by the reference
+	Normalised *float64         `json:"normalised,omitempty"`
+	Reference  *OutputReference `json:"reference,omitempty"`
+	// Unit is the unit of all values of the result (see Parameters.Unit)
+	Unit *string `json:"unit,omitempty"`
+	// Summary describes the distribution of the point-wise values
+	Summary *OutputSummary `json:"summary,omitempty"`
+}
+
+// OutputSummary contains the summary statistics of the point-wise values of
+// a state-dependent result (see Summary)
+type OutputSummary struct {
+	N                *int              `json:"n,omitempty"`
+	NonFinite        *int              `json:"nonFinite,omitempty"`
+	Mean             *float64          `json:"mean,omitempty"`
+	SD               *float64          `json:"sd,omitempty"`
+	Min              *float64          `json:"min,omitempty"`
+	Max              *float64          `json:"max,omitempty"`
+	Median           *float64          `json:"median,omitempty"`
+	Quantiles        *[]OutputQuantile `json:"quantiles,omitempty"`
+	FractionNegative *float64          `json:"fractionNegative,omitempty"`
+	Skewness         *float64          `json:"skewness,omitempty"`
+	Kurtosis         *float64          `json:"excessKurtosis,omitempty"`
+	Histogram        *OutputHistogram  `json:"histogram,omitempty"`
+}
+
+// OutputQuantile is the quantile of the point-wise values at a level
+type OutputQuantile struct {
+	Level *float64 `json:"level,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+// OutputHistogram contains the bin edges and the counts of a histogram
+type OutputHistogram struct {
+	Edges  *[]float64 `json:"edges,omitempty"`
+	Counts *[]int     `json:"counts,omitempty"`
+}
+
+// OutputReference is the reference by which the results are normalised and
+// its constituent terms (see Parameters.Reference)
+type OutputReference struct {
+	Name  *string                `json:"name,omitempty"`
+	Value *float64               `json:"value,omitempty"`
+	Terms *[]OutputReferenceTerm `json:"terms,omitempty"`
+}
+
+// OutputReferenceTerm is one of the constituent terms of the reference
+type OutputReferenceTerm struct {
+	Term  *string  `json:"term,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+// OutputHierarchy contains the contributions of the interaction orders of
+// W, S, and A to I(W';W,S,A), see discrete.Hierarchy
+type OutputHierarchy struct {
+	Individual *float64 `json:"individual,omitempty"`
+	Pairwise   *float64 `json:"pairwise,omitempty"`
+	Triple     *float64 `json:"triple,omitempty"`
+}
+
+// OutputPID contains the atoms of the partial information decomposition of
+// I(W';W,A) and the measure of redundancy that determines them
+type OutputPID struct {
+	Redundancy  *string  `json:"redundancy,omitempty"`
+	Redundant   *float64 `json:"redundant,omitempty"`
+	UniqueW     *float64 `json:"uniqueW,omitempty"`
+	UniqueA     *float64 `json:"uniqueA,omitempty"`
+	Synergistic *float64 `json:"synergistic,omitempty"`
+}
+
+// OutputAtom is an atom of the partial information decomposition of
+// I(W';W,S,A), named by its antichain, e.g. {W}{SA}
+type OutputAtom struct {
+	Name  *string  `json:"name,omitempty"`
+	Value *float64 `json:"value,omitempty"`
 }
 
 // OutputDomainFile ...
@@ -57,13 +186,22 @@ type OutputDomainFile struct {
 	Actuator *OutputMinMax `json:"actuators,omitempty"`
 }
 
+// OutputDataChecksums contains the SHA-256 checksums of the data files
+type OutputDataChecksums struct {
+	Global *string `json:"global,omitempty"`
+	W      *string `json:"world,omitempty"`
+	S      *string `json:"sensors,omitempty"`
+	A      *string `json:"actuators,omitempty"`
+}
+
 // OutputDataFile ...
 type OutputDataFile struct {
-	Global *string           `json:"global,omitempty"`
-	W      *string           `json:"world,omitempty"`
-	S      *string           `json:"sensors,omitempty"`
-	A      *string           `json:"actuators,omitempty"`
-	Domain *OutputDomainFile `json:"domain,omitempty"`
+	Global    *string              `json:"global,omitempty"`
+	W         *string              `json:"world,omitempty"`
+	S         *string              `json:"sensors,omitempty"`
+	A         *string              `json:"actuators,omitempty"`
+	Domain    *OutputDomainFile    `json:"domain,omitempty"`
+	Checksums *OutputDataChecksums `json:"sha256,omitempty"`
 }
 
 // OutputDataIndices ...
@@ -93,7 +231,9 @@ type Output struct {
 	Data    *OutputData    `json:"data,omitempty"`
 
 	W2W1A1   *OutputDataRawNormalised `json:"w2w1a1,omitempty"`
+	W2W1S1   *OutputDataRawNormalised `json:"w2w1s1,omitempty"`
 	W2W1S1A1 *OutputDataRawNormalised `json:"w2w1s1a1,omitempty"`
+	A1S1     *OutputDataRawNormalised `json:"a1s1,omitempty"`
 }
 
 // CreateW2W1A1 ...
@@ -116,6 +256,46 @@ func (o *Output) SetW2W1A1Normalised(data [][]float64) {
 	o.W2W1A1.Normalised = &data
 }
 
+// CreateA1S1 ...
+func (o *Output) CreateA1S1() {
+	if o.A1S1 == nil {
+		var r OutputDataRawNormalised
+		o.A1S1 = &r
+	}
+}
+
+// SetA1S1Raw ...
+func (o *Output) SetA1S1Raw(data [][]float64) {
+	o.CreateA1S1()
+	o.A1S1.Raw = &data
+}
+
+// SetA1S1Normalised ...
+func (o *Output) SetA1S1Normalised(data [][]float64) {
+	o.CreateA1S1()
+	o.A1S1.Normalised = &data
+}
+
+// CreateW2W1S1 ...
+func (o *Output) CreateW2W1S1() {
+	if o.W2W1S1 == nil {
+		var r OutputDataRawNormalised
+		o.W2W1S1 = &r
+	}
+}
+
+// SetW2W1S1Raw ...
+func (o *Output) SetW2W1S1Raw(data [][]float64) {
+	o.CreateW2W1S1()
+	o.W2W1S1.Raw = &data
+}
+
+// SetW2W1S1Normalised ...
+func (o *Output) SetW2W1S1Normalised(data [][]float64) {
+	o.CreateW2W1S1()
+	o.W2W1S1.Normalised = &data
+}
+
 // CreateW2W1S1A1 ...
 func (o *Output) CreateW2W1S1A1() {
 	if o.W2W1S1A1 == nil {
@@ -136,6 +316,17 @@ func (o *Output) SetW2W1S1A1Normalised(data [][]float64) {
 	o.W2W1S1A1.Normalised = &data
 }
 
+// ImportJSON reads an Output that was written by ExportJSON
+func ImportJSON(filename string) (Output, error) {
+	var o Output
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return o, err
+	}
+	err = json.Unmarshal(bytes, &o)
+	return o, err
+}
+
 // ExportJSON exports to JSON
 func (o Output) ExportJSON(filename string) {
 	bytes, _ := json.MarshalIndent(o, "", " ")
@@ -167,6 +358,91 @@ func (o *Output) SetPointWiseResult(r []float64) {
 	o.Result.PointWise = &r
 }
 
+// SetSummaryResult sets the summary statistics of the point-wise values. It
+// is omitted, if there are no finite values.
+func (o *Output) SetSummaryResult(s Summary) {
+	if s.N == 0 {
+		return
+	}
+	o.CreateResults()
+	quantiles := make([]OutputQuantile, len(s.Quantiles), len(s.Quantiles))
+	for i := range s.Quantiles {
+		quantiles[i] = OutputQuantile{Level: &QuantileLevels[i], Value: &s.Quantiles[i]}
+	}
+	o.Result.Summary = &OutputSummary{N: &s.N,
+		NonFinite:        &s.NonFinite,
+		Mean:             &s.Mean,
+		SD:               &s.SD,
+		Min:              &s.Min,
+		Max:              &s.Max,
+		Median:           &s.Median,
+		Quantiles:        &quantiles,
+		FractionNegative: &s.FractionNegative,
+		Skewness:         &s.Skewness,
+		Kurtosis:         &s.Kurtosis,
+		Histogram:        &OutputHistogram{Edges: &s.Edges, Counts: &s.Counts}}
+}
+
+// SetNormalisedResult sets the averaged value divided by the reference. It is
+// omitted, if no reference is selected or the reference is not positive.
+func (o *Output) SetNormalisedResult(p Parameters, r float64) {
+	if p.useReference() == false || p.ReferenceValue <= 0.0 {
+		return
+	}
+	o.CreateResults()
+	n := p.normalise(r)
+	o.Result.Normalised = &n
+}
+
+// SetUnit sets the unit of the result
+func (o *Output) SetUnit(unit string) {
+	o.CreateResults()
+	o.Result.Unit = &unit
+}
+
+// SetReference sets the reference and its constituent terms, if a reference
+// is selected
+func (o *Output) SetReference(p Parameters) {
+	if p.useReference() == false {
+		return
+	}
+	o.CreateResults()
+	terms := make([]OutputReferenceTerm, len(p.ReferenceTerms), len(p.ReferenceTerms))
+	for i := range p.ReferenceTerms {
+		terms[i] = OutputReferenceTerm{Term: &p.ReferenceTerms[i].Term, Value: &p.ReferenceTerms[i].Value}
+	}
+	o.Result.Reference = &OutputReference{Name: &p.Reference, Value: &p.ReferenceValue, Terms: &terms}
+}
+
+// SetPIDResult sets the atoms of the partial information decomposition
+func (o *Output) SetPIDResult(redundancy string, atoms pid.Atoms) {
+	o.CreateResults()
+	o.Result.PID = &OutputPID{Redundancy: &redundancy,
+		Redundant:   &atoms.Redundant,
+		UniqueW:     &atoms.Unique1,
+		UniqueA:     &atoms.Unique2,
+		Synergistic: &atoms.Synergistic}
+}
+
+// SetPID3Result sets the atoms of the partial information decomposition of
+// three sources
+func (o *Output) SetPID3Result(atoms []pid.Atom) {
+	o.CreateResults()
+	r := make([]OutputAtom, len(atoms), len(atoms))
+	for i := range atoms {
+		name := atoms[i].Name([3]string{"W", "S", "A"})
+		r[i] = OutputAtom{Name: &name, Value: &atoms[i].Value}
+	}
+	o.Result.PID3 = &r
+}
+
+// SetHierarchyResult sets the contributions of the interaction orders and
+// their synergy as the averaged value
+func (o *Output) SetHierarchyResult(h discrete.Hierarchy) {
+	o.SetAvgResult(h.Synergy())
+	o.Result.Hierarchy = &OutputHierarchy{Individual: &h.Individual, Pairwise: &h.Pairwise, Triple: &h.Triple}
+}
+
 // CreateMeasure ...
 func (o *Output) CreateMeasure() {
 	if o.Measure == nil {
@@ -175,12 +451,33 @@ func (o *Output) CreateMeasure() {
 	}
 }
 
+// SetTransfer sets the source, target, and history lengths of TE and AIS.
+// The source is omitted for AIS.
+func (o *Output) SetTransfer(p Parameters) {
+	if p.MeasureName != "TE" && p.MeasureName != "AIS" {
+		return
+	}
+	o.CreateMeasure()
+	t := OutputTransfer{Target: &p.Target, History: &p.History}
+	if p.MeasureName == "TE" {
+		t.Source = &p.Source
+		t.SourceHistory = &p.SourceHistory
+	}
+	o.Measure.Transfer = &t
+}
+
 // SetName sets the name
 func (o *Output) SetName(name string) {
 	o.CreateMeasure()
 	o.Measure.Name = &name
 }
 
+// SetSeed sets the seed of the random number source
+func (o *Output) SetSeed(seed int64) {
+	o.CreateMeasure()
+	o.Measure.Seed = &seed
+}
+
 // SetUseContinuous sets the name
 func (o *Output) SetUseContinuous(b bool) {
 	o.CreateMeasure()
@@ -245,6 +542,12 @@ func (o *Output) SetGlobalBins(bins int) {
 	o.Measure.Discrete.Bins.Global = &bins
 }
 
+// SetUseSparseMatrix ...
+func (o *Output) SetUseSparseMatrix(b bool) {
+	o.CreateMeasureDiscrete()
+	o.Measure.Discrete.UseSparseMatrix = &b
+}
+
 // CreateMeasureDiscrete ...
 func (o *Output) CreateMeasureDiscrete() {
 	o.CreateMeasure()
@@ -263,13 +566,69 @@ func (o *Output) SetIterations(iterations int) {
 	o.Measure.Discrete.Iterations = &iterations
 }
 
+// SetConvergence ...
+func (o *Output) SetConvergence(c discrete.Convergence) {
+	o.CreateMeasureDiscrete()
+	r := OutputConvergence{Criterion: &c.Criterion,
+		Tolerance:  &c.Tolerance,
+		Achieved:   &c.Achieved,
+		Iterations: &c.Iterations,
+		Converged:  &c.Converged}
+	if len(c.Trace) > 0 {
+		r.Trace = &c.Trace
+	}
+	o.Measure.Discrete.Convergence = &r
+}
+
+// convergenceString returns a description of the convergence of the
+// iterative scaling, or an empty string if none was used
+func (o Output) convergenceString(prefix string) string {
+	if o.Measure == nil || o.Measure.Discrete == nil || o.Measure.Discrete.Convergence == nil {
+		return ""
+	}
+	c := o.Measure.Discrete.Convergence
+	return fmt.Sprintf("%sConverged:                 %t\n%sIterations performed:      %d\n%sAchieved tolerance:        %g",
+		prefix, *c.Converged, prefix, *c.Iterations, prefix, *c.Achieved)
+}
+
+// SetNormaliser ...
+func (o *Output) SetNormaliser(term string, value float64) {
+	o.CreateContinuous()
+	o.Measure.Continuous.Normaliser = &OutputNormaliser{Term: &term, Value: &value}
+}
+
+// convertNormaliser converts the normaliser from nats, the unit of the
+// continuous estimators, to the given unit
+func (o *Output) convertNormaliser(unit string) {
+	if o.Measure == nil || o.Measure.Continuous == nil || o.Measure.Continuous.Normaliser == nil || o.Measure.Continuous.Normaliser.Value == nil {
+		return
+	}
+	v := ConvertUnit(*o.Measure.Continuous.Normaliser.Value, UnitNats, unit)
+	o.Measure.Continuous.Normaliser.Value = &v
+}
+
+// SetPartition ...
+func (o *Output) SetPartition(bins int) {
+	o.CreateContinuous()
+	o.Measure.Continuous.Partition = &bins
+}
+
+// normaliserString returns a description of the normaliser, or an empty
+// string if none was used
+func (o Output) normaliserString(prefix string) string {
+	if o.Measure == nil || o.Measure.Continuous == nil || o.Measure.Continuous.Normaliser == nil {
+		return ""
+	}
+	n := o.Measure.Continuous.Normaliser
+	return fmt.Sprintf("%sNormaliser:                %s = %f", prefix, *n.Term, *n.Value)
+}
+
 // SetNormalisation ...
 func (o *Output) SetNormalisation(min, max []float64) {
 	if len(min) == 0 && len(max) == 0 {
-		fmt.Println("end end")
 		return
 	}
-	o.CreateMinMax()
+	o.CreateNormalisation()
 	if len(min) > 0 {
 		o.Measure.Continuous.Normalisation.Min = &min
 	}
@@ -278,11 +637,39 @@ func (o *Output) SetNormalisation(min, max []float64) {
 	}
 }
 
-// CreateMinMax ...
-func (o *Output) CreateMinMax() {
+// SetNormalisationMode sets the requested and the applied normalisation
+func (o *Output) SetNormalisationMode(mode, applied string) {
+	o.CreateNormalisation()
+	o.Measure.Continuous.Normalisation.Mode = &mode
+	if applied != "" {
+		o.Measure.Continuous.Normalisation.Applied = &applied
+	}
+}
+
+// SetNormalisationMeanSD ...
+func (o *Output) SetNormalisationMeanSD(mean, sd []float64) {
+	if len(mean) == 0 && len(sd) == 0 {
+		return
+	}
+	o.CreateNormalisation()
+	o.Measure.Continuous.Normalisation.Mean = &mean
+	o.Measure.Continuous.Normalisation.SD = &sd
+}
+
+// SetNoise sets the amplitude of the tie-breaking noise
+func (o *Output) SetNoise(noise float64) {
+	if noise == 0.0 {
+		return
+	}
+	o.CreateNormalisation()
+	o.Measure.Continuous.Normalisation.Noise = &noise
+}
+
+// CreateNormalisation ...
+func (o *Output) CreateNormalisation() {
 	o.CreateContinuous()
 	if o.Measure.Continuous.Normalisation == nil {
-		var n OutputMinMax
+		var n OutputNormalisation
 		o.Measure.Continuous.Normalisation = &n
 	}
 }
@@ -296,6 +683,15 @@ func (o *Output) CreateContinuous() {
 	}
 }
 
+// SetCategorical sets the variables that were treated as categorical
+func (o *Output) SetCategorical(variables []string) {
+	if len(variables) == 0 {
+		return
+	}
+	o.CreateContinuous()
+	o.Measure.Continuous.Categorical = &variables
+}
+
 // SetK ...
 func (o *Output) SetK(k int) {
 	if k == 0 {
@@ -347,6 +743,28 @@ func (o *Output) SetSFile(name string) {
 	o.Data.File.S = &name
 }
 
+// CreateChecksums ...
+func (o *Output) CreateChecksums() {
+	o.CreateDataFile()
+	if o.Data.File.Checksums == nil {
+		c := OutputDataChecksums{}
+		o.Data.File.Checksums = &c
+	}
+}
+
+// SetChecksums stores the SHA-256 checksums of all data files, such that
+// a later rerun can detect if the input data has changed
+func (o *Output) SetChecksums(p Parameters) {
+	if p.GlobalFile == "" && p.WFile == "" && p.SFile == "" && p.AFile == "" {
+		return
+	}
+	o.CreateChecksums()
+	o.Data.File.Checksums.Global = checksumOrNil(p.GlobalFile)
+	o.Data.File.Checksums.W = checksumOrNil(p.WFile)
+	o.Data.File.Checksums.S = checksumOrNil(p.SFile)
+	o.Data.File.Checksums.A = checksumOrNil(p.AFile)
+}
+
 // CreateData ...
 func (o *Output) CreateData() {
 	if o.Data == nil {
@@ -487,10 +905,16 @@ func (o *Output) SetDomainName(name string) {
 	o.Data.File.Domain.Name = &name
 }
 
-// SetDate ...
+// SetDate sets the current date. If the environment variable
+// SOURCE_DATE_EPOCH is set (seconds since 1970-01-01 UTC), its value is used
+// instead, so that identical computations produce identical files.
 func (o *Output) SetDate() {
 	o.CreateMeasure()
-	s := time.Now().Format("2006-01-02 15:04:05")
+	t := time.Now()
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		t = time.Unix(epoch, 0).UTC()
+	}
+	s := t.Format("2006-01-02 15:04:05")
 	o.Date = &s
 }
 
@@ -498,15 +922,23 @@ func (o *Output) SetDate() {
 func (o *Output) SetParameters(p Parameters) {
 
 	o.SetName(p.MeasureName)
+	o.SetSeed(p.Seed)
+	o.SetTransfer(p)
+	o.SetReference(p)
+	o.SetUnit(p.Unit)
 	o.SetABins(p.ABins)
 	o.SetWBins(p.WBins)
 	o.SetSBins(p.SBins)
 	o.SetGlobalBins(p.GlobalBins)
 	o.SetIterations(p.Iterations)
-	o.SetNormalisation(p.NormalisationMin, p.NormalisationMax)
 	if p.UseContinuous {
 		o.SetK(p.K)
 		o.SetContinuousMode(p.ContinuousMode)
+		o.SetNormalisationMode(p.Normalisation, p.NormalisationApplied)
+		o.SetNormalisation(p.NormalisationMin, p.NormalisationMax)
+		o.SetNormalisationMeanSD(p.NormalisationMean, p.NormalisationSD)
+		o.SetNoise(p.Noise)
+		o.SetCategorical(p.Categorical)
 	}
 
 	o.SetUseContinuous(p.UseContinuous)
@@ -524,5 +956,8 @@ func (o *Output) SetParameters(p Parameters) {
 	o.SetDomainAMinMax(p.ActuatorMin, p.ActuatorMax)
 	o.SetDomainSMinMax(p.SensorMin, p.SensorMax)
 	o.SetDomainName(p.DFile)
-
+	o.SetChecksums(p)
+	if p.UseContinuous == false {
+		o.SetUseSparseMatrix(p.UseSparseMatrix)
+	}
 }
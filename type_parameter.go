@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 	"os"
 
+	"github.com/kzahedi/gomi/discrete"
+	"github.com/kzahedi/gomi/random"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -18,10 +20,18 @@ type Parameters struct {
 	Verbose           bool
 	LogData           bool
 	UseSparseMatrix   bool
+	Storage           string
+	MemoryBudget      int64
 	ContinuousMode    int
 	K                 int
+	Normalisation     string
+	Noise             float64
+	Seed              int64
 	GlobalBins        int
 	Iterations        int
+	Tolerance         float64
+	Criterion         string
+	Trace             bool
 	WBins             []int
 	SBins             []int
 	ABins             []int
@@ -29,6 +39,15 @@ type Parameters struct {
 	WIndices          []int
 	SIndices          []int
 	AIndices          []int
+	TimeIndex         int
+	WriteValues       bool
+	Source            string
+	Target            string
+	History           int
+	SourceHistory     int
+	Redundancy        string
+	Reference         string
+	Unit              string
 	WFile             string
 	SFile             string
 	AFile             string
@@ -41,6 +60,16 @@ type Parameters struct {
 	ActuatorMax       []float64
 	NormalisationMin  []float64
 	NormalisationMax  []float64
+	// Categorical lists the variables (W, S, A) with categorical columns
+	Categorical []string
+	// NormalisationApplied, NormalisationMean and NormalisationSD are set
+	// by NormaliseContinuous
+	NormalisationApplied string
+	NormalisationMean    []float64
+	NormalisationSD      []float64
+	// ReferenceValue and ReferenceTerms are set by computeReference
+	ReferenceValue float64
+	ReferenceTerms []ReferenceTerm
 }
 
 // GenerateString ...
@@ -51,12 +80,28 @@ func (p Parameters) GenerateString(prefix string) string {
 	s = fmt.Sprintf("%s\n%sUse state-dependent:       %t", s, prefix, p.UseStateDependent)
 	s = fmt.Sprintf("%s\n%sUse continuous:            %t", s, prefix, p.UseContinuous)
 	s = fmt.Sprintf("%s\n%sUse sparse matrix:         %t", s, prefix, p.UseSparseMatrix)
+	s = fmt.Sprintf("%s\n%sStorage:                   %s", s, prefix, p.Storage)
+	s = fmt.Sprintf("%s\n%sMemory budget:             %s", s, prefix, formatByteSize(p.MemoryBudget))
 	s = fmt.Sprintf("%s\n%sVerbose:                   %t", s, prefix, p.Verbose)
 	s = fmt.Sprintf("%s\n%sLog converted data:        %t", s, prefix, p.LogData)
 	s = fmt.Sprintf("%s\n%sContinuous Mode:           %d", s, prefix, p.ContinuousMode)
 	s = fmt.Sprintf("%s\n%sk:                         %d", s, prefix, p.K)
+	s = fmt.Sprintf("%s\n%sNormalisation:             %s", s, prefix, p.Normalisation)
+	s = fmt.Sprintf("%s\n%sNoise:                     %g", s, prefix, p.Noise)
+	s = fmt.Sprintf("%s\n%sCategorical:               %v", s, prefix, p.Categorical)
+	s = fmt.Sprintf("%s\n%sSeed:                      %d", s, prefix, p.Seed)
+	s = fmt.Sprintf("%s\n%sSource:                    %s", s, prefix, p.Source)
+	s = fmt.Sprintf("%s\n%sTarget:                    %s", s, prefix, p.Target)
+	s = fmt.Sprintf("%s\n%sHistory:                   %d", s, prefix, p.History)
+	s = fmt.Sprintf("%s\n%sSource history:            %d", s, prefix, p.SourceHistory)
+	s = fmt.Sprintf("%s\n%sRedundancy:                %s", s, prefix, p.Redundancy)
+	s = fmt.Sprintf("%s\n%sReference:                 %s", s, prefix, p.Reference)
+	s = fmt.Sprintf("%s\n%sUnit:                      %s", s, prefix, p.Unit)
 	s = fmt.Sprintf("%s\n%sBins:                      %d", s, prefix, p.GlobalBins)
 	s = fmt.Sprintf("%s\n%sIterations:                %d", s, prefix, p.Iterations)
+	s = fmt.Sprintf("%s\n%sTolerance:                 %g", s, prefix, p.Tolerance)
+	s = fmt.Sprintf("%s\n%sConvergence criterion:     %s", s, prefix, p.Criterion)
+	s = fmt.Sprintf("%s\n%sConvergence trace:         %t", s, prefix, p.Trace)
 	s = fmt.Sprintf("%s\n%sW bins:                    %v", s, prefix, p.WBins)
 	s = fmt.Sprintf("%s\n%sS bins:                    %v", s, prefix, p.SBins)
 	s = fmt.Sprintf("%s\n%sA bins:                    %v", s, prefix, p.ABins)
@@ -64,6 +109,8 @@ func (p Parameters) GenerateString(prefix string) string {
 	s = fmt.Sprintf("%s\n%sW indices:                 %v", s, prefix, p.WIndices)
 	s = fmt.Sprintf("%s\n%sS indices:                 %v", s, prefix, p.SIndices)
 	s = fmt.Sprintf("%s\n%sA indices:                 %v", s, prefix, p.AIndices)
+	s = fmt.Sprintf("%s\n%sTime index:                %d", s, prefix, p.TimeIndex)
+	s = fmt.Sprintf("%s\n%sWrite values:              %t", s, prefix, p.WriteValues)
 	s = fmt.Sprintf("%s\n%sW data set:                %s", s, prefix, p.WFile)
 	s = fmt.Sprintf("%s\n%sS data set:                %s", s, prefix, p.SFile)
 	s = fmt.Sprintf("%s\n%sA data set:                %s", s, prefix, p.AFile)
@@ -88,12 +135,19 @@ func CreateParametersContainer() Parameters {
 	return Parameters{MeasureName: defaultMeasure,
 		UseContinuous:     defaultUseContinuous,
 		UseStateDependent: defaultUseStateDependent,
-		UseSparseMatrix:   false,
+		UseSparseMatrix:   defaultUseSparse,
+		Storage:           defaultStorage,
+		MemoryBudget:      defaultMemoryBudget,
 		Verbose:           false,
 		LogData:           false,
 		K:                 defaultK,
+		Normalisation:     defaultNormalisation,
+		Noise:             defaultNoise,
+		Seed:              defaultSeed,
 		GlobalBins:        defaultBins,
 		Iterations:        defaultIterations,
+		Tolerance:         defaultTolerance,
+		Criterion:         defaultCriterion,
 		ContinuousMode:    defaultContinuousMode,
 		WBins:             []int{},
 		SBins:             []int{},
@@ -101,6 +155,14 @@ func CreateParametersContainer() Parameters {
 		WIndices:          []int{},
 		SIndices:          []int{},
 		AIndices:          []int{},
+		TimeIndex:         defaultTimeIndex,
+		Source:            defaultSource,
+		Target:            defaultTarget,
+		History:           defaultHistory,
+		SourceHistory:     defaultHistory,
+		Redundancy:        defaultRedundancy,
+		Reference:         defaultReference,
+		Unit:              defaultUnit,
 		WorldMin:          []float64{},
 		WorldMax:          []float64{},
 		SensorMin:         []float64{},
@@ -112,112 +174,87 @@ func CreateParametersContainer() Parameters {
 		GlobalFile:        defaultFile,
 		WFile:             defaultWFile,
 		SFile:             defaultSFile,
-		AFile:             defaultAFile}
+		AFile:             defaultAFile,
+		DFile:             defaultDFile}
 }
 
-// SetMeasureName set the name of the measure to use, if
+// SetMeasureName sets the name of the measure to use
 func (p *Parameters) SetMeasureName(name string) {
-	if name != defaultMeasure {
-		p.MeasureName = name
-	}
+	p.MeasureName = name
 }
 
 // SetUseStateDependent ...
 func (p *Parameters) SetUseStateDependent(b bool) {
-	if b != defaultUseStateDependent {
-		p.UseStateDependent = b
-	}
+	p.UseStateDependent = b
 }
 
 // SetUseContinuous ...
 func (p *Parameters) SetUseContinuous(b bool) {
-	if b != defaultUseContinuous {
-		p.UseContinuous = b
-	}
+	p.UseContinuous = b
 }
 
-// SetUseSparseMatrix ...
+// SetUseSparseMatrix selects sparse (true) or dense (false) storage
 func (p *Parameters) SetUseSparseMatrix(b bool) {
-	if b != defaultUseSparse {
-		p.UseSparseMatrix = b
+	if b {
+		p.SetStorage(StorageSparse)
+	} else {
+		p.SetStorage(StorageDense)
 	}
 }
 
 // SetWBins ...
 func (p *Parameters) SetWBins(wBins string) {
-	if wBins != "" {
-		p.WBins = parseIntString(wBins)
-	}
+	p.WBins = parseIntString(wBins)
 }
 
 // SetSBins ...
 func (p *Parameters) SetSBins(sBins string) {
-	if sBins != "" {
-		p.SBins = parseIntString(sBins)
-	}
+	p.SBins = parseIntString(sBins)
 }
 
 // SetABins ...
 func (p *Parameters) SetABins(aBins string) {
-	if aBins != "" {
-		p.ABins = parseIntString(aBins)
-	}
+	p.ABins = parseIntString(aBins)
 }
 
 // SetWIndices ...
 func (p *Parameters) SetWIndices(wIndices string) {
-	if wIndices != "" {
-		p.WIndices = parseIntString(wIndices)
-	}
+	p.WIndices = parseIntString(wIndices)
 }
 
 // SetSIndices ...
-func (p *Parameters) SetSIndices(wIndices string) {
-	if wIndices != "" {
-		p.SIndices = parseIntString(wIndices)
-	}
+func (p *Parameters) SetSIndices(sIndices string) {
+	p.SIndices = parseIntString(sIndices)
 }
 
 // SetAIndices ...
-func (p *Parameters) SetAIndices(wIndices string) {
-	if wIndices != "" {
-		p.AIndices = parseIntString(wIndices)
-	}
+func (p *Parameters) SetAIndices(aIndices string) {
+	p.AIndices = parseIntString(aIndices)
 }
 
 // SetGlobalBins ...
 func (p *Parameters) SetGlobalBins(bins int) {
-	if bins != defaultBins {
-		p.GlobalBins = bins
-	}
+	p.GlobalBins = bins
 }
 
 // SetGlobalFile ...
 func (p *Parameters) SetGlobalFile(file string) {
-	if file != defaultFile {
-		p.GlobalFile = file
-	}
+	p.GlobalFile = file
 }
 
 // SetWFile ...
 func (p *Parameters) SetWFile(file string) {
-	if file != defaultWFile {
-		p.WFile = file
-	}
+	p.WFile = file
 }
 
 // SetSFile ...
 func (p *Parameters) SetSFile(file string) {
-	if file != defaultSFile {
-		p.SFile = file
-	}
+	p.SFile = file
 }
 
 // SetAFile ...
 func (p *Parameters) SetAFile(file string) {
-	if file != defaultFile {
-		p.AFile = file
-	}
+	p.AFile = file
 }
 
 // SetLogData ...
@@ -227,9 +264,7 @@ func (p *Parameters) SetLogData(log bool) {
 
 // SetIterations ...
 func (p *Parameters) SetIterations(iterations int) {
-	if iterations != defaultIterations {
-		p.Iterations = iterations
-	}
+	p.Iterations = iterations
 }
 
 type domainCfg struct {
@@ -241,23 +276,23 @@ type domainCfg struct {
 	ActuatorMax []float64 `yaml:"A max"`
 }
 
-// SetDFile ...
-func (p *Parameters) SetDFile(file string) {
+// SetDFile reads the domains of W, S, and A from the given yaml file
+func (p *Parameters) SetDFile(file string) error {
 	p.DFile = file
 	if p.DFile == "" {
-		return
+		return nil
 	}
 
 	t := domainCfg{}
 
 	data, err := ioutil.ReadFile(p.DFile)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	err = yaml.Unmarshal([]byte(data), &t)
+	err = yaml.UnmarshalStrict([]byte(data), &t)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("domain file %s: %s", p.DFile, err)
 	}
 
 	p.WorldMin = t.WorldMin
@@ -268,6 +303,8 @@ func (p *Parameters) SetDFile(file string) {
 
 	p.ActuatorMin = t.ActuatorMin
 	p.ActuatorMax = t.ActuatorMax
+
+	return nil
 }
 
 // SetWMinMax ...
@@ -308,71 +345,18 @@ func (p *Parameters) SetContinuousMode(cm int) {
 	p.ContinuousMode = cm
 }
 
-// CfgT ...
-type CfgT struct {
-	Measure        string `yaml:"Measure"`
-	Continuous     bool   `yaml:"Continuous"`
-	ContinuousMode int    `yaml:"Continuous mode"`
-	UseState       bool   `yaml:"State-dependent"`
-	Verbose        bool   `yaml:"Verbose"`
-	Bins           int    `yaml:"Bins"`
-	Iterations     int    `yaml:"Iterations"`
-	K              int    `yaml:"k"`
-	Output         string `yaml:"Output file"`
-	WBins          string `yaml:"W Bins"`
-	ABins          string `yaml:"A Bins"`
-	SBins          string `yaml:"S Bins"`
-	WIndices       string `yaml:"W Indices"`
-	AIndices       string `yaml:"A Indices"`
-	SIndices       string `yaml:"S Indices"`
-	File           string `yaml:"Full data file"`
-	WFile          string `yaml:"W data file"`
-	AFile          string `yaml:"A data file"`
-	SFile          string `yaml:"S data file"`
-	DFile          string `yaml:"Domain file"`
-}
-
-// SetConfigFile ..
-func (p *Parameters) SetConfigFile(file string) {
-	p.ConfigFile = file
-	if p.ConfigFile == "" {
-		return
-	}
-
-	t := CfgT{}
-
-	data, err := ioutil.ReadFile(p.ConfigFile)
-	if err != nil {
-		panic(err)
-	}
-
-	err = yaml.Unmarshal([]byte(data), &t)
-	if err != nil {
-		panic(err)
-	}
+// Random returns the random number source of the computation. All
+// components that need random numbers draw them from streams of this source.
+func (p Parameters) Random() random.Source {
+	return random.New(p.Seed)
+}
 
-	p.SetMeasureName(t.Measure)
-	p.SetUseContinuous(t.Continuous)
-	p.SetContinuousMode(t.ContinuousMode)
-	p.SetUseStateDependent(t.UseState)
-	p.SetGlobalBins(t.Bins)
-	p.SetWBins(t.WBins)
-	p.SetSBins(t.SBins)
-	p.SetABins(t.ABins)
-	p.SetK(t.K)
-	p.SetOutput(t.Output)
-	p.SetVerbose(t.Verbose)
-	p.SetGlobalFile(t.File)
-	p.SetWIndices(t.WIndices)
-	p.SetSIndices(t.SIndices)
-	p.SetAIndices(t.AIndices)
-	p.SetWFile(t.WFile)
-	p.SetSFile(t.SFile)
-	p.SetAFile(t.AFile)
-	p.SetDFile(t.DFile)
-	p.SetIterations(t.Iterations)
-
-	p.Verbose = true
+// stopping returns the stopping rule of the iterative scaling
+func (p Parameters) stopping() discrete.Stopping {
+	return discrete.Stopping{MaxIterations: p.Iterations,
+		Tolerance: p.Tolerance,
+		Criterion: p.Criterion,
+		Trace:     p.Trace}
 }
 
 // checkFile returns true, if the file exists and false otherwise
@@ -386,38 +370,11 @@ func checkFile(filename string) bool {
 	return true
 }
 
-// CheckParameters checks for the sanity of the command line parameters
+// CheckParameters checks for the sanity of the parameters (see Validate)
+// and exits with a list of all problems if any are found
 func (p *Parameters) CheckParameters() {
-	errorMsg := ""
-	hasError := false
-	var foundFile bool
-
-	foundFile = checkFile(p.GlobalFile)
-	hasError = foundFile && hasError
-	if foundFile == false {
-		errorMsg = fmt.Sprintf("Global file %s not found\n", p.GlobalFile)
-	}
-
-	foundFile = checkFile(p.WFile)
-	hasError = foundFile && hasError
-	if foundFile == true {
-		errorMsg = fmt.Sprintf("%sWorld file %s not found\n", errorMsg, p.WFile)
-	}
-
-	foundFile = checkFile(p.SFile)
-	hasError = foundFile && hasError
-	if foundFile == true {
-		errorMsg = fmt.Sprintf("%sSensor file %s not found\n", errorMsg, p.SFile)
-	}
-
-	foundFile = checkFile(p.AFile)
-	hasError = foundFile && hasError
-	if foundFile == true {
-		errorMsg = fmt.Sprintf("%sActuator file %s not found\n", errorMsg, p.AFile)
-	}
-
-	if hasError == true {
-		fmt.Println(errorMsg)
+	if err := p.Validate(); err != nil {
+		fmt.Println(err)
 		os.Exit(-1)
 	}
 }
@@ -0,0 +1,73 @@
+package gomi
+
+import "math"
+
+// Units of information (see Parameters.Unit)
+const (
+	// UnitBits uses the logarithm to base 2
+	UnitBits = "bits"
+	// UnitNats uses the natural logarithm
+	UnitNats = "nats"
+	// UnitBans uses the logarithm to base 10 (also called hartleys)
+	UnitBans = "bans"
+)
+
+// Units lists all units of information
+var Units = []string{UnitBits, UnitNats, UnitBans}
+
+func isKnownUnit(unit string) bool {
+	for _, u := range Units {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// unitLog returns the natural logarithm of the base of the unit
+func unitLog(unit string) float64 {
+	switch unit {
+	case UnitNats:
+		return 1.0
+	case UnitBans:
+		return math.Log(10.0)
+	}
+	return math.Log(2.0)
+}
+
+// ConvertUnit converts an amount of information from one unit to another
+func ConvertUnit(v float64, from, to string) float64 {
+	if from == to {
+		return v
+	}
+	return v * unitLog(from) / unitLog(to)
+}
+
+// nativeUnit returns the unit in which the estimators return the results of
+// the measure. The discrete estimators use bits, the continuous estimators
+// nats. CA and CW are calculated on a discrete partition of continuous data
+// (see MakePS2S1A1Partition) and therefore also use bits.
+func (p Parameters) nativeUnit() string {
+	if p.UseContinuous == false || p.MeasureName == "CA" || p.MeasureName == "CW" {
+		return UnitBits
+	}
+	return UnitNats
+}
+
+// inUnit converts a result of the measure from its native unit to p.Unit.
+// Results of dimensionless measures (e.g. MI_A_Prime) are not changed.
+func (p Parameters) inUnit(v float64) float64 {
+	if spec, _ := p.lookupMeasure(); spec.dimensionless {
+		return v
+	}
+	return ConvertUnit(v, p.nativeUnit(), p.Unit)
+}
+
+// inUnits converts all values with inUnit
+func (p Parameters) inUnits(values []float64) []float64 {
+	r := make([]float64, len(values), len(values))
+	for i, v := range values {
+		r[i] = p.inUnit(v)
+	}
+	return r
+}
@@ -0,0 +1,33 @@
+package gomi
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInUnit(t *testing.T) {
+	tests := []struct {
+		measure    string
+		continuous bool
+		unit       string
+		want       float64
+	}{
+		{"MI_W", false, UnitBits, 1.0},
+		{"MI_W", false, UnitNats, math.Log(2.0)},
+		{"MI_W", false, UnitBans, math.Log10(2.0)},
+		{"MI_W", true, UnitBits, 1.0 / math.Log(2.0)},
+		{"MI_W", true, UnitNats, 1.0},
+		{"CW", true, UnitNats, math.Log(2.0)},
+		{"MI_A_Prime", false, UnitNats, 1.0},
+		{"CA", true, UnitBans, 1.0},
+	}
+	for _, tt := range tests {
+		p := CreateParametersContainer()
+		p.MeasureName = tt.measure
+		p.UseContinuous = tt.continuous
+		p.Unit = tt.unit
+		if got := p.inUnit(1.0); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("inUnit(1) of %s (continuous %t) in %s = %v, want %v", tt.measure, tt.continuous, tt.unit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package gomi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kzahedi/gomi/progress"
+	"github.com/kzahedi/gomi/simulate"
+)
+
+// Benchmarks of ValidateEstimators
+const (
+	// BenchmarkGaussian is a linear-Gaussian loop (correlated Gaussians with
+	// known conditional mutual information)
+	BenchmarkGaussian = "gaussian"
+	// BenchmarkBinary is the binary loop of Zahedi & Ay (2013) with a
+	// synergistic coupling of W and A (a discrete channel with known synergy)
+	BenchmarkBinary = "binary"
+)
+
+// Benchmarks lists all benchmarks of ValidateEstimators
+var Benchmarks = []string{BenchmarkGaussian, BenchmarkBinary}
+
+// models of the benchmarks
+var (
+	benchmarkGaussian = simulate.Gaussian{Phi: 0.4, Psi: 0.4, Zeta: 1.0, Mu: 0.5, Sigma: 1.0}
+	benchmarkBinary   = simulate.Binary{Phi: 0.5, Psi: 0.5, Chi: 1.0, Zeta: 1.0, Mu: 1.0}
+)
+
+func isKnownBenchmark(name string) bool {
+	for _, b := range Benchmarks {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationResult summarises the estimates of one measure on one benchmark
+//    Bias     = mean - analytic
+//    Variance = 1/(n-1) sum_i (estimate_i - mean)^2
+//    RMSE     = sqrt(1/n sum_i (estimate_i - analytic)^2)
+type ValidationResult struct {
+	Benchmark string
+	Measure   string
+	Analytic  float64
+	Estimates []float64
+	Mean      float64
+	Bias      float64
+	Variance  float64
+	RMSE      float64
+}
+
+// ValidateEstimators estimates each measure with the estimator that is
+// selected in p (bins, k, continuous mode, etc.) on runs independent samples
+// of each benchmark and compares the estimates with the analytic values.
+// Each sample has the given number of rows and dims columns per variable,
+// which are independent copies of the benchmark loop, so that the analytic
+// values of most measures are dims times those of one loop. The samples are
+// drawn from the stream "validate <benchmark>" of p.Random() and are
+// evaluated exactly like data files (see Calculate). Pairs of benchmark and
+// measure without analytic value are returned in skipped.
+func ValidateEstimators(ctx context.Context, p Parameters, benchmarks, measures []string, samples, dims, runs int, r progress.Reporter) (results []ValidationResult, skipped []string, err error) {
+	r = progress.OrNone(r)
+	if samples < 2 {
+		return nil, nil, fmt.Errorf("the number of samples must be at least 2, got %d", samples)
+	}
+	if dims < 1 {
+		return nil, nil, fmt.Errorf("the number of columns must be at least 1, got %d", dims)
+	}
+	if runs < 2 {
+		return nil, nil, fmt.Errorf("the number of runs must be at least 2, got %d", runs)
+	}
+	for _, b := range benchmarks {
+		if isKnownBenchmark(b) == false {
+			return nil, nil, fmt.Errorf("unknown benchmark %q, use one of %s", b, strings.Join(Benchmarks, ", "))
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "gomi-validate")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	p.GlobalFile = filepath.Join(dir, "data.csv")
+	p.WFile, p.SFile, p.AFile = "", "", ""
+	p.WIndices, p.SIndices, p.AIndices = columnRange(0, dims), columnRange(dims, dims), columnRange(2*dims, dims)
+	p.TimeIndex = -1
+	p.Output = filepath.Join(dir, "out.txt")
+	p.LogData = true
+	p.Verbose = false
+	p.Reference = ReferenceNone
+
+	for _, b := range benchmarks {
+		analytic, err := p.benchmarkValues(ctx, b, dims)
+		if err != nil {
+			return nil, nil, err
+		}
+		var selected []ValidationResult
+		for _, m := range measures {
+			v, ok := analytic[m]
+			if ok == false {
+				skipped = append(skipped, fmt.Sprintf("%s on %s", m, b))
+				continue
+			}
+			selected = append(selected, ValidationResult{Benchmark: b, Measure: m, Analytic: v})
+		}
+		if len(selected) == 0 {
+			continue
+		}
+
+		stage := fmt.Sprintf("validate %s", b)
+		r.Report(stage, 0, runs)
+		for run := 0; run < runs; run++ {
+			if err := writeBenchmark(p.GlobalFile, b, p.Random().Stream(stage, run), samples, dims); err != nil {
+				return nil, nil, err
+			}
+			for i := range selected {
+				e, err := p.estimate(ctx, selected[i].Measure)
+				if err != nil {
+					return nil, nil, err
+				}
+				selected[i].Estimates = append(selected[i].Estimates, e)
+			}
+			r.Report(stage, run+1, runs)
+		}
+		for i := range selected {
+			selected[i].summarise()
+		}
+		results = append(results, selected...)
+	}
+	return results, skipped, nil
+}
+
+func columnRange(start, n int) []int {
+	r := make([]int, n, n)
+	for i := range r {
+		r[i] = start + i
+	}
+	return r
+}
+
+// estimate calculates the measure on the data file of p and returns the
+// averaged result
+func (p Parameters) estimate(ctx context.Context, measure string) (float64, error) {
+	p.MeasureName = measure
+	if err := p.Validate(); err != nil {
+		return 0.0, err
+	}
+	var data Data
+	data.Read(p)
+	if err := Calculate(ctx, p, data, nil); err != nil {
+		return 0.0, err
+	}
+	o, err := ImportJSON(jsonFilename(p.Output))
+	if err != nil {
+		return 0.0, err
+	}
+	if o.Result == nil || o.Result.Average == nil {
+		return 0.0, fmt.Errorf("measure %s has no averaged result", measure)
+	}
+	return *o.Result.Average, nil
+}
+
+func (v *ValidationResult) summarise() {
+	n := float64(len(v.Estimates))
+	v.Mean, v.Variance, v.RMSE = 0.0, 0.0, 0.0
+	for _, e := range v.Estimates {
+		v.Mean += e
+		v.RMSE += (e - v.Analytic) * (e - v.Analytic)
+	}
+	v.Mean /= n
+	v.RMSE = math.Sqrt(v.RMSE / n)
+	for _, e := range v.Estimates {
+		v.Variance += (e - v.Mean) * (e - v.Mean)
+	}
+	v.Variance /= n - 1.0
+	v.Bias = v.Mean - v.Analytic
+}
+
+// writeBenchmark writes a sample of the benchmark with dims independent
+// loops to filename. The columns are w_1..w_dims, s_1..s_dims, a_1..a_dims.
+func writeBenchmark(filename, benchmark string, rng *rand.Rand, samples, dims int) error {
+	series := make([]simulate.Series, dims, dims)
+	for j := range series {
+		var err error
+		switch benchmark {
+		case BenchmarkGaussian:
+			series[j], err = benchmarkGaussian.Simulate(rng, samples)
+		case BenchmarkBinary:
+			series[j] = benchmarkBinary.Simulate(rng, samples)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	row := make([]string, 3*dims, 3*dims)
+	for t := 0; t < samples; t++ {
+		for j, s := range series {
+			row[j] = strconv.FormatFloat(s.W[t], 'g', -1, 64)
+			row[dims+j] = strconv.FormatFloat(s.S[t], 'g', -1, 64)
+			row[2*dims+j] = strconv.FormatFloat(s.A[t], 'g', -1, 64)
+		}
+		w.WriteString(strings.Join(row, ","))
+		w.WriteString("\n")
+	}
+	return w.Flush()
+}
+
+// benchmarkValues returns the analytic values of the measures on the
+// benchmark with dims independent loops in p.Unit. Information is additive
+// over independent loops. The ratio MI_A_Prime is 1 - MI_A / I(W';W,A) for
+// continuous and 1 - MI_A / log|W| for discrete data, and MI_IN depends on
+// log|A|. The values of CA and CW are only known for one binary loop with
+// two bins.
+func (p Parameters) benchmarkValues(ctx context.Context, benchmark string, dims int) (map[string]float64, error) {
+	var values []simulate.Value
+	var unit string
+	var err error
+	switch benchmark {
+	case BenchmarkGaussian:
+		values, err = benchmarkGaussian.Analytic()
+		unit = UnitNats
+	case BenchmarkBinary:
+		values, err = benchmarkBinary.Analytic(ctx, p.stopping())
+		unit = UnitBits
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := float64(dims)
+	r := map[string]float64{}
+	for _, v := range values {
+		switch v.Measure {
+		case "MI_A_Prime", "MI_IN", "CA", "CW":
+			continue
+		}
+		r[v.Measure] = ConvertUnit(d*v.Value, unit, p.Unit)
+	}
+
+	wBins := columnBins(p.WBins, p.GlobalBins, dims)
+	aBins := columnBins(p.ABins, p.GlobalBins, dims)
+	sBins := columnBins(p.SBins, p.GlobalBins, dims)
+	for _, v := range values {
+		switch {
+		case v.Measure == "MI_A_Prime" && benchmark == BenchmarkGaussian && p.UseContinuous:
+			r[v.Measure] = v.Value
+		case v.Measure == "MI_A_Prime" && benchmark == BenchmarkBinary && p.UseContinuous == false:
+			if w, err := alphabetSize("W", wBins); err == nil && w > 1 {
+				// MI_A_Prime = 1 - MI_A / log2(2) of one loop
+				r[v.Measure] = 1.0 - d*(1.0-v.Value)/math.Log2(float64(w))
+			}
+		case v.Measure == "MI_IN" && p.UseContinuous == false:
+			if a, err := alphabetSize("A", aBins); err == nil {
+				// MI_IN = log2(2) - I(A;S) of one loop
+				r[v.Measure] = ConvertUnit(math.Log2(float64(a))-d*(1.0-v.Value), UnitBits, p.Unit)
+			}
+		case (v.Measure == "CA" || v.Measure == "CW") && p.UseContinuous == false &&
+			dims == 1 && sBins[0] == 2 && aBins[0] == 2:
+			if v.Dimensionless {
+				r[v.Measure] = v.Value
+			} else {
+				r[v.Measure] = ConvertUnit(v.Value, unit, p.Unit)
+			}
+		}
+	}
+	return r, nil
+}
+
+// ValidationTable returns the results as a table with one row per benchmark
+// and measure
+func ValidationTable(results []ValidationResult) string {
+	s := fmt.Sprintf("%-10s %-12s %12s %12s %12s %12s %12s\n", "benchmark", "measure", "analytic", "mean", "bias", "variance", "rmse")
+	for _, v := range results {
+		s = fmt.Sprintf("%s%-10s %-12s %12.6f %12.6f %12.6f %12.6f %12.6f\n", s, v.Benchmark, v.Measure, v.Analytic, v.Mean, v.Bias, v.Variance, v.RMSE)
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package gomi
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestValidateEstimators(t *testing.T) {
+	tests := []struct {
+		name       string
+		continuous bool
+		benchmark  string
+		measures   []string
+		results    int
+		skipped    int
+	}{
+		{"discrete binary", false, BenchmarkBinary, []string{"MI_W", "MI_SY"}, 2, 0},
+		{"continuous gaussian", true, BenchmarkGaussian, []string{"MI_A", "MI_SY"}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CreateParametersContainer()
+			p.GlobalBins = 2
+			p.UseContinuous = tt.continuous
+			p.ContinuousMode = 3
+			results, skipped, err := ValidateEstimators(context.Background(), p, []string{tt.benchmark}, tt.measures, 2000, 1, 3, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != tt.results || len(skipped) != tt.skipped {
+				t.Fatalf("ValidateEstimators() returned %d results and %d skipped, want %d and %d", len(results), len(skipped), tt.results, tt.skipped)
+			}
+			for _, v := range results {
+				if len(v.Estimates) != 3 {
+					t.Errorf("%s: %d estimates, want 3", v.Measure, len(v.Estimates))
+				}
+				if math.Abs(v.Bias) > 0.05 {
+					t.Errorf("%s: bias %v of the estimate %v of %v is too large", v.Measure, v.Bias, v.Mean, v.Analytic)
+				}
+			}
+		})
+	}
+}
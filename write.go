@@ -6,14 +6,40 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/kzahedi/gomi/discrete"
 )
 
+// jsonFilename returns the name of the JSON file that is written next to the
+// output file, if the data is logged
+func jsonFilename(output string) string {
+	name := strings.TrimSuffix(output, filepath.Ext(output))
+	return fmt.Sprintf("%s.json", name)
+}
+
+// writeOutputAvg writes the averaged result, which is converted from the
+// native unit of the measure to p.Unit (see Parameters.inUnit)
 func writeOutputAvg(p Parameters, result float64, label string, output Output) {
-	str := fmt.Sprintf("%s\n%f", p.GenerateString("# "), result)
+	result = p.inUnit(result)
+	output.convertNormaliser(p.Unit)
+	str := p.GenerateString("# ")
+	for _, c := range []string{output.convergenceString("# "), output.normaliserString("# ")} {
+		if c != "" {
+			str = fmt.Sprintf("%s\n%s", str, c)
+		}
+	}
+	if p.useReference() {
+		str = fmt.Sprintf("%s\n%s\n# Normalised value:          %f", str, p.referenceString("# "), p.normalise(result))
+	}
+	str = fmt.Sprintf("%s\n%f", str, result)
 
 	if p.Verbose {
 		fmt.Println(fmt.Sprintf("Result of %s is %f", label, result))
+		if p.useReference() {
+			fmt.Println(fmt.Sprintf("Normalised result of %s is %f", label, p.normalise(result)))
+		}
 	}
 
 	file, err := os.Create(p.Output)
@@ -26,25 +52,210 @@ func writeOutputAvg(p Parameters, result float64, label string, output Output) {
 	w.WriteString(str)
 
 	if p.LogData {
-		name := strings.TrimSuffix(p.Output, filepath.Ext(p.Output))
-		name = fmt.Sprintf("%s.json", name)
+		name := jsonFilename(p.Output)
 		output.SetAvgResult(result)
+		output.SetNormalisedResult(p, result)
 		output.SetParameters(p)
 		output.SetDate()
 		output.ExportJSON(name)
 	}
 }
 
-func writeOutputSD(p Parameters, result []float64, label string, output Output) {
+// writeOutputRow writes the values of a measure with several components
+// (e.g. the atoms of PID) as a table with one row. If a reference is
+// selected, the normalised values follow in the columns with the suffix
+// _normalised. The values must be given in p.Unit (see Parameters.inUnit)
+// and the result of the JSON output must be set by the caller.
+func writeOutputRow(p Parameters, columns []string, values []float64, label string, output Output) {
+	str := p.GenerateString("# ")
+	if c := output.convergenceString("# "); c != "" {
+		str = fmt.Sprintf("%s\n%s", str, c)
+	}
+	if p.useReference() {
+		str = fmt.Sprintf("%s\n%s", str, p.referenceString("# "))
+		n := len(values)
+		for i := 0; i < n; i++ {
+			columns = append(columns, fmt.Sprintf("%s_normalised", columns[i]))
+			values = append(values, p.normalise(values[i]))
+		}
+	}
+	row := make([]string, len(values), len(values))
+	parts := make([]string, len(values), len(values))
+	for i, v := range values {
+		row[i] = fmt.Sprintf("%f", v)
+		parts[i] = fmt.Sprintf("%s %f", columns[i], v)
+	}
+	str = fmt.Sprintf("%s\n# %s\n%s", str, strings.Join(columns, ","), strings.Join(row, ","))
+
+	if p.Verbose {
+		fmt.Println(fmt.Sprintf("Result of %s is %s", label, strings.Join(parts, ", ")))
+	}
+
+	file, err := os.Create(p.Output)
+	defer file.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := bufio.NewWriter(file)
+	defer w.Flush()
+	w.WriteString(str)
+
+	if p.LogData {
+		name := jsonFilename(p.Output)
+		if output.Result != nil && output.Result.Average != nil {
+			output.SetNormalisedResult(p, *output.Result.Average)
+		}
+		output.SetParameters(p)
+		output.SetDate()
+		output.ExportJSON(name)
+	}
+}
+
+// writeOutputHierarchy writes the contributions of the interaction orders of
+// W, S, and A (see discrete.Hierarchy) and their synergy
+func writeOutputHierarchy(p Parameters, h discrete.Hierarchy, label string, output Output) {
+	h = discrete.Hierarchy{Individual: p.inUnit(h.Individual), Pairwise: p.inUnit(h.Pairwise), Triple: p.inUnit(h.Triple)}
+	output.SetHierarchyResult(h)
+	writeOutputRow(p, []string{"individual", "pairwise", "triple", "synergistic"},
+		[]float64{h.Individual, h.Pairwise, h.Triple, h.Synergy()}, label, output)
+}
+
+// pointwiseTable returns the header and the rows of the state-dependent
+// output. The local value result[t] of the measure belongs to the row t of
+// the input data (rows are counted from 0, without comments and header),
+// e.g. the pair (w[t+1], w[t]) of MI_W. The samples of a query (and of TE
+// and AIS) start at the row of the earliest term, so that their rows are
+// shifted to the row of time t (or the nearest row of a term, if t is not
+// within the offsets of the query). Each row contains the row index,
+// the timestamp (if given, see Parameters.TimeIndex), the local value, the
+// normalised local value (if a reference is selected), and, if p.WriteValues
+// is set, the raw and (for discrete measures) the
+// discretised values of W', W, S, and A that the measure uses (of each term
+// for queries).
+func pointwiseTable(p Parameters, data Data, result []float64) (string, []string) {
+	spec, _ := p.lookupMeasure()
+	header := []string{"row"}
+	if len(data.Time) > 0 {
+		header = append(header, "time")
+	}
+	if isQuery(p.MeasureName) {
+		// the query may contain commas
+		header = append(header, "value")
+	} else {
+		header = append(header, p.MeasureName)
+	}
+	if p.useReference() {
+		header = append(header, "normalised")
+	}
+
+	type block struct {
+		name  string
+		shift int
+		raw   [][]float64
+		bins  [][]int
+	}
+	var blocks []block
+	// first is the row of the input that belongs to the first sample
+	first := 0
+	q, query, _ := p.query()
+	if query {
+		min, max := q.offsets()
+		switch {
+		case max < 0:
+			first = max - min
+		case min < 0:
+			first = -min
+		}
+	}
+	if p.WriteValues {
+		if p.UseContinuous == false {
+			data.Discretise(p)
+		}
+		raw := map[string][][]float64{"W": data.W, "S": data.S, "A": data.A}
+		bins := map[string][][]int{"W": data.Discretised.W, "S": data.Discretised.S, "A": data.Discretised.A}
+		if query {
+			// one block per term, the term with offset o of sample i is in
+			// row i + o - min
+			min, _ := q.offsets()
+			for _, t := range q.terms() {
+				blocks = append(blocks, block{strings.ToLower(t.String()), t.Offset - min, raw[t.Variable], bins[t.Variable]})
+			}
+		}
+		if query == false && spec.W {
+			blocks = append(blocks, block{"w'", 1, data.W, data.Discretised.W}, block{"w", 0, data.W, data.Discretised.W})
+		}
+		if query == false && spec.S {
+			blocks = append(blocks, block{"s", 0, data.S, data.Discretised.S})
+		}
+		if query == false && spec.A {
+			blocks = append(blocks, block{"a", 0, data.A, data.Discretised.A})
+		}
+	}
+	for _, b := range blocks {
+		if len(b.raw) > 0 {
+			for j := range b.raw[0] {
+				header = append(header, fmt.Sprintf("%s%d", b.name, j))
+			}
+		}
+	}
+	for _, b := range blocks {
+		if len(b.bins) > 0 {
+			for j := range b.bins[0] {
+				header = append(header, fmt.Sprintf("%s%d_bin", b.name, j))
+			}
+		}
+	}
+
+	rows := make([]string, len(result), len(result))
+	for t, v := range result {
+		row := []string{strconv.Itoa(t + first)}
+		if len(data.Time) > 0 {
+			row = append(row, strconv.FormatFloat(data.Time[t+first], 'g', -1, 64))
+		}
+		row = append(row, fmt.Sprintf("%f", v))
+		if p.useReference() {
+			row = append(row, fmt.Sprintf("%f", p.normalise(v)))
+		}
+		for _, b := range blocks {
+			if len(b.raw) > 0 {
+				for _, x := range b.raw[t+b.shift] {
+					row = append(row, strconv.FormatFloat(x, 'g', -1, 64))
+				}
+			}
+		}
+		for _, b := range blocks {
+			if len(b.bins) > 0 {
+				for _, x := range b.bins[t+b.shift] {
+					row = append(row, strconv.Itoa(x))
+				}
+			}
+		}
+		rows[t] = strings.Join(row, ",")
+	}
+	return strings.Join(header, ","), rows
+}
+
+// writeOutputSD writes the state-dependent result, which is converted from
+// the native unit of the measure to p.Unit (see Parameters.inUnit), as a
+// table (see pointwiseTable). The summary statistics of the local values
+// (see Summarise) are written to the header.
+func writeOutputSD(p Parameters, data Data, result []float64, label string, output Output) {
+	result = p.inUnits(result)
+	output.convertNormaliser(p.Unit)
 	avg := 0.0
 	for _, v := range result {
 		avg += v
 	}
 
 	avg /= float64(len(result))
+	summary := Summarise(result)
 
 	if p.Verbose {
 		fmt.Println(fmt.Sprintf("Averaged value: %f", avg))
+		if p.useReference() {
+			fmt.Println(fmt.Sprintf("Normalised averaged value: %f", p.normalise(avg)))
+		}
+		fmt.Println(summary.String(""))
 		n := 10
 		if n > len(result)-1 {
 			n = len(result) - 1
@@ -62,16 +273,33 @@ func writeOutputSD(p Parameters, result []float64, label string, output Output)
 
 	w.WriteString(p.GenerateString("# "))
 	w.WriteString("\n")
+	if n := output.normaliserString("# "); n != "" {
+		w.WriteString(n)
+		w.WriteString("\n")
+	}
+	if p.useReference() {
+		w.WriteString(p.referenceString("# "))
+		w.WriteString("\n")
+	}
 	w.WriteString(fmt.Sprintf("# Averaged value: %f\n", avg))
-	for _, v := range result {
-		w.WriteString(fmt.Sprintf("%f\n", v))
+	if p.useReference() {
+		w.WriteString(fmt.Sprintf("# Normalised averaged value: %f\n", p.normalise(avg)))
+	}
+	w.WriteString(summary.String("# "))
+	w.WriteString("\n")
+	header, rows := pointwiseTable(p, data, result)
+	w.WriteString(fmt.Sprintf("# %s\n", header))
+	for _, row := range rows {
+		w.WriteString(row)
+		w.WriteString("\n")
 	}
 
 	if p.LogData {
-		name := strings.TrimSuffix(p.Output, filepath.Ext(p.Output))
-		name = fmt.Sprintf("%s.json", name)
+		name := jsonFilename(p.Output)
 		output.SetAvgResult(avg)
+		output.SetNormalisedResult(p, avg)
 		output.SetPointWiseResult(result)
+		output.SetSummaryResult(summary)
 		output.SetParameters(p)
 		output.SetDate()
 		output.ExportJSON(name)
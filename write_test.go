@@ -0,0 +1,60 @@
+package gomi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPointwiseTable(t *testing.T) {
+	data := Data{
+		W:    [][]float64{{0.0}, {1.0}, {2.0}},
+		A:    [][]float64{{0.5}, {1.5}, {2.5}},
+		Time: []float64{10.0, 10.5, 11.0}}
+	tests := []struct {
+		name       string
+		set        func(p *Parameters)
+		wantHeader string
+		wantRows   []string
+	}{
+		{"index and value", func(p *Parameters) { p.UseContinuous = true },
+			"row,time,MI_W", []string{"0,10,0.100000", "1,10.5,0.200000"}},
+		{"raw values", func(p *Parameters) { p.UseContinuous = true; p.WriteValues = true },
+			"row,time,MI_W,w'0,w0,a0", []string{"0,10,0.100000,1,0,0.5", "1,10.5,0.200000,2,1,1.5"}},
+		{"discretised values", func(p *Parameters) { p.GlobalBins = 3; p.WriteValues = true },
+			"row,time,MI_W,w'0,w0,a0,w'0_bin,w0_bin,a0_bin", []string{"0,10,0.100000,1,0,0.5,1,0,0", "1,10.5,0.200000,2,1,1.5,2,1,1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CreateParametersContainer()
+			tt.set(&p)
+			header, rows := pointwiseTable(p, data, []float64{0.1, 0.2})
+			if header != tt.wantHeader {
+				t.Errorf("pointwiseTable() header = %q, want %q", header, tt.wantHeader)
+			}
+			if reflect.DeepEqual(rows, tt.wantRows) == false {
+				t.Errorf("pointwiseTable() rows = %v, want %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+// the samples of AIS with history 3 start at row 0 with W[t-2], so that the
+// first local value belongs to t = 2
+func TestPointwiseTableQuery(t *testing.T) {
+	data := Data{
+		W:    [][]float64{{0.0}, {1.0}, {2.0}, {3.0}, {4.0}},
+		Time: []float64{10.0, 10.5, 11.0, 11.5, 12.0}}
+	p := CreateParametersContainer()
+	p.UseContinuous = true
+	p.WriteValues = true
+	p.MeasureName = "AIS"
+	p.Target = "W"
+	p.History = 3
+	header, rows := pointwiseTable(p, data, []float64{0.1, 0.2})
+	if want := "row,time,AIS,w[t+1]0,w[t]0,w[t-1]0,w[t-2]0"; header != want {
+		t.Errorf("pointwiseTable() header = %q, want %q", header, want)
+	}
+	if want := []string{"2,11,0.100000,3,2,1,0", "3,11.5,0.200000,4,3,2,1"}; reflect.DeepEqual(rows, want) == false {
+		t.Errorf("pointwiseTable() rows = %v, want %v", rows, want)
+	}
+}